@@ -0,0 +1,98 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/guardian/admin"
+	"github.com/cloudfoundry-incubator/guardian/rundmc"
+)
+
+type FakeCgroupParents struct {
+	SetLimitsStub        func(parent string, limits rundmc.CgroupLimits) error
+	setLimitsMutex       sync.RWMutex
+	setLimitsArgsForCall []struct {
+		parent string
+		limits rundmc.CgroupLimits
+	}
+	setLimitsReturns struct {
+		result1 error
+	}
+	LimitsStub        func(parent string) (rundmc.CgroupLimits, error)
+	limitsMutex       sync.RWMutex
+	limitsArgsForCall []struct {
+		parent string
+	}
+	limitsReturns struct {
+		result1 rundmc.CgroupLimits
+		result2 error
+	}
+}
+
+func (fake *FakeCgroupParents) SetLimits(parent string, limits rundmc.CgroupLimits) error {
+	fake.setLimitsMutex.Lock()
+	fake.setLimitsArgsForCall = append(fake.setLimitsArgsForCall, struct {
+		parent string
+		limits rundmc.CgroupLimits
+	}{parent, limits})
+	fake.setLimitsMutex.Unlock()
+	if fake.SetLimitsStub != nil {
+		return fake.SetLimitsStub(parent, limits)
+	} else {
+		return fake.setLimitsReturns.result1
+	}
+}
+
+func (fake *FakeCgroupParents) SetLimitsCallCount() int {
+	fake.setLimitsMutex.RLock()
+	defer fake.setLimitsMutex.RUnlock()
+	return len(fake.setLimitsArgsForCall)
+}
+
+func (fake *FakeCgroupParents) SetLimitsArgsForCall(i int) (string, rundmc.CgroupLimits) {
+	fake.setLimitsMutex.RLock()
+	defer fake.setLimitsMutex.RUnlock()
+	return fake.setLimitsArgsForCall[i].parent, fake.setLimitsArgsForCall[i].limits
+}
+
+func (fake *FakeCgroupParents) SetLimitsReturns(result1 error) {
+	fake.SetLimitsStub = nil
+	fake.setLimitsReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeCgroupParents) Limits(parent string) (rundmc.CgroupLimits, error) {
+	fake.limitsMutex.Lock()
+	fake.limitsArgsForCall = append(fake.limitsArgsForCall, struct {
+		parent string
+	}{parent})
+	fake.limitsMutex.Unlock()
+	if fake.LimitsStub != nil {
+		return fake.LimitsStub(parent)
+	} else {
+		return fake.limitsReturns.result1, fake.limitsReturns.result2
+	}
+}
+
+func (fake *FakeCgroupParents) LimitsCallCount() int {
+	fake.limitsMutex.RLock()
+	defer fake.limitsMutex.RUnlock()
+	return len(fake.limitsArgsForCall)
+}
+
+func (fake *FakeCgroupParents) LimitsArgsForCall(i int) string {
+	fake.limitsMutex.RLock()
+	defer fake.limitsMutex.RUnlock()
+	return fake.limitsArgsForCall[i].parent
+}
+
+func (fake *FakeCgroupParents) LimitsReturns(result1 rundmc.CgroupLimits, result2 error) {
+	fake.LimitsStub = nil
+	fake.limitsReturns = struct {
+		result1 rundmc.CgroupLimits
+		result2 error
+	}{result1, result2}
+}
+
+var _ admin.CgroupParents = new(FakeCgroupParents)
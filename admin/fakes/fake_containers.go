@@ -0,0 +1,93 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/guardian/admin"
+)
+
+type FakeContainers struct {
+	PauseStub        func(handle string) error
+	pauseMutex       sync.RWMutex
+	pauseArgsForCall []struct {
+		handle string
+	}
+	pauseReturns struct {
+		result1 error
+	}
+	ResumeStub        func(handle string) error
+	resumeMutex       sync.RWMutex
+	resumeArgsForCall []struct {
+		handle string
+	}
+	resumeReturns struct {
+		result1 error
+	}
+}
+
+func (fake *FakeContainers) Pause(handle string) error {
+	fake.pauseMutex.Lock()
+	fake.pauseArgsForCall = append(fake.pauseArgsForCall, struct {
+		handle string
+	}{handle})
+	fake.pauseMutex.Unlock()
+	if fake.PauseStub != nil {
+		return fake.PauseStub(handle)
+	} else {
+		return fake.pauseReturns.result1
+	}
+}
+
+func (fake *FakeContainers) PauseCallCount() int {
+	fake.pauseMutex.RLock()
+	defer fake.pauseMutex.RUnlock()
+	return len(fake.pauseArgsForCall)
+}
+
+func (fake *FakeContainers) PauseArgsForCall(i int) string {
+	fake.pauseMutex.RLock()
+	defer fake.pauseMutex.RUnlock()
+	return fake.pauseArgsForCall[i].handle
+}
+
+func (fake *FakeContainers) PauseReturns(result1 error) {
+	fake.PauseStub = nil
+	fake.pauseReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeContainers) Resume(handle string) error {
+	fake.resumeMutex.Lock()
+	fake.resumeArgsForCall = append(fake.resumeArgsForCall, struct {
+		handle string
+	}{handle})
+	fake.resumeMutex.Unlock()
+	if fake.ResumeStub != nil {
+		return fake.ResumeStub(handle)
+	} else {
+		return fake.resumeReturns.result1
+	}
+}
+
+func (fake *FakeContainers) ResumeCallCount() int {
+	fake.resumeMutex.RLock()
+	defer fake.resumeMutex.RUnlock()
+	return len(fake.resumeArgsForCall)
+}
+
+func (fake *FakeContainers) ResumeArgsForCall(i int) string {
+	fake.resumeMutex.RLock()
+	defer fake.resumeMutex.RUnlock()
+	return fake.resumeArgsForCall[i].handle
+}
+
+func (fake *FakeContainers) ResumeReturns(result1 error) {
+	fake.ResumeStub = nil
+	fake.resumeReturns = struct {
+		result1 error
+	}{result1}
+}
+
+var _ admin.Containers = new(FakeContainers)
@@ -0,0 +1,122 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/guardian/rundmc"
+	"github.com/pivotal-golang/lager"
+)
+
+//go:generate counterfeiter . CgroupParents
+type CgroupParents interface {
+	SetLimits(parent string, limits rundmc.CgroupLimits) error
+	Limits(parent string) (rundmc.CgroupLimits, error)
+}
+
+//go:generate counterfeiter . Containers
+type Containers interface {
+	Pause(handle string) error
+	Resume(handle string) error
+}
+
+// Handler serves the admin API, which operators use for what the Garden API
+// does not offer:
+//
+//	GET  /cgroup-parents/<name>/limits
+//	PUT  /cgroup-parents/<name>/limits
+//	POST /containers/<handle>/pause
+//	POST /containers/<handle>/resume
+//
+// Limits are JSON encoded rundmc.CgroupLimits.
+type Handler struct {
+	CgroupParents CgroupParents
+	Containers    Containers
+	Logger        lager.Logger
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log := h.Logger.Session("admin", lager.Data{"method": r.Method, "path": r.URL.Path})
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) != 3 {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch {
+	case parts[0] == "cgroup-parents" && parts[2] == "limits":
+		h.limits(log, w, r, parts[1])
+	case parts[0] == "containers" && (parts[2] == "pause" || parts[2] == "resume"):
+		h.pause(log, w, r, parts[1], parts[2])
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (h *Handler) limits(log lager.Logger, w http.ResponseWriter, r *http.Request, parent string) {
+	switch r.Method {
+	case "GET":
+		limits, err := h.CgroupParents.Limits(parent)
+		if os.IsNotExist(err) {
+			http.Error(w, "cgroup parent "+parent+" does not exist", http.StatusNotFound)
+			return
+		}
+
+		if err != nil {
+			log.Error("get-limits-failed", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		writeJSON(w, limits)
+
+	case "PUT":
+		var limits rundmc.CgroupLimits
+		if err := json.NewDecoder(r.Body).Decode(&limits); err != nil {
+			http.Error(w, "invalid limits: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := h.CgroupParents.SetLimits(parent, limits); err != nil {
+			log.Error("set-limits-failed", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		log.Info("set-limits", lager.Data{"limits": limits})
+		writeJSON(w, limits)
+
+	default:
+		w.Header().Set("Allow", "GET, PUT")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *Handler) pause(log lager.Logger, w http.ResponseWriter, r *http.Request, handle, action string) {
+	if r.Method != "POST" {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	do := h.Containers.Pause
+	if action == "resume" {
+		do = h.Containers.Resume
+	}
+
+	if err := do(handle); err != nil {
+		log.Error(action+"-failed", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
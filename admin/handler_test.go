@@ -0,0 +1,133 @@
+package admin_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/guardian/admin"
+	"github.com/cloudfoundry-incubator/guardian/admin/fakes"
+	"github.com/cloudfoundry-incubator/guardian/rundmc"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/lager/lagertest"
+)
+
+var _ = Describe("Handler", func() {
+	var (
+		fakeCgroupParents *fakes.FakeCgroupParents
+		fakeContainers    *fakes.FakeContainers
+		handler           *admin.Handler
+	)
+
+	BeforeEach(func() {
+		fakeCgroupParents = new(fakes.FakeCgroupParents)
+		fakeContainers = new(fakes.FakeContainers)
+		handler = &admin.Handler{
+			CgroupParents: fakeCgroupParents,
+			Containers:    fakeContainers,
+			Logger:        lagertest.NewTestLogger("test"),
+		}
+	})
+
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, path, strings.NewReader(body))
+		Expect(err).NotTo(HaveOccurred())
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	Describe("GET /cgroup-parents/<name>/limits", func() {
+		It("returns the parent's limits", func() {
+			fakeCgroupParents.LimitsReturns(rundmc.CgroupLimits{MemoryLimitInBytes: 1024, CPUShares: 512}, nil)
+
+			response := serve("GET", "/cgroup-parents/some-org/limits", "")
+			Expect(response.Code).To(Equal(http.StatusOK))
+			Expect(response.Body.String()).To(MatchJSON(`{"memory_limit_in_bytes": 1024, "cpu_shares": 512}`))
+			Expect(fakeCgroupParents.LimitsArgsForCall(0)).To(Equal("some-org"))
+		})
+
+		It("returns 404 when the parent does not exist", func() {
+			fakeCgroupParents.LimitsReturns(rundmc.CgroupLimits{}, &os.PathError{Op: "open", Path: "memory.limit_in_bytes", Err: os.ErrNotExist})
+			Expect(serve("GET", "/cgroup-parents/some-org/limits", "").Code).To(Equal(http.StatusNotFound))
+		})
+
+		It("returns 500 when the limits cannot be read", func() {
+			fakeCgroupParents.LimitsReturns(rundmc.CgroupLimits{}, errors.New("boom"))
+			Expect(serve("GET", "/cgroup-parents/some-org/limits", "").Code).To(Equal(http.StatusInternalServerError))
+		})
+	})
+
+	Describe("PUT /cgroup-parents/<name>/limits", func() {
+		It("sets the parent's limits", func() {
+			response := serve("PUT", "/cgroup-parents/some-org/limits", `{"memory_limit_in_bytes": 1024, "cpu_shares": 512}`)
+			Expect(response.Code).To(Equal(http.StatusOK))
+
+			parent, limits := fakeCgroupParents.SetLimitsArgsForCall(0)
+			Expect(parent).To(Equal("some-org"))
+			Expect(limits).To(Equal(rundmc.CgroupLimits{MemoryLimitInBytes: 1024, CPUShares: 512}))
+		})
+
+		It("returns 400 when the limits are not valid JSON", func() {
+			Expect(serve("PUT", "/cgroup-parents/some-org/limits", `{`).Code).To(Equal(http.StatusBadRequest))
+			Expect(fakeCgroupParents.SetLimitsCallCount()).To(Equal(0))
+		})
+
+		It("returns 500 when the limits cannot be set", func() {
+			fakeCgroupParents.SetLimitsReturns(errors.New("boom"))
+
+			response := serve("PUT", "/cgroup-parents/some-org/limits", `{"cpu_shares": 512}`)
+			Expect(response.Code).To(Equal(http.StatusInternalServerError))
+			Expect(response.Body.String()).To(ContainSubstring("boom"))
+		})
+	})
+
+	Describe("POST /containers/<handle>/pause", func() {
+		It("pauses the container", func() {
+			Expect(serve("POST", "/containers/some-handle/pause", "").Code).To(Equal(http.StatusNoContent))
+			Expect(fakeContainers.PauseCallCount()).To(Equal(1))
+			Expect(fakeContainers.PauseArgsForCall(0)).To(Equal("some-handle"))
+			Expect(fakeContainers.ResumeCallCount()).To(Equal(0))
+		})
+
+		It("returns 500 when the container cannot be paused", func() {
+			fakeContainers.PauseReturns(errors.New("runc pause: exit status 1"))
+
+			response := serve("POST", "/containers/some-handle/pause", "")
+			Expect(response.Code).To(Equal(http.StatusInternalServerError))
+			Expect(response.Body.String()).To(ContainSubstring("runc pause: exit status 1"))
+		})
+
+		It("returns 405 for other methods", func() {
+			response := serve("GET", "/containers/some-handle/pause", "")
+			Expect(response.Code).To(Equal(http.StatusMethodNotAllowed))
+			Expect(response.Header().Get("Allow")).To(Equal("POST"))
+			Expect(fakeContainers.PauseCallCount()).To(Equal(0))
+		})
+	})
+
+	Describe("POST /containers/<handle>/resume", func() {
+		It("resumes the container", func() {
+			Expect(serve("POST", "/containers/some-handle/resume", "").Code).To(Equal(http.StatusNoContent))
+			Expect(fakeContainers.ResumeCallCount()).To(Equal(1))
+			Expect(fakeContainers.ResumeArgsForCall(0)).To(Equal("some-handle"))
+			Expect(fakeContainers.PauseCallCount()).To(Equal(0))
+		})
+	})
+
+	It("returns 405 for other methods", func() {
+		response := serve("DELETE", "/cgroup-parents/some-org/limits", "")
+		Expect(response.Code).To(Equal(http.StatusMethodNotAllowed))
+		Expect(response.Header().Get("Allow")).To(Equal("GET, PUT"))
+	})
+
+	It("returns 404 for other paths", func() {
+		Expect(serve("GET", "/cgroup-parents/some-org", "").Code).To(Equal(http.StatusNotFound))
+		Expect(serve("GET", "/cgroup-parents/some/org/limits", "").Code).To(Equal(http.StatusNotFound))
+		Expect(serve("POST", "/containers/some-handle/stop", "").Code).To(Equal(http.StatusNotFound))
+	})
+})
@@ -1,10 +1,13 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net"
+	"net/http"
 	"os"
 	"os/signal"
 	"path"
@@ -22,6 +25,7 @@ import (
 	"github.com/cloudfoundry-incubator/garden-shed/rootfs_provider"
 	"github.com/cloudfoundry-incubator/garden/server"
 	"github.com/cloudfoundry-incubator/goci"
+	"github.com/cloudfoundry-incubator/guardian/admin"
 	"github.com/cloudfoundry-incubator/guardian/gardener"
 	"github.com/cloudfoundry-incubator/guardian/kawasaki"
 	"github.com/cloudfoundry-incubator/guardian/kawasaki/factory"
@@ -31,12 +35,14 @@ import (
 	"github.com/cloudfoundry-incubator/guardian/logging"
 	"github.com/cloudfoundry-incubator/guardian/netplugin"
 	"github.com/cloudfoundry-incubator/guardian/pkg/vars"
+	"github.com/cloudfoundry-incubator/guardian/preflight"
 	"github.com/cloudfoundry-incubator/guardian/properties"
 	"github.com/cloudfoundry-incubator/guardian/rundmc"
 	"github.com/cloudfoundry-incubator/guardian/rundmc/bundlerules"
 	"github.com/cloudfoundry-incubator/guardian/rundmc/depot"
 	"github.com/cloudfoundry-incubator/guardian/rundmc/process_tracker"
 	"github.com/cloudfoundry-incubator/guardian/rundmc/runrunc"
+	"github.com/cloudfoundry-incubator/guardian/rundmc/streamin"
 	"github.com/cloudfoundry-incubator/guardian/sysinfo"
 	"github.com/cloudfoundry/gunk/command_runner/linux_command_runner"
 	"github.com/docker/docker/daemon/graphdriver"
@@ -52,28 +58,10 @@ import (
 	"github.com/pivotal-golang/localip"
 )
 
-const OciStateDir = "/var/run/opencontainer/containers"
-
-var DefaultCapabilities = []string{
-	"CAP_CHOWN",
-	"CAP_DAC_OVERRIDE",
-	"CAP_FSETID",
-	"CAP_FOWNER",
-	"CAP_MKNOD",
-	"CAP_NET_RAW",
-	"CAP_SETGID",
-	"CAP_SETUID",
-	"CAP_SETFCAP",
-	"CAP_SETPCAP",
-	"CAP_NET_BIND_SERVICE",
-	"CAP_SYS_CHROOT",
-	"CAP_KILL",
-	"CAP_AUDIT_WRITE",
-}
+const notifySocketName = "notify.sock"
 
-var PrivilegedContainerNamespaces = []specs.Namespace{
-	goci.NetworkNamespace, goci.PIDNamespace, goci.UTSNamespace, goci.IPCNamespace, goci.MountNamespace,
-}
+// OciStateDir is where runc keeps the state of its containers by default
+const OciStateDir = "/var/run/opencontainer/containers"
 
 var listenNetwork = flag.String(
 	"listenNetwork",
@@ -93,22 +81,34 @@ var binPath = flag.String(
 	"directory containing backend-specific scripts (i.e. ./create.sh)",
 )
 
-var iodaemonBin = flag.String(
-	"iodaemonBin",
-	"",
-	"path to iodaemon binary",
+var runtimePath = flag.String(
+	"runtimePath",
+	"runc",
+	"path to the OCI runtime, or its name on the PATH; it must accept the command line of runc "+runrunc.SupportedVersions,
+)
+
+var runtimeLogLines = flag.Int(
+	"runtimeLogLines",
+	100,
+	"number of lines the OCI runtime logged about each container to keep in its depot directory, for post-mortems",
 )
 
-var nstarBin = flag.String(
-	"nstarBin",
+var runtimeRoot = flag.String(
+	"runtimeRoot",
 	"",
-	"path to nstar binary",
+	"directory in which the OCI runtime keeps the state of containers (defaults to "+OciStateDir+"-<tag>, or "+OciStateDir+" without a tag)",
 )
 
-var tarBin = flag.String(
-	"tarBin",
+var iodaemonBin = flag.String(
+	"iodaemonBin",
 	"",
-	"path to tar binary",
+	"path to iodaemon binary",
+)
+
+var processReplaySize = flag.Int(
+	"processReplaySize",
+	0,
+	"number of bytes of each process's most recent stdout and stderr to replay to clients which attach to it late; older output is dropped (0 disables replay)",
 )
 
 var kawasakiBin = flag.String(
@@ -123,6 +123,83 @@ var initBin = flag.String(
 	"path to process used as pid 1 inside container",
 )
 
+var bundleProfiles = flag.String(
+	"bundleProfiles",
+	"",
+	"JSON file describing the namespaces, capabilities, devices and mounts of privileged and unprivileged containers (defaults to the built-in profiles)")
+
+var seccompProfile = flag.String(
+	"seccompProfile",
+	"",
+	"docker-compatible JSON seccomp profile applied to containers (defaults to a built-in profile)")
+
+var apparmorProfile = flag.String(
+	"apparmorProfile",
+	"",
+	"AppArmor profile to confine unprivileged containers with (defaults to none, or to the bundled profile when -loadDefaultAppArmorProfile is set)")
+
+var loadDefaultAppArmorProfile = flag.Bool(
+	"loadDefaultAppArmorProfile",
+	false,
+	"load the bundled "+rundmc.DefaultAppArmorProfileName+" AppArmor profile at startup")
+
+var selinuxProcessLabel = flag.String(
+	"selinuxProcessLabel",
+	"",
+	"SELinux label to run container processes with")
+
+var selinuxMountLabel = flag.String(
+	"selinuxMountLabel",
+	"",
+	"SELinux label to give container mounts")
+
+var scratchMountSize = flag.String(
+	"scratchMountSize",
+	"64m",
+	"default size of the tmpfs mounts which containers request with the "+bundlerules.ScratchMountsProperty+" property")
+
+var shmSize = flag.String(
+	"shmSize",
+	"64m",
+	"default size of each container's /dev/shm, which containers may override with the "+bundlerules.ShmSizeProperty+" property")
+
+var cpuPool = flag.String(
+	"cpuPool",
+	"",
+	"CPUs, e.g. 4-15, to hand out exclusively to containers which ask for them with the "+gardener.CPUSetExclusiveCPUsKey+" property")
+
+var cgroupParentProperty = flag.String(
+	"cgroupParentProperty",
+	"",
+	"container property, e.g. org-guid, whose value names a parent cgroup to place the container in, so that the parent's limits apply to all of its containers")
+
+var cgroupParentLimitsDir = flag.String(
+	"cgroupParentLimitsDir",
+	"",
+	"directory in which to keep the limits of parent cgroups, so that they are applied again when a parent is re-created (defaults to a directory under the system's temporary directory)")
+
+var adminListenNetwork = flag.String(
+	"adminListenNetwork",
+	"unix",
+	"how to listen on the admin API address (unix, tcp, etc.)",
+)
+
+var adminListenAddr = flag.String(
+	"adminListenAddr",
+	"",
+	"address to serve the admin API, which sets the limits of parent cgroups and pauses containers, on (defaults to not serving it)",
+)
+
+var autoResume = flag.Bool(
+	"autoResume",
+	false,
+	"resume paused containers when a process is run in them or files are streamed in or out, rather than failing")
+
+var initReadyString = flag.String(
+	"initReadyString",
+	"Pid 1 Running",
+	"for compatibility with init binaries which do not use the notify socket, also treat this string on init's stdout as readiness (empty to disable)")
+
 var networkPlugin = flag.String(
 	"networkPlugin",
 	"",
@@ -238,11 +315,34 @@ var maxContainers = flag.Uint(
 	0,
 	"Maximum number of containers that can be created")
 
+var streamInRequestByteLimit = flag.Uint64(
+	"streamInRequestByteLimit",
+	0,
+	"Maximum number of bytes a single StreamIn may extract (0 for unlimited)")
+
+var streamInRequestEntryLimit = flag.Uint64(
+	"streamInRequestEntryLimit",
+	0,
+	"Maximum number of archive entries a single StreamIn may extract (0 for unlimited)")
+
+var streamInContainerByteLimit = flag.Uint64(
+	"streamInContainerByteLimit",
+	0,
+	"Maximum number of bytes that may be streamed in to a container over its lifetime (0 for unlimited)")
+
+var streamInContainerEntryLimit = flag.Uint64(
+	"streamInContainerEntryLimit",
+	0,
+	"Maximum number of archive entries that may be streamed in to a container over its lifetime (0 for unlimited)")
+
 var idMappings rootfs_provider.MappingList
 
-func init() {
+// defaultIDMappings reads the host's id maps, so it must not run in the stream
+// helpers: they have entered a container's mount namespace, where /proc/self
+// does not resolve, before guardian's init functions run
+func defaultIDMappings() rootfs_provider.MappingList {
 	maxId := uint32(sysinfo.Min(sysinfo.MustGetMaxValidUID(), sysinfo.MustGetMaxValidGID()))
-	idMappings = rootfs_provider.MappingList{
+	return rootfs_provider.MappingList{
 		{
 			ContainerID: 0,
 			HostID:      maxId,
@@ -261,6 +361,8 @@ func main() {
 		return
 	}
 
+	idMappings = defaultIDMappings()
+
 	var insecureRegistries vars.StringList
 	flag.Var(
 		&insecureRegistries,
@@ -275,6 +377,48 @@ func main() {
 		"DNS server IP address to use instead of automatically determined servers. (Can be specified multiple times)",
 	)
 
+	var maskedPaths vars.StringList
+	flag.Var(
+		&maskedPaths,
+		"maskedPath",
+		"Path to hide from unprivileged containers. (Can be specified multiple times; replaces the default list)",
+	)
+
+	var readonlyPaths vars.StringList
+	flag.Var(
+		&readonlyPaths,
+		"readonlyPath",
+		"Path to make read-only in unprivileged containers. (Can be specified multiple times; replaces the default list)",
+	)
+
+	var allowedCapabilities vars.StringList
+	flag.Var(
+		&allowedCapabilities,
+		"allowedCapability",
+		"Capability which containers may request with the "+bundlerules.CapabilitiesProperty+" property. (Can be specified multiple times)",
+	)
+
+	var allowedDevices vars.StringList
+	flag.Var(
+		&allowedDevices,
+		"allowedDevice",
+		"Device, as path:type:major:minor, which containers may request with the "+bundlerules.DevicesProperty+" property. (Can be specified multiple times)",
+	)
+
+	var allowedSysctls vars.StringList
+	flag.Var(
+		&allowedSysctls,
+		"allowedSysctl",
+		"Namespaced sysctl which containers may set with the "+bundlerules.SysctlsProperty+" property; a trailing * matches any suffix. (Can be specified multiple times; replaces the default list)",
+	)
+
+	var allowedBindMountSources vars.StringList
+	flag.Var(
+		&allowedBindMountSources,
+		"allowedBindMountSource",
+		"Host directory beneath which containers may bind mount sources. (Can be specified multiple times; any host path may be bind mounted if unset)",
+	)
+
 	cf_debug_server.AddFlags(flag.CommandLine)
 	cf_lager.AddFlags(flag.CommandLine)
 	flag.Parse()
@@ -289,16 +433,33 @@ func main() {
 		missing("-iodaemonBin")
 	}
 
-	if *nstarBin == "" {
-		missing("-nstarBin")
+	if *initBin == "" {
+		missing("-initBin")
 	}
 
-	if *tarBin == "" {
-		missing("-tarBin")
-	}
+	interfacePrefix := fmt.Sprintf("g%s", *tag)
+	chainPrefix := fmt.Sprintf("g-%s-", *tag)
 
-	if *initBin == "" {
-		missing("-initBin")
+	profiles, profilesErr := loadBundleProfiles()
+	seccomp, seccompErr := loadSeccompProfile()
+	extraPrivileges, extraPrivilegesErr := loadExtraPrivileges(allowedCapabilities, allowedDevices)
+	tmpfs := bundlerules.Tmpfs{DefaultShmSize: *shmSize, DefaultSize: *scratchMountSize}
+	sysctls := wireSysctls(allowedSysctls)
+	sysInfoProvider := sysinfo.NewProvider(*depotPath)
+	cpuSetAllocator, cpuPoolErr := wireCPUSetAllocator(sysInfoProvider)
+
+	report := preflight.Run(logger, wirePreflightChecks(interfacePrefix, chainPrefix, configErrors{
+		BundleProfiles:  profilesErr,
+		SeccompProfile:  seccompErr,
+		ExtraPrivileges: extraPrivilegesErr,
+		Tmpfs:           tmpfs.Validate(),
+		Sysctls:         sysctls.Validate(),
+		CPUPool:         cpuPoolErr,
+	})...)
+	report.WriteTo(os.Stderr)
+	if report.Fatal() {
+		logger.Error("preflight-checks-failed", errors.New("fatal preflight checks failed, see the report above"))
+		os.Exit(1)
 	}
 
 	resolvedRootFSPath, err := filepath.EvalSymlinks(*rootFSPath)
@@ -321,11 +482,10 @@ func main() {
 		panic(err)
 	}
 
-	interfacePrefix := fmt.Sprintf("g%s", *tag)
-	chainPrefix := fmt.Sprintf("g-%s-", *tag)
 	ipt := wireIptables(logger, chainPrefix)
 
 	propManager := properties.NewManager()
+	cgroupParents := wireCgroupParents(logger)
 
 	var networker gardener.Networker = netplugin.New(*networkPlugin, strings.Split(*networkPluginExtraArgs, ",")...)
 	if *networkPlugin == "" {
@@ -335,11 +495,25 @@ func main() {
 	backend := &gardener.Gardener{
 		UidGenerator:    wireUidGenerator(),
 		Starter:         wireStarter(logger, ipt, *allowHostAccess, interfacePrefix, denyNetworksList),
-		SysInfoProvider: sysinfo.NewProvider(*depotPath),
+		SysInfoProvider: sysInfoProvider,
 		Networker:       networker,
 		VolumeCreator:   wireVolumeCreator(logger, *graphRoot, insecureRegistries),
-		Containerizer:   wireContainerizer(logger, *depotPath, *iodaemonBin, *nstarBin, *tarBin, resolvedRootFSPath, propManager),
+		Containerizer: wireContainerizer(logger, containerizerConfig{
+			DepotPath:               *depotPath,
+			IodaemonPath:            *iodaemonBin,
+			DefaultRootFSPath:       resolvedRootFSPath,
+			Profiles:                profiles,
+			Seccomp:                 seccomp,
+			Hardening:               wireHardening(maskedPaths, readonlyPaths),
+			ExtraPrivileges:         extraPrivileges,
+			Tmpfs:                   tmpfs,
+			Sysctls:                 sysctls,
+			AllowedBindMountSources: allowedBindMountSources,
+			CgroupParents:           cgroupParents,
+			Properties:              propManager,
+		}),
 		PropertyManager: propManager,
+		CPUSetAllocator: cpuSetAllocator,
 
 		Logger: logger,
 	}
@@ -351,6 +525,10 @@ func main() {
 		logger.Fatal("failed-to-start-server", err)
 	}
 
+	if *adminListenAddr != "" {
+		startAdminServer(logger, cgroupParents, backend)
+	}
+
 	signals := make(chan os.Signal, 1)
 
 	go func() {
@@ -369,6 +547,67 @@ func main() {
 	select {}
 }
 
+// configErrors are the results of validating the configuration loaded from
+// flags, which are reported by the preflight checks
+type configErrors struct {
+	BundleProfiles  error
+	SeccompProfile  error
+	ExtraPrivileges error
+	Tmpfs           error
+	Sysctls         error
+	CPUPool         error
+}
+
+func wirePreflightChecks(interfacePrefix, chainPrefix string, errs configErrors) []preflight.Check {
+	host := preflight.NewHost(linux_command_runner.New())
+
+	checks := []preflight.Check{
+		preflight.Flag("tag", kawasaki.ValidatePrefixes(interfacePrefix, chainPrefix), "use a tag of at most one character"),
+		preflight.Flag("bundleProfiles", errs.BundleProfiles, "fix the bundle profiles file, or omit the flag to use the built-in profiles"),
+		preflight.Flag("seccompProfile", errs.SeccompProfile, "fix the seccomp profile, or omit the flag to use the built-in profile"),
+		preflight.Flag("allowedCapability/allowedDevice", errs.ExtraPrivileges, "use CAP_ capability names and path:type:major:minor devices"),
+		preflight.Flag("shmSize/scratchMountSize", errs.Tmpfs, "use a number of bytes with an optional k, m or g suffix"),
+		preflight.Flag("allowedSysctl", errs.Sysctls, "only allow sysctls which are namespaced, e.g. net.*"),
+		preflight.Flag("cpuPool", errs.CPUPool, "list online CPUs in cpuset format, e.g. 4-7,12"),
+		preflight.WritableDir("depot", *depotPath, preflight.Fatal, "point -depot at a writable directory"),
+		{
+			Name:     "default rootfs",
+			Severity: preflight.Fatal,
+			Advice:   "point -rootfs at an existing root filesystem",
+			Run: func() (string, error) {
+				return filepath.EvalSymlinks(*rootFSPath)
+			},
+		},
+		host.Binary(*runtimePath, preflight.Fatal, "install runc on guardian's PATH, or point -runtimePath at an OCI runtime"),
+		{
+			Name:     "runtime version",
+			Severity: preflight.Fatal,
+			Advice:   "use runc " + runrunc.SupportedVersions + ", or a runtime which accepts the same command line",
+			Run: func() (string, error) {
+				return runrunc.Runc{Path: *runtimePath}.Version(linux_command_runner.New())
+			},
+		},
+		host.Executable("iptables", "/sbin/iptables", preflight.Fatal, "install iptables at /sbin/iptables"),
+		host.Executable("-iodaemonBin", *iodaemonBin, preflight.Fatal, "build iodaemon and point -iodaemonBin at it"),
+		host.Executable("-initBin", *initBin, preflight.Fatal, "build the init binary and point -initBin at it"),
+		host.UserNamespaces(),
+		host.Cgroups("cpu", "memory"),
+		host.Filesystem("aufs", preflight.Warning, "docker:// root filesystems will not work; load the aufs kernel module"),
+	}
+
+	if *loadDefaultAppArmorProfile {
+		checks = append(checks, host.Binary("apparmor_parser", preflight.Fatal, "install apparmor, or do not pass -loadDefaultAppArmorProfile"))
+	}
+
+	if *networkPlugin == "" {
+		checks = append(checks, host.Executable("-kawasakiBin", *kawasakiBin, preflight.Fatal, "build kawasaki and point -kawasakiBin at it"))
+	} else {
+		checks = append(checks, host.Executable("-networkPlugin", *networkPlugin, preflight.Fatal, "point -networkPlugin at an executable"))
+	}
+
+	return checks
+}
+
 func wireUidGenerator() gardener.UidGeneratorFunc {
 	return gardener.UidGeneratorFunc(func() string { return mustStringify(uuid.NewV4()) })
 }
@@ -376,10 +615,24 @@ func wireUidGenerator() gardener.UidGeneratorFunc {
 func wireStarter(logger lager.Logger, ipt *iptables.IPTables, allowHostAccess bool, nicPrefix string, denyNetworks []string) gardener.Starter {
 	runner := &logging.Runner{CommandRunner: linux_command_runner.New(), Logger: logger.Session("runner")}
 
-	return &StartAll{starters: []gardener.Starter{
-		rundmc.NewStarter(logger, mustOpen("/proc/cgroups"), path.Join(os.TempDir(), fmt.Sprintf("cgroups-%s", *tag)), runner),
+	starters := []gardener.Starter{
+		rundmc.NewStarter(logger, mustOpen("/proc/cgroups"), cgroupsMountpoint(), "/sys/fs/cgroup", runner),
 		iptables.NewStarter(ipt, allowHostAccess, nicPrefix, denyNetworks),
-	}}
+	}
+
+	if *loadDefaultAppArmorProfile {
+		starters = append(starters, &rundmc.AppArmorStarter{Profile: rundmc.DefaultAppArmorProfile, CommandRunner: runner})
+	}
+
+	return &StartAll{starters: starters}
+}
+
+func appArmorProfileName() string {
+	if *apparmorProfile == "" && *loadDefaultAppArmorProfile {
+		return rundmc.DefaultAppArmorProfileName
+	}
+
+	return *apparmorProfile
 }
 
 func wireIptables(logger lager.Logger, prefix string) *iptables.IPTables {
@@ -502,62 +755,236 @@ func wireVolumeCreator(logger lager.Logger, graphRoot string, insecureRegistries
 	return cakeOrdinator
 }
 
-func wireContainerizer(log lager.Logger, depotPath, iodaemonPath, nstarPath, tarPath, defaultRootFSPath string, properties gardener.PropertyManager) *rundmc.Containerizer {
-	depot := depot.New(depotPath)
+func loadBundleProfiles() (bundlerules.Profiles, error) {
+	if *bundleProfiles == "" {
+		return bundlerules.DefaultProfiles, nil
+	}
+
+	return bundlerules.LoadProfiles(*bundleProfiles)
+}
+
+func loadExtraPrivileges(allowedCapabilities, allowedDevices vars.StringList) (bundlerules.ExtraPrivileges, error) {
+	extraPrivileges := bundlerules.ExtraPrivileges{AllowedCapabilities: allowedCapabilities.List}
+	for _, d := range allowedDevices.List {
+		device, err := bundlerules.ParseDeviceNode(d)
+		if err != nil {
+			return extraPrivileges, err
+		}
+
+		extraPrivileges.AllowedDevices = append(extraPrivileges.AllowedDevices, device)
+	}
+
+	return extraPrivileges, extraPrivileges.Validate()
+}
+
+func wireHardening(maskedPaths, readonlyPaths vars.StringList) bundlerules.Hardening {
+	hardening := bundlerules.Hardening{
+		MaskedPaths:   bundlerules.DefaultMaskedPaths,
+		ReadonlyPaths: bundlerules.DefaultReadonlyPaths,
+	}
+
+	if len(maskedPaths.List) > 0 {
+		hardening.MaskedPaths = maskedPaths.List
+	}
+
+	if len(readonlyPaths.List) > 0 {
+		hardening.ReadonlyPaths = readonlyPaths.List
+	}
+
+	return hardening
+}
+
+func wireSysctls(allowedSysctls vars.StringList) bundlerules.Sysctls {
+	if len(allowedSysctls.List) > 0 {
+		return bundlerules.Sysctls{Allowed: allowedSysctls.List}
+	}
+
+	return bundlerules.Sysctls{Allowed: bundlerules.DefaultAllowedSysctls}
+}
+
+// runtimeStateRoot keeps the containers of guardians with different tags
+// apart, so that they don't collide in the runtime's state
+func runtimeStateRoot() string {
+	if *runtimeRoot != "" {
+		return *runtimeRoot
+	}
+
+	if *tag == "" {
+		return OciStateDir
+	}
+
+	return fmt.Sprintf("%s-%s", OciStateDir, *tag)
+}
+
+func cgroupsMountpoint() string {
+	return path.Join(os.TempDir(), fmt.Sprintf("cgroups-%s", *tag))
+}
+
+func wireCgroupParents(logger lager.Logger) *rundmc.CgroupParents {
+	limitsDir := *cgroupParentLimitsDir
+	if limitsDir == "" {
+		limitsDir = path.Join(os.TempDir(), fmt.Sprintf("garden-%s", *tag), "cgroup-parent-limits")
+	}
+
+	return &rundmc.CgroupParents{
+		Root:         fmt.Sprintf("garden-parents-%s", *tag),
+		CgroupPath:   cgroupsMountpoint(),
+		UnifiedRoot:  "/sys/fs/cgroup",
+		LimitsDir:    limitsDir,
+		BundleLoader: &goci.BndlLoader{},
+		Retrier:      retrier.New(retrier.ConstantBackoff(50, 100*time.Millisecond), nil),
+		Logger:       logger.Session("cgroup-parents"),
+	}
+}
+
+func startAdminServer(logger lager.Logger, cgroupParents *rundmc.CgroupParents, containers admin.Containers) {
+	if *adminListenNetwork == "unix" {
+		os.Remove(*adminListenAddr)
+	}
+
+	listener, err := net.Listen(*adminListenNetwork, *adminListenAddr)
+	if err != nil {
+		logger.Fatal("failed-to-start-admin-server", err)
+	}
+
+	go http.Serve(listener, &admin.Handler{CgroupParents: cgroupParents, Containers: containers, Logger: logger})
+
+	logger.Info("admin-server-started", lager.Data{
+		"network": *adminListenNetwork,
+		"addr":    *adminListenAddr,
+	})
+}
+
+func wireCPUSetAllocator(sysInfoProvider sysinfo.Provider) (gardener.CPUSetAllocator, error) {
+	if *cpuPool == "" {
+		return nil, nil
+	}
+
+	online, err := sysInfoProvider.CPUs()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(online) == 0 {
+		return nil, errors.New("no online CPUs found")
+	}
+
+	pool, err := sysinfo.ParseCPUListWithin(*cpuPool, online[len(online)-1])
+	if err != nil {
+		return nil, err
+	}
+
+	for _, cpu := range pool {
+		if !containsInt(online, cpu) {
+			return nil, fmt.Errorf("CPU %d is not online (online: %s)", cpu, sysinfo.FormatCPUList(online))
+		}
+	}
+
+	return gardener.NewExclusiveCPUPool(pool), nil
+}
+
+func containsInt(list []int, n int) bool {
+	for _, item := range list {
+		if item == n {
+			return true
+		}
+	}
+
+	return false
+}
 
-	startChecker := rundmc.StartChecker{Expect: "Pid 1 Running", Timeout: 15 * time.Second}
-	stateChecker := rundmc.StateChecker{StateFileDir: OciStateDir}
+func loadSeccompProfile() (bundlerules.SeccompProfile, error) {
+	if *seccompProfile == "" {
+		return bundlerules.DefaultSeccompProfile, nil
+	}
+
+	return bundlerules.LoadSeccompProfile(*seccompProfile)
+}
+
+// kernelRelease is the host's kernel release (e.g. "4.4.0-21-generic"), or
+// empty if it cannot be read
+func kernelRelease() string {
+	release, err := ioutil.ReadFile("/proc/sys/kernel/osrelease")
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(release))
+}
+
+// guardianBinary is the path of the running guardian binary, for helpers
+// which are spawned by other processes
+func guardianBinary() string {
+	self, err := os.Readlink("/proc/self/exe")
+	if err != nil {
+		return os.Args[0]
+	}
+
+	return self
+}
+
+// containerizerConfig is what wireContainerizer needs beyond the flags it
+// reads directly
+type containerizerConfig struct {
+	DepotPath         string
+	IodaemonPath      string
+	DefaultRootFSPath string
+
+	Profiles        bundlerules.Profiles
+	Seccomp         bundlerules.SeccompProfile
+	Hardening       bundlerules.Hardening
+	ExtraPrivileges bundlerules.ExtraPrivileges
+	Tmpfs           bundlerules.Tmpfs
+	Sysctls         bundlerules.Sysctls
+
+	AllowedBindMountSources vars.StringList
+	CgroupParents           *rundmc.CgroupParents
+	Properties              gardener.PropertyManager
+}
+
+func wireContainerizer(log lager.Logger, config containerizerConfig) *rundmc.Containerizer {
+	depot := depot.New(config.DepotPath)
+
+	startChecker := rundmc.StartChecker{SocketName: notifySocketName, Expect: *initReadyString, Timeout: 15 * time.Second}
+	runtime := runrunc.Runc{Path: *runtimePath, Root: runtimeStateRoot()}
+	stateChecker := rundmc.StateChecker{StateFileDir: runtime.Root}
 
 	commandRunner := linux_command_runner.New()
 	execPreparer := runrunc.NewExecPreparer(&goci.BndlLoader{}, runrunc.LookupFunc(runrunc.LookupUser), runrunc.DirectoryCreator{})
 
-	pidFileReader := &process_tracker.PidFileReader{
-		Clock:         clock.NewClock(),
-		Timeout:       10 * time.Second,
-		SleepInterval: time.Millisecond * 100,
+	pidGetter := &process_tracker.PidFileWatcher{
+		Timeout: 10 * time.Second,
+		Fallback: &process_tracker.PidFileReader{
+			Clock:         clock.NewClock(),
+			Timeout:       10 * time.Second,
+			SleepInterval: time.Millisecond * 100,
+		},
+	}
+
+	processTracker := process_tracker.New(path.Join(os.TempDir(), fmt.Sprintf("garden-%s", *tag), "processes"), config.IodaemonPath, commandRunner, pidGetter, *processReplaySize)
+	if err := processTracker.Recover(); err != nil {
+		log.Error("recover-processes-failed", err)
 	}
 
 	runcrunner := runrunc.New(
-		process_tracker.New(path.Join(os.TempDir(), fmt.Sprintf("garden-%s", *tag), "processes"), iodaemonPath, commandRunner, pidFileReader),
+		processTracker,
 		commandRunner,
 		wireUidGenerator(),
-		goci.RuncBinary("runc"),
+		runtime,
 		execPreparer,
+		runrunc.OomScoreAdj{BundleLoader: &goci.BndlLoader{}, Self: guardianBinary()},
+		&runrunc.RuntimeLogs{DepotPath: config.DepotPath, MaxLines: *runtimeLogLines},
 	)
 
-	mounts := []specs.Mount{
-		specs.Mount{Type: "proc", Source: "proc", Destination: "/proc"},
-		specs.Mount{Type: "tmpfs", Source: "tmpfs", Destination: "/dev/shm"},
-		specs.Mount{Type: "devpts", Source: "devpts", Destination: "/dev/pts",
-			Options: []string{"nosuid", "noexec", "newinstance", "ptmxmode=0666", "mode=0620"}},
-		specs.Mount{Type: "bind", Source: *initBin, Destination: "/tmp/garden-init", Options: []string{"bind"}},
-	}
-
-	rwm := "rwm"
-	character := 'c'
-	var majorMinor = func(i int64) *int64 {
-		return &i
-	}
-
-	denyAll := specs.DeviceCgroup{Allow: false, Access: &rwm}
-	allowedDevices := []specs.DeviceCgroup{
-		{Access: &rwm, Type: &character, Major: majorMinor(1), Minor: majorMinor(3), Allow: true},
-		{Access: &rwm, Type: &character, Major: majorMinor(5), Minor: majorMinor(0), Allow: true},
-		{Access: &rwm, Type: &character, Major: majorMinor(1), Minor: majorMinor(8), Allow: true},
-		{Access: &rwm, Type: &character, Major: majorMinor(1), Minor: majorMinor(9), Allow: true},
-		{Access: &rwm, Type: &character, Major: majorMinor(1), Minor: majorMinor(5), Allow: true},
-		{Access: &rwm, Type: &character, Major: majorMinor(1), Minor: majorMinor(7), Allow: true},
-	}
+	initMount := specs.Mount{Type: "bind", Source: *initBin, Destination: "/tmp/garden-init", Options: []string{"bind"}}
 
-	baseBundle := goci.Bundle().
-		WithNamespaces(PrivilegedContainerNamespaces...).
-		WithCapabilities(DefaultCapabilities...).
-		WithResources(&specs.Resources{Devices: append([]specs.DeviceCgroup{denyAll}, allowedDevices...)}).
-		WithMounts(mounts...).
-		WithRootFS(defaultRootFSPath)
+	baseBundle := config.Profiles.Privileged.Bundle().
+		WithMounts(initMount).
+		WithRootFS(config.DefaultRootFSPath)
 
-	unprivilegedBundle := baseBundle.
-		WithNamespace(goci.UserNamespace).
+	unprivilegedBundle := config.Profiles.Unprivileged.Bundle().
+		WithMounts(initMount).
+		WithRootFS(config.DefaultRootFSPath).
 		WithUIDMappings(idMappings...).
 		WithGIDMappings(idMappings...)
 
@@ -567,28 +994,69 @@ func wireContainerizer(log lager.Logger, depotPath, iodaemonPath, nstarPath, tar
 				PrivilegedBase:   baseBundle,
 				UnprivilegedBase: unprivilegedBundle,
 			},
+			config.ExtraPrivileges,
+			bundlerules.Hostname{},
 			bundlerules.RootFS{
 				ContainerRootUID: idMappings.Map(0),
 				ContainerRootGID: idMappings.Map(0),
 				MkdirChowner:     bundlerules.MkdirChownFunc(bundlerules.MkdirChown),
 			},
+			bundlerules.ReadonlyRootFS{BundlePathPattern: filepath.Join(config.DepotPath, "%s")},
+			config.Tmpfs,
 			bundlerules.Limits{},
-			bundlerules.Hooks{LogFilePattern: filepath.Join(depotPath, "%s", "network.log")},
-			bundlerules.BindMounts{},
+			config.Sysctls,
+			bundlerules.CPUSet{},
+			bundlerules.CgroupParent{Property: *cgroupParentProperty, Parents: config.CgroupParents},
+			bundlerules.Hooks{LogFilePattern: filepath.Join(config.DepotPath, "%s", "network.log")},
+			bundlerules.BindMounts{AllowedSourcePrefixes: config.AllowedBindMountSources.List},
 			bundlerules.InitProcess{
 				Process: specs.Process{
 					Args: []string{"/tmp/garden-init"},
 					Cwd:  "/",
 				},
 			},
+			bundlerules.NotifySocket{
+				SocketPathPattern: filepath.Join(config.DepotPath, "%s", notifySocketName),
+				ContainerPath:     "/tmp/garden-notify.sock",
+			},
+			config.Hardening,
+			bundlerules.Seccomp{Profile: config.Seccomp, KernelVersion: kernelRelease()},
+			bundlerules.SecurityLabels{
+				AppArmorProfile:     appArmorProfileName(),
+				SELinuxProcessLabel: *selinuxProcessLabel,
+				SELinuxMountLabel:   *selinuxMountLabel,
+			},
 		},
 	}
 
-	eventStore := rundmc.NewEventStore(properties)
-	nstar := rundmc.NewNstarRunner(nstarPath, tarPath, linux_command_runner.New())
+	eventStore := rundmc.NewEventStore(config.Properties)
+	nstar := rundmc.NewNstarRunner(linux_command_runner.New())
+	admitter := streamin.New(&goci.BndlLoader{},
+		streamin.Limit{Bytes: *streamInRequestByteLimit, Entries: *streamInRequestEntryLimit},
+		streamin.Limit{Bytes: *streamInContainerByteLimit, Entries: *streamInContainerEntryLimit},
+	)
+
+	pausedPolicy := rundmc.FailWhenPaused
+	if *autoResume {
+		pausedPolicy = rundmc.ResumeWhenPaused
+	}
 
 	stateCheckRetrier := retrier.New(retrier.ConstantBackoff(10, 100*time.Millisecond), nil)
-	return rundmc.New(depot, template, runcrunner, startChecker, stateChecker, nstar, eventStore, stateCheckRetrier)
+	return rundmc.New(rundmc.Config{
+		Depot:        depot,
+		Loader:       &goci.BndlLoader{},
+		Bundler:      template,
+		Runner:       runcrunner,
+		StartChecker: startChecker,
+		StateChecker: stateChecker,
+		Nstar:        nstar,
+		Admitter:     admitter,
+		Events:       eventStore,
+		Retrier:      stateCheckRetrier,
+		Cgroups:      config.CgroupParents,
+		Properties:   config.Properties,
+		PausedPolicy: pausedPolicy,
+	})
 }
 
 func missing(flagName string) {
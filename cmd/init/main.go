@@ -1,19 +1,81 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"net"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
+
+	"github.com/cloudfoundry-incubator/guardian/rundmc/initd"
+	"github.com/pivotal-golang/clock"
+)
+
+var shutdownTimeout = flag.Duration(
+	"shutdownTimeout",
+	10*time.Second,
+	"time to wait for processes to exit after forwarding SIGTERM or SIGINT, before killing them",
+)
+
+var exitReportPath = flag.String(
+	"exitReport",
+	"",
+	"file (or fifo) to write the exit status of each reaped process to, as lines of JSON",
 )
 
 func main() {
-	fmt.Println("Pid 1 Running")
+	flag.Parse()
+
+	signals := make(chan os.Signal, 64)
+	signal.Notify(signals, syscall.SIGCHLD, syscall.SIGTERM, syscall.SIGINT)
+
+	reaper := &initd.Reaper{Wait: initd.Wait4}
+	if *exitReportPath != "" {
+		exitReport, err := os.OpenFile(*exitReportPath, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "open exit report: %s\n", err)
+			os.Exit(1)
+		}
+
+		reaper.Reporter = &initd.JSONReporter{W: exitReport}
+	}
 
-	signals := make(chan os.Signal)
-	signal.Notify(signals, syscall.SIGTERM)
+	if err := notifyReady(); err != nil {
+		fmt.Fprintf(os.Stderr, "notify ready: %s\n", err)
+		os.Exit(1)
+	}
+
+	pid1 := &initd.Init{
+		Reaper:          reaper,
+		Kill:            syscall.Kill,
+		ShutdownTimeout: *shutdownTimeout,
+		PollInterval:    100 * time.Millisecond,
+		Clock:           clock.NewClock(),
+	}
 
-	for {
-		<-signals
+	if err := pid1.Run(signals); err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
 	}
 }
+
+// notifyReady tells guardian that the container has started, via the socket
+// named in NOTIFY_SOCKET, falling back to the legacy stdout message.
+func notifyReady() error {
+	socketPath := os.Getenv("NOTIFY_SOCKET")
+	if socketPath == "" {
+		fmt.Println("Pid 1 Running")
+		return nil
+	}
+
+	conn, err := net.Dial("unixgram", socketPath)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = conn.Write([]byte("READY=1"))
+	return err
+}
@@ -111,10 +111,22 @@ func wireDNSResolvConfigurer(state specs.State, config kawasaki.NetworkConfig) *
 
 	rootUid, rootGid := extractRootIds(bndl)
 
+	// a read-only rootfs has its DNS files bind mounted from the bundle
+	// directory (see bundlerules.ReadonlyRootFS), so write them there instead
+	dnsRoot := bndl.Spec.Spec.Root.Path
+	if bndl.Spec.Spec.Root.Readonly {
+		dnsRoot = state.BundlePath
+	}
+
+	hostname := bndl.Spec.Spec.Hostname
+	if hostname == "" {
+		hostname = state.ID
+	}
+
 	configurer := &dns.ResolvConfigurer{
 		HostsFileCompiler: &dns.HostsFileCompiler{
-			Handle: state.ID,
-			IP:     config.ContainerIP,
+			Hostname: hostname,
+			IP:       config.ContainerIP,
 		},
 		ResolvFileCompiler: &dns.ResolvFileCompiler{
 			HostResolvConfPath: "/etc/resolv.conf",
@@ -122,7 +134,7 @@ func wireDNSResolvConfigurer(state specs.State, config kawasaki.NetworkConfig) *
 			OverrideServers:    config.DNSServers,
 		},
 		FileWriter: &dns.RootfsWriter{
-			RootfsPath: bndl.Spec.Spec.Root.Path,
+			RootfsPath: dnsRoot,
 			RootUid:    rootUid,
 			RootGid:    rootGid,
 		},
@@ -67,9 +67,14 @@ func (c *container) Info() (garden.ContainerInfo, error) {
 		log.Error("find-key", err)
 	}
 
+	state := "active"
+	if actualContainerSpec.Paused {
+		state = "paused"
+	}
+
 	json.Unmarshal([]byte(mappedPortsCfg), &mappedPorts)
 	return garden.ContainerInfo{
-		State:         "active",
+		State:         state,
 		ContainerIP:   containerIP,
 		HostIP:        hostIP,
 		ExternalIP:    externalIP,
@@ -145,11 +150,19 @@ func (c *container) Property(name string) (string, error) {
 }
 
 func (c *container) SetProperty(name string, value string) error {
+	if err := checkClientProperty(name); err != nil {
+		return err
+	}
+
 	c.propertyManager.Set(c.handle, name, value)
 	return nil
 }
 
 func (c *container) RemoveProperty(name string) error {
+	if err := checkClientProperty(name); err != nil {
+		return err
+	}
+
 	return c.propertyManager.Remove(c.handle, name)
 }
 
@@ -0,0 +1,100 @@
+package gardener
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// ExclusiveCPUPool hands out CPUs from a fixed pool, each to at most one
+// container at a time
+type ExclusiveCPUPool struct {
+	mu        sync.Mutex
+	pool      []int
+	free      []int
+	allocated map[string][]int
+}
+
+func NewExclusiveCPUPool(cpus []int) *ExclusiveCPUPool {
+	free := append([]int{}, cpus...)
+	sort.Ints(free)
+
+	return &ExclusiveCPUPool{
+		pool:      append([]int{}, free...),
+		free:      free,
+		allocated: make(map[string][]int),
+	}
+}
+
+// Allocate gives the container the lowest numbered free CPUs
+func (p *ExclusiveCPUPool) Allocate(handle string, count int) ([]int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.allocated[handle]; ok {
+		return nil, fmt.Errorf("container %s already has exclusive CPUs", handle)
+	}
+
+	if count > len(p.free) {
+		return nil, fmt.Errorf("not enough free CPUs in the exclusive pool: asked for %d, %d free", count, len(p.free))
+	}
+
+	cpus := append([]int{}, p.free[:count]...)
+	p.free = p.free[count:]
+	p.allocated[handle] = cpus
+
+	return cpus, nil
+}
+
+// Release returns the container's CPUs, if it has any, to the pool
+func (p *ExclusiveCPUPool) Release(handle string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	cpus, ok := p.allocated[handle]
+	if !ok {
+		return
+	}
+
+	delete(p.allocated, handle)
+	p.free = append(p.free, cpus...)
+	sort.Ints(p.free)
+}
+
+// Claim allocates the container those of its CPUs which are in the pool, so
+// that containers which existed before a restart keep their CPUs
+func (p *ExclusiveCPUPool) Claim(handle string, cpus []int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var free, claimed []int
+	for _, cpu := range p.free {
+		if containsCPU(cpus, cpu) {
+			claimed = append(claimed, cpu)
+		} else {
+			free = append(free, cpu)
+		}
+	}
+
+	if len(claimed) == 0 {
+		return
+	}
+
+	p.free = free
+	p.allocated[handle] = append(p.allocated[handle], claimed...)
+}
+
+// Pool returns every CPU in the pool, whether or not it is allocated
+func (p *ExclusiveCPUPool) Pool() []int {
+	return append([]int{}, p.pool...)
+}
+
+func containsCPU(cpus []int, cpu int) bool {
+	for _, c := range cpus {
+		if c == cpu {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,73 @@
+package gardener_test
+
+import (
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ExclusiveCPUPool", func() {
+	var pool *gardener.ExclusiveCPUPool
+
+	BeforeEach(func() {
+		pool = gardener.NewExclusiveCPUPool([]int{7, 4, 5, 6})
+	})
+
+	It("hands out the lowest numbered free CPUs", func() {
+		Expect(pool.Allocate("fred", 2)).To(Equal([]int{4, 5}))
+		Expect(pool.Allocate("george", 1)).To(Equal([]int{6}))
+	})
+
+	It("does not give the same CPU to two containers", func() {
+		_, err := pool.Allocate("fred", 3)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = pool.Allocate("george", 2)
+		Expect(err).To(MatchError("not enough free CPUs in the exclusive pool: asked for 2, 1 free"))
+	})
+
+	It("does not give a container two allocations", func() {
+		_, err := pool.Allocate("fred", 1)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = pool.Allocate("fred", 1)
+		Expect(err).To(MatchError(ContainSubstring("already has exclusive CPUs")))
+	})
+
+	It("returns released CPUs to the pool", func() {
+		_, err := pool.Allocate("fred", 2)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = pool.Allocate("george", 2)
+		Expect(err).NotTo(HaveOccurred())
+
+		pool.Release("fred")
+		Expect(pool.Allocate("ron", 2)).To(Equal([]int{4, 5}))
+	})
+
+	It("ignores containers without CPUs", func() {
+		pool.Release("nobody")
+		Expect(pool.Allocate("fred", 4)).To(Equal([]int{4, 5, 6, 7}))
+	})
+
+	Describe("Claim", func() {
+		It("allocates the container the claimed CPUs which are in the pool", func() {
+			pool.Claim("fred", []int{0, 4, 6})
+			Expect(pool.Allocate("george", 2)).To(Equal([]int{5, 7}))
+
+			pool.Release("fred")
+			Expect(pool.Allocate("ron", 2)).To(Equal([]int{4, 6}))
+		})
+
+		It("ignores containers none of whose CPUs are in the pool", func() {
+			pool.Claim("fred", []int{0, 1})
+			Expect(pool.Allocate("fred", 4)).To(Equal([]int{4, 5, 6, 7}))
+		})
+	})
+
+	It("lists every CPU in the pool, allocated or not", func() {
+		_, err := pool.Allocate("fred", 2)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(pool.Pool()).To(Equal([]int{4, 5, 6, 7}))
+	})
+})
@@ -62,6 +62,24 @@ type FakeContainerizer struct {
 	destroyReturns struct {
 		result1 error
 	}
+	PauseStub        func(log lager.Logger, handle string) error
+	pauseMutex       sync.RWMutex
+	pauseArgsForCall []struct {
+		log    lager.Logger
+		handle string
+	}
+	pauseReturns struct {
+		result1 error
+	}
+	ResumeStub        func(log lager.Logger, handle string) error
+	resumeMutex       sync.RWMutex
+	resumeArgsForCall []struct {
+		log    lager.Logger
+		handle string
+	}
+	resumeReturns struct {
+		result1 error
+	}
 	InfoStub        func(log lager.Logger, handle string) (gardener.ActualContainerSpec, error)
 	infoMutex       sync.RWMutex
 	infoArgsForCall []struct {
@@ -252,6 +270,72 @@ func (fake *FakeContainerizer) DestroyReturns(result1 error) {
 	}{result1}
 }
 
+func (fake *FakeContainerizer) Pause(log lager.Logger, handle string) error {
+	fake.pauseMutex.Lock()
+	fake.pauseArgsForCall = append(fake.pauseArgsForCall, struct {
+		log    lager.Logger
+		handle string
+	}{log, handle})
+	fake.pauseMutex.Unlock()
+	if fake.PauseStub != nil {
+		return fake.PauseStub(log, handle)
+	} else {
+		return fake.pauseReturns.result1
+	}
+}
+
+func (fake *FakeContainerizer) PauseCallCount() int {
+	fake.pauseMutex.RLock()
+	defer fake.pauseMutex.RUnlock()
+	return len(fake.pauseArgsForCall)
+}
+
+func (fake *FakeContainerizer) PauseArgsForCall(i int) (lager.Logger, string) {
+	fake.pauseMutex.RLock()
+	defer fake.pauseMutex.RUnlock()
+	return fake.pauseArgsForCall[i].log, fake.pauseArgsForCall[i].handle
+}
+
+func (fake *FakeContainerizer) PauseReturns(result1 error) {
+	fake.PauseStub = nil
+	fake.pauseReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeContainerizer) Resume(log lager.Logger, handle string) error {
+	fake.resumeMutex.Lock()
+	fake.resumeArgsForCall = append(fake.resumeArgsForCall, struct {
+		log    lager.Logger
+		handle string
+	}{log, handle})
+	fake.resumeMutex.Unlock()
+	if fake.ResumeStub != nil {
+		return fake.ResumeStub(log, handle)
+	} else {
+		return fake.resumeReturns.result1
+	}
+}
+
+func (fake *FakeContainerizer) ResumeCallCount() int {
+	fake.resumeMutex.RLock()
+	defer fake.resumeMutex.RUnlock()
+	return len(fake.resumeArgsForCall)
+}
+
+func (fake *FakeContainerizer) ResumeArgsForCall(i int) (lager.Logger, string) {
+	fake.resumeMutex.RLock()
+	defer fake.resumeMutex.RUnlock()
+	return fake.resumeArgsForCall[i].log, fake.resumeArgsForCall[i].handle
+}
+
+func (fake *FakeContainerizer) ResumeReturns(result1 error) {
+	fake.ResumeStub = nil
+	fake.resumeReturns = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeContainerizer) Info(log lager.Logger, handle string) (gardener.ActualContainerSpec, error) {
 	fake.infoMutex.Lock()
 	fake.infoArgsForCall = append(fake.infoArgsForCall, struct {
@@ -0,0 +1,145 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+)
+
+type FakeCPUSetAllocator struct {
+	AllocateStub        func(handle string, count int) ([]int, error)
+	allocateMutex       sync.RWMutex
+	allocateArgsForCall []struct {
+		handle string
+		count  int
+	}
+	allocateReturns struct {
+		result1 []int
+		result2 error
+	}
+	ReleaseStub        func(handle string)
+	releaseMutex       sync.RWMutex
+	releaseArgsForCall []struct {
+		handle string
+	}
+	ClaimStub        func(handle string, cpus []int)
+	claimMutex       sync.RWMutex
+	claimArgsForCall []struct {
+		handle string
+		cpus   []int
+	}
+	PoolStub        func() []int
+	poolMutex       sync.RWMutex
+	poolArgsForCall []struct{}
+	poolReturns     struct {
+		result1 []int
+	}
+}
+
+func (fake *FakeCPUSetAllocator) Allocate(handle string, count int) ([]int, error) {
+	fake.allocateMutex.Lock()
+	fake.allocateArgsForCall = append(fake.allocateArgsForCall, struct {
+		handle string
+		count  int
+	}{handle, count})
+	fake.allocateMutex.Unlock()
+	if fake.AllocateStub != nil {
+		return fake.AllocateStub(handle, count)
+	} else {
+		return fake.allocateReturns.result1, fake.allocateReturns.result2
+	}
+}
+
+func (fake *FakeCPUSetAllocator) AllocateCallCount() int {
+	fake.allocateMutex.RLock()
+	defer fake.allocateMutex.RUnlock()
+	return len(fake.allocateArgsForCall)
+}
+
+func (fake *FakeCPUSetAllocator) AllocateArgsForCall(i int) (string, int) {
+	fake.allocateMutex.RLock()
+	defer fake.allocateMutex.RUnlock()
+	return fake.allocateArgsForCall[i].handle, fake.allocateArgsForCall[i].count
+}
+
+func (fake *FakeCPUSetAllocator) AllocateReturns(result1 []int, result2 error) {
+	fake.AllocateStub = nil
+	fake.allocateReturns = struct {
+		result1 []int
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeCPUSetAllocator) Release(handle string) {
+	fake.releaseMutex.Lock()
+	fake.releaseArgsForCall = append(fake.releaseArgsForCall, struct {
+		handle string
+	}{handle})
+	fake.releaseMutex.Unlock()
+	if fake.ReleaseStub != nil {
+		fake.ReleaseStub(handle)
+	}
+}
+
+func (fake *FakeCPUSetAllocator) ReleaseCallCount() int {
+	fake.releaseMutex.RLock()
+	defer fake.releaseMutex.RUnlock()
+	return len(fake.releaseArgsForCall)
+}
+
+func (fake *FakeCPUSetAllocator) ReleaseArgsForCall(i int) string {
+	fake.releaseMutex.RLock()
+	defer fake.releaseMutex.RUnlock()
+	return fake.releaseArgsForCall[i].handle
+}
+
+func (fake *FakeCPUSetAllocator) Claim(handle string, cpus []int) {
+	fake.claimMutex.Lock()
+	fake.claimArgsForCall = append(fake.claimArgsForCall, struct {
+		handle string
+		cpus   []int
+	}{handle, cpus})
+	fake.claimMutex.Unlock()
+	if fake.ClaimStub != nil {
+		fake.ClaimStub(handle, cpus)
+	}
+}
+
+func (fake *FakeCPUSetAllocator) ClaimCallCount() int {
+	fake.claimMutex.RLock()
+	defer fake.claimMutex.RUnlock()
+	return len(fake.claimArgsForCall)
+}
+
+func (fake *FakeCPUSetAllocator) ClaimArgsForCall(i int) (string, []int) {
+	fake.claimMutex.RLock()
+	defer fake.claimMutex.RUnlock()
+	return fake.claimArgsForCall[i].handle, fake.claimArgsForCall[i].cpus
+}
+
+func (fake *FakeCPUSetAllocator) Pool() []int {
+	fake.poolMutex.Lock()
+	fake.poolArgsForCall = append(fake.poolArgsForCall, struct{}{})
+	fake.poolMutex.Unlock()
+	if fake.PoolStub != nil {
+		return fake.PoolStub()
+	} else {
+		return fake.poolReturns.result1
+	}
+}
+
+func (fake *FakeCPUSetAllocator) PoolCallCount() int {
+	fake.poolMutex.RLock()
+	defer fake.poolMutex.RUnlock()
+	return len(fake.poolArgsForCall)
+}
+
+func (fake *FakeCPUSetAllocator) PoolReturns(result1 []int) {
+	fake.PoolStub = nil
+	fake.poolReturns = struct {
+		result1 []int
+	}{result1}
+}
+
+var _ gardener.CPUSetAllocator = new(FakeCPUSetAllocator)
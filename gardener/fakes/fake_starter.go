@@ -0,0 +1,43 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+)
+
+type FakeStarter struct {
+	StartStub        func() error
+	startMutex       sync.RWMutex
+	startArgsForCall []struct{}
+	startReturns     struct {
+		result1 error
+	}
+}
+
+func (fake *FakeStarter) Start() error {
+	fake.startMutex.Lock()
+	fake.startArgsForCall = append(fake.startArgsForCall, struct{}{})
+	fake.startMutex.Unlock()
+	if fake.StartStub != nil {
+		return fake.StartStub()
+	} else {
+		return fake.startReturns.result1
+	}
+}
+
+func (fake *FakeStarter) StartCallCount() int {
+	fake.startMutex.RLock()
+	defer fake.startMutex.RUnlock()
+	return len(fake.startArgsForCall)
+}
+
+func (fake *FakeStarter) StartReturns(result1 error) {
+	fake.StartStub = nil
+	fake.startReturns = struct {
+		result1 error
+	}{result1}
+}
+
+var _ gardener.Starter = new(FakeStarter)
@@ -22,6 +22,20 @@ type FakeSysInfoProvider struct {
 		result1 uint64
 		result2 error
 	}
+	CPUsStub        func() ([]int, error)
+	cPUsMutex       sync.RWMutex
+	cPUsArgsForCall []struct{}
+	cPUsReturns     struct {
+		result1 []int
+		result2 error
+	}
+	MemoryNodesStub        func() ([]int, error)
+	memoryNodesMutex       sync.RWMutex
+	memoryNodesArgsForCall []struct{}
+	memoryNodesReturns     struct {
+		result1 []int
+		result2 error
+	}
 }
 
 func (fake *FakeSysInfoProvider) TotalMemory() (uint64, error) {
@@ -74,4 +88,54 @@ func (fake *FakeSysInfoProvider) TotalDiskReturns(result1 uint64, result2 error)
 	}{result1, result2}
 }
 
+func (fake *FakeSysInfoProvider) CPUs() ([]int, error) {
+	fake.cPUsMutex.Lock()
+	fake.cPUsArgsForCall = append(fake.cPUsArgsForCall, struct{}{})
+	fake.cPUsMutex.Unlock()
+	if fake.CPUsStub != nil {
+		return fake.CPUsStub()
+	} else {
+		return fake.cPUsReturns.result1, fake.cPUsReturns.result2
+	}
+}
+
+func (fake *FakeSysInfoProvider) CPUsCallCount() int {
+	fake.cPUsMutex.RLock()
+	defer fake.cPUsMutex.RUnlock()
+	return len(fake.cPUsArgsForCall)
+}
+
+func (fake *FakeSysInfoProvider) CPUsReturns(result1 []int, result2 error) {
+	fake.CPUsStub = nil
+	fake.cPUsReturns = struct {
+		result1 []int
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeSysInfoProvider) MemoryNodes() ([]int, error) {
+	fake.memoryNodesMutex.Lock()
+	fake.memoryNodesArgsForCall = append(fake.memoryNodesArgsForCall, struct{}{})
+	fake.memoryNodesMutex.Unlock()
+	if fake.MemoryNodesStub != nil {
+		return fake.MemoryNodesStub()
+	} else {
+		return fake.memoryNodesReturns.result1, fake.memoryNodesReturns.result2
+	}
+}
+
+func (fake *FakeSysInfoProvider) MemoryNodesCallCount() int {
+	fake.memoryNodesMutex.RLock()
+	defer fake.memoryNodesMutex.RUnlock()
+	return len(fake.memoryNodesArgsForCall)
+}
+
+func (fake *FakeSysInfoProvider) MemoryNodesReturns(result1 []int, result2 error) {
+	fake.MemoryNodesStub = nil
+	fake.memoryNodesReturns = struct {
+		result1 []int
+		result2 error
+	}{result1, result2}
+}
+
 var _ gardener.SysInfoProvider = new(FakeSysInfoProvider)
@@ -1,12 +1,16 @@
 package gardener
 
 import (
+	"fmt"
 	"io"
 	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/cloudfoundry-incubator/garden"
 	"github.com/cloudfoundry-incubator/garden-shed/rootfs_provider"
+	"github.com/cloudfoundry-incubator/guardian/sysinfo"
 	"github.com/pivotal-golang/lager"
 )
 
@@ -15,15 +19,36 @@ import (
 //go:generate counterfeiter . Networker
 //go:generate counterfeiter . VolumeCreator
 //go:generate counterfeiter . UidGenerator
+//go:generate counterfeiter . CPUSetAllocator
+//go:generate counterfeiter . Starter
 
 const ContainerIPKey = "garden.network.container-ip"
 const BridgeIPKey = "garden.network.host-ip"
 const ExternalIPKey = "garden.network.external-ip"
 const MappedPortsKey = "garden.network.mapped-ports"
 
+// CPUSetCPUsKey and CPUSetMemsKey pin a container to the listed CPUs and
+// memory nodes (e.g. "0-3,8"). The CPUs a container was given, including any
+// allocated with CPUSetExclusiveCPUsKey, are recorded in CPUSetCPUsKey.
+const CPUSetCPUsKey = "garden.cpuset-cpus"
+const CPUSetMemsKey = "garden.cpuset-mems"
+
+// CPUSetExclusiveCPUsKey asks for that many CPUs from the exclusive CPU pool
+const CPUSetExclusiveCPUsKey = "garden.cpuset-exclusive-cpus"
+
+// GrantedPropertyPrefix starts the names of the properties which record what
+// a container was granted (e.g. extra capabilities). Only guardian sets them.
+const GrantedPropertyPrefix = "garden.granted-"
+
+// MountedTmpfsProperty lists the tmpfs mounts a container was given. Like the
+// granted properties, only guardian sets it.
+const MountedTmpfsProperty = "garden.mounted-tmpfs"
+
 type SysInfoProvider interface {
 	TotalMemory() (uint64, error)
 	TotalDisk() (uint64, error)
+	CPUs() ([]int, error)
+	MemoryNodes() ([]int, error)
 }
 
 type Containerizer interface {
@@ -32,6 +57,8 @@ type Containerizer interface {
 	StreamOut(log lager.Logger, handle string, spec garden.StreamOutSpec) (io.ReadCloser, error)
 	Run(log lager.Logger, handle string, spec garden.ProcessSpec, io garden.ProcessIO) (garden.Process, error)
 	Destroy(log lager.Logger, handle string) error
+	Pause(log lager.Logger, handle string) error
+	Resume(log lager.Logger, handle string) error
 	Info(log lager.Logger, handle string) (ActualContainerSpec, error)
 	Handles() ([]string, error)
 }
@@ -53,6 +80,13 @@ type UidGenerator interface {
 	Generate() string
 }
 
+type CPUSetAllocator interface {
+	Allocate(handle string, count int) ([]int, error)
+	Release(handle string)
+	Claim(handle string, cpus []int)
+	Pool() []int
+}
+
 //go:generate counterfeiter . PropertyManager
 
 type PropertyManager interface {
@@ -102,6 +136,19 @@ type DesiredContainerSpec struct {
 	Limits garden.Limits
 
 	Env []string
+
+	// Properties requested by the client, e.g. to opt out of a security feature
+	Properties garden.Properties
+
+	// CPUs and memory nodes to pin the container to
+	CPUSet CPUSet
+}
+
+// CPUSet lists CPUs and memory nodes in the kernel's cpuset format; empty
+// lists leave the container unpinned
+type CPUSet struct {
+	CPUs string
+	Mems string
 }
 
 type ActualContainerSpec struct {
@@ -111,11 +158,17 @@ type ActualContainerSpec struct {
 	// Whether the container is stopped
 	Stopped bool
 
+	// Whether the container's processes are frozen
+	Paused bool
+
 	// Process IDs (not PIDs) of processes in the container
 	ProcessIDs []string
 
 	// Events (e.g. OOM) which have occured in the container
 	Events []string
+
+	// CPUs and memory nodes the container is pinned to
+	CPUSet CPUSet
 }
 
 // Gardener orchestrates other components to implement the Garden API
@@ -142,6 +195,56 @@ type Gardener struct {
 
 	// PropertyManager creates map of container properties
 	PropertyManager PropertyManager
+
+	// CPUSetAllocator hands out exclusive CPUs; containers can't ask for
+	// exclusive CPUs if it is nil
+	CPUSetAllocator CPUSetAllocator
+}
+
+// Start runs the start-up tasks, then gives containers which survived a
+// restart back the exclusive CPUs they were pinned to
+func (g *Gardener) Start() error {
+	if err := g.Starter.Start(); err != nil {
+		return err
+	}
+
+	return g.claimCPUs()
+}
+
+func (g *Gardener) claimCPUs() error {
+	if g.CPUSetAllocator == nil {
+		return nil
+	}
+
+	log := g.Logger.Session("claim-cpus")
+
+	handles, err := g.Containerizer.Handles()
+	if err != nil {
+		log.Error("handles-failed", err)
+		return err
+	}
+
+	for _, handle := range handles {
+		info, err := g.Containerizer.Info(log, handle)
+		if err != nil {
+			log.Error("info-failed", err, lager.Data{"handle": handle})
+			continue
+		}
+
+		if info.CPUSet.CPUs == "" {
+			continue
+		}
+
+		cpus, err := sysinfo.ParseCPUList(info.CPUSet.CPUs)
+		if err != nil {
+			log.Error("parse-cpus-failed", err, lager.Data{"handle": handle})
+			continue
+		}
+
+		g.CPUSetAllocator.Claim(handle, cpus)
+	}
+
+	return nil
 }
 
 func (g *Gardener) Create(spec garden.ContainerSpec) (garden.Container, error) {
@@ -151,14 +254,27 @@ func (g *Gardener) Create(spec garden.ContainerSpec) (garden.Container, error) {
 		spec.Handle = g.UidGenerator.Generate()
 	}
 
+	for name := range spec.Properties {
+		if err := checkClientProperty(name); err != nil {
+			return nil, err
+		}
+	}
+
+	cpuset, err := g.cpuSet(spec.Handle, spec.Properties)
+	if err != nil {
+		return nil, err
+	}
+
 	hooks, err := g.Networker.Hooks(log, spec.Handle, spec.Network)
 	if err != nil {
+		g.releaseCPUs(spec.Handle)
 		return nil, err
 	}
 
 	rootFSURL, err := url.Parse(spec.RootFSPath)
 	if err != nil {
 		g.Networker.Destroy(g.Logger, spec.Handle)
+		g.releaseCPUs(spec.Handle)
 		return nil, err
 	}
 
@@ -170,6 +286,7 @@ func (g *Gardener) Create(spec garden.ContainerSpec) (garden.Container, error) {
 	})
 	if err != nil {
 		g.Networker.Destroy(g.Logger, spec.Handle)
+		g.releaseCPUs(spec.Handle)
 		return nil, err
 	}
 
@@ -181,8 +298,11 @@ func (g *Gardener) Create(spec garden.ContainerSpec) (garden.Container, error) {
 		BindMounts:   spec.BindMounts,
 		Limits:       spec.Limits,
 		Env:          append(env, spec.Env...),
+		Properties:   spec.Properties,
+		CPUSet:       cpuset,
 	}); err != nil {
 		g.Networker.Destroy(g.Logger, spec.Handle)
+		g.releaseCPUs(spec.Handle)
 		return nil, err
 	}
 
@@ -198,9 +318,127 @@ func (g *Gardener) Create(spec garden.ContainerSpec) (garden.Container, error) {
 		}
 	}
 
+	if cpuset.CPUs != "" {
+		if err := container.SetProperty(CPUSetCPUsKey, cpuset.CPUs); err != nil {
+			return nil, err
+		}
+	}
+
 	return container, nil
 }
 
+func checkClientProperty(name string) error {
+	if strings.HasPrefix(name, GrantedPropertyPrefix) {
+		return fmt.Errorf("property %s is reserved: %s* properties are set by guardian", name, GrantedPropertyPrefix)
+	}
+
+	if name == MountedTmpfsProperty {
+		return fmt.Errorf("property %s is reserved: it is set by guardian", name)
+	}
+
+	return nil
+}
+
+// cpuSet allocates the exclusive CPUs a container asks for, or checks that
+// the CPUs and memory nodes it asks to be pinned to exist
+func (g *Gardener) cpuSet(handle string, properties garden.Properties) (CPUSet, error) {
+	cpuset := CPUSet{CPUs: properties[CPUSetCPUsKey], Mems: properties[CPUSetMemsKey]}
+
+	if cpuset.Mems != "" {
+		if _, err := g.checkCPUList(CPUSetMemsKey, cpuset.Mems, g.SysInfoProvider.MemoryNodes); err != nil {
+			return CPUSet{}, err
+		}
+	}
+
+	count, ok := properties[CPUSetExclusiveCPUsKey]
+	if !ok {
+		if cpuset.CPUs != "" {
+			return cpuset, g.checkPinnedCPUs(cpuset.CPUs)
+		}
+
+		return cpuset, nil
+	}
+
+	if cpuset.CPUs != "" {
+		return CPUSet{}, fmt.Errorf("%s and %s can't both be set", CPUSetCPUsKey, CPUSetExclusiveCPUsKey)
+	}
+
+	if g.CPUSetAllocator == nil {
+		return CPUSet{}, fmt.Errorf("%s: no exclusive CPU pool is configured", CPUSetExclusiveCPUsKey)
+	}
+
+	n, err := strconv.Atoi(count)
+	if err != nil || n < 1 {
+		return CPUSet{}, fmt.Errorf("%s: must be a positive number", CPUSetExclusiveCPUsKey)
+	}
+
+	cpus, err := g.CPUSetAllocator.Allocate(handle, n)
+	if err != nil {
+		return CPUSet{}, err
+	}
+
+	cpuset.CPUs = sysinfo.FormatCPUList(cpus)
+	return cpuset, nil
+}
+
+// checkPinnedCPUs checks that CPUs a container asks to be pinned to are
+// online and are not in the exclusive pool
+func (g *Gardener) checkPinnedCPUs(list string) error {
+	requested, err := g.checkCPUList(CPUSetCPUsKey, list, g.SysInfoProvider.CPUs)
+	if err != nil || g.CPUSetAllocator == nil {
+		return err
+	}
+
+	exclusive := g.CPUSetAllocator.Pool()
+	for _, n := range requested {
+		for _, cpu := range exclusive {
+			if n == cpu {
+				return fmt.Errorf("%s: %d is in the exclusive CPU pool (%s)", CPUSetCPUsKey, n, sysinfo.FormatCPUList(exclusive))
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkCPUList checks that every number in the list is online, returning
+// them. Ranges are bounded by the highest online number before they are
+// expanded, as the list comes from the client.
+func (g *Gardener) checkCPUList(key, list string, online func() ([]int, error)) ([]int, error) {
+	available, err := online()
+	if err != nil {
+		return nil, err
+	}
+
+	highest := 0
+	onHost := make(map[int]bool)
+	for _, n := range available {
+		onHost[n] = true
+		if n > highest {
+			highest = n
+		}
+	}
+
+	requested, err := sysinfo.ParseCPUListWithin(list, highest)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", key, err)
+	}
+
+	for _, n := range requested {
+		if !onHost[n] {
+			return nil, fmt.Errorf("%s: %d is not online (online: %s)", key, n, sysinfo.FormatCPUList(available))
+		}
+	}
+
+	return requested, nil
+}
+
+func (g *Gardener) releaseCPUs(handle string) {
+	if g.CPUSetAllocator != nil {
+		g.CPUSetAllocator.Release(handle)
+	}
+}
+
 func (g *Gardener) Lookup(handle string) (garden.Container, error) {
 	return &container{
 		logger:          g.Logger,
@@ -216,6 +454,10 @@ func (g *Gardener) Destroy(handle string) error {
 		return err
 	}
 
+	// the container's processes are gone, so its CPUs are free even if
+	// tidying up the rest of it fails
+	g.releaseCPUs(handle)
+
 	if err := g.Networker.Destroy(g.Logger, handle); err != nil {
 		return err
 	}
@@ -227,6 +469,17 @@ func (g *Gardener) Destroy(handle string) error {
 	return g.PropertyManager.DestroyKeySpace(handle)
 }
 
+// Pause freezes the container's processes until it is resumed. It is not
+// part of the Garden API, so is served by the admin API.
+func (g *Gardener) Pause(handle string) error {
+	return g.Containerizer.Pause(g.Logger, handle)
+}
+
+// Resume thaws the processes of a paused container
+func (g *Gardener) Resume(handle string) error {
+	return g.Containerizer.Resume(g.Logger, handle)
+}
+
 func (g *Gardener) Stop()                                    {}
 func (g *Gardener) GraceTime(garden.Container) time.Duration { return 0 }
 func (g *Gardener) Ping() error                              { return nil }
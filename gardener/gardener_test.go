@@ -50,6 +50,72 @@ var _ = Describe("Gardener", func() {
 		}
 	})
 
+	Describe("starting", func() {
+		var (
+			starter   *fakes.FakeStarter
+			allocator *fakes.FakeCPUSetAllocator
+		)
+
+		BeforeEach(func() {
+			starter = new(fakes.FakeStarter)
+			allocator = new(fakes.FakeCPUSetAllocator)
+			gdnr.Starter = starter
+			gdnr.CPUSetAllocator = allocator
+
+			containerizer.HandlesReturns([]string{"fred", "george"}, nil)
+			containerizer.InfoStub = func(_ lager.Logger, handle string) (gardener.ActualContainerSpec, error) {
+				if handle == "fred" {
+					return gardener.ActualContainerSpec{CPUSet: gardener.CPUSet{CPUs: "4-5"}}, nil
+				}
+
+				return gardener.ActualContainerSpec{}, nil
+			}
+		})
+
+		It("runs the start-up tasks", func() {
+			Expect(gdnr.Start()).To(Succeed())
+			Expect(starter.StartCallCount()).To(Equal(1))
+		})
+
+		It("gives existing containers back the CPUs they are pinned to", func() {
+			Expect(gdnr.Start()).To(Succeed())
+
+			Expect(allocator.ClaimCallCount()).To(Equal(1))
+			handle, cpus := allocator.ClaimArgsForCall(0)
+			Expect(handle).To(Equal("fred"))
+			Expect(cpus).To(Equal([]int{4, 5}))
+		})
+
+		It("skips containers whose info can't be read", func() {
+			containerizer.InfoReturns(gardener.ActualContainerSpec{}, errors.New("boom"))
+
+			Expect(gdnr.Start()).To(Succeed())
+			Expect(allocator.ClaimCallCount()).To(Equal(0))
+		})
+
+		It("fails when the start-up tasks fail", func() {
+			starter.StartReturns(errors.New("boom"))
+			Expect(gdnr.Start()).To(MatchError("boom"))
+			Expect(allocator.ClaimCallCount()).To(Equal(0))
+		})
+
+		It("fails when the containers can't be listed", func() {
+			containerizer.HandlesReturns(nil, errors.New("boom"))
+			Expect(gdnr.Start()).To(MatchError("boom"))
+		})
+
+		Context("when there is no exclusive CPU pool", func() {
+			BeforeEach(func() {
+				gdnr.CPUSetAllocator = nil
+			})
+
+			It("does not look at the containers", func() {
+				Expect(gdnr.Start()).To(Succeed())
+				Expect(containerizer.HandlesCallCount()).To(Equal(0))
+			})
+		})
+	})
+
 	Describe("creating a container", func() {
 		Context("when a handle is specified", func() {
 			It("passes the network hooks to the containerizer", func() {
@@ -138,6 +204,18 @@ var _ = Describe("Gardener", func() {
 				})
 			})
 
+			It("should pass the properties to the containerizer", func() {
+				_, err := gdnr.Create(garden.ContainerSpec{
+					Properties: garden.Properties{"foo": "bar"},
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(containerizer.CreateCallCount()).To(Equal(1))
+
+				_, spec := containerizer.CreateArgsForCall(0)
+				Expect(spec.Properties).To(Equal(garden.Properties{"foo": "bar"}))
+			})
+
 			It("should ask the shed for a namespaced rootfs", func() {
 				_, err := gdnr.Create(garden.ContainerSpec{})
 				Expect(err).NotTo(HaveOccurred())
@@ -368,6 +446,181 @@ var _ = Describe("Gardener", func() {
 				Expect(spec.BindMounts).To(Equal(bindMounts))
 			})
 		})
+
+		It("refuses properties which say what the container was granted", func() {
+			_, err := gdnr.Create(garden.ContainerSpec{
+				Properties: garden.Properties{gardener.GrantedPropertyPrefix + "capabilities": "CAP_SYS_ADMIN"},
+			})
+			Expect(err).To(MatchError("property garden.granted-capabilities is reserved: garden.granted-* properties are set by guardian"))
+			Expect(containerizer.CreateCallCount()).To(Equal(0))
+		})
+
+		It("refuses the property which lists the container's tmpfs mounts", func() {
+			_, err := gdnr.Create(garden.ContainerSpec{
+				Properties: garden.Properties{gardener.MountedTmpfsProperty: "/tmp:1g:1777"},
+			})
+			Expect(err).To(MatchError("property garden.mounted-tmpfs is reserved: it is set by guardian"))
+			Expect(containerizer.CreateCallCount()).To(Equal(0))
+		})
+
+		Context("when the container asks to be pinned to CPUs and memory nodes", func() {
+			BeforeEach(func() {
+				sysinfoProvider.CPUsReturns([]int{0, 1, 2, 3}, nil)
+				sysinfoProvider.MemoryNodesReturns([]int{0, 1}, nil)
+			})
+
+			It("passes the cpuset to the containerizer", func() {
+				_, err := gdnr.Create(garden.ContainerSpec{
+					Properties: garden.Properties{gardener.CPUSetCPUsKey: "1-2", gardener.CPUSetMemsKey: "1"},
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				_, spec := containerizer.CreateArgsForCall(0)
+				Expect(spec.CPUSet).To(Equal(gardener.CPUSet{CPUs: "1-2", Mems: "1"}))
+			})
+
+			It("fails when a CPU is not online", func() {
+				sysinfoProvider.CPUsReturns([]int{0, 1, 3}, nil)
+
+				_, err := gdnr.Create(garden.ContainerSpec{
+					Properties: garden.Properties{gardener.CPUSetCPUsKey: "1-2"},
+				})
+				Expect(err).To(MatchError("garden.cpuset-cpus: 2 is not online (online: 0-1,3)"))
+				Expect(networker.HooksCallCount()).To(Equal(0))
+			})
+
+			It("fails, without expanding it, when a range goes beyond the online CPUs", func() {
+				_, err := gdnr.Create(garden.ContainerSpec{
+					Properties: garden.Properties{gardener.CPUSetCPUsKey: "0-2000000000"},
+				})
+				Expect(err).To(MatchError(ContainSubstring("2000000000 is above the highest allowed, 3")))
+				Expect(networker.HooksCallCount()).To(Equal(0))
+			})
+
+			Context("and an exclusive CPU pool is configured", func() {
+				BeforeEach(func() {
+					allocator := new(fakes.FakeCPUSetAllocator)
+					allocator.PoolReturns([]int{2, 3})
+					gdnr.CPUSetAllocator = allocator
+				})
+
+				It("fails when a CPU is in the pool", func() {
+					_, err := gdnr.Create(garden.ContainerSpec{
+						Properties: garden.Properties{gardener.CPUSetCPUsKey: "1-2"},
+					})
+					Expect(err).To(MatchError("garden.cpuset-cpus: 2 is in the exclusive CPU pool (2-3)"))
+					Expect(containerizer.CreateCallCount()).To(Equal(0))
+				})
+
+				It("allows CPUs outside the pool", func() {
+					_, err := gdnr.Create(garden.ContainerSpec{
+						Properties: garden.Properties{gardener.CPUSetCPUsKey: "0-1"},
+					})
+					Expect(err).NotTo(HaveOccurred())
+				})
+			})
+
+			It("fails when a memory node is not online", func() {
+				_, err := gdnr.Create(garden.ContainerSpec{
+					Properties: garden.Properties{gardener.CPUSetMemsKey: "2"},
+				})
+				Expect(err).To(MatchError(ContainSubstring("2 is above the highest allowed, 1")))
+			})
+
+			It("fails when the list is invalid", func() {
+				_, err := gdnr.Create(garden.ContainerSpec{
+					Properties: garden.Properties{gardener.CPUSetCPUsKey: "banana"},
+				})
+				Expect(err).To(MatchError(ContainSubstring("invalid cpu list")))
+			})
+		})
+
+		Context("when the container asks for exclusive CPUs", func() {
+			var allocator *fakes.FakeCPUSetAllocator
+
+			BeforeEach(func() {
+				allocator = new(fakes.FakeCPUSetAllocator)
+				allocator.AllocateReturns([]int{4, 5, 6}, nil)
+				gdnr.CPUSetAllocator = allocator
+			})
+
+			create := func() error {
+				_, err := gdnr.Create(garden.ContainerSpec{
+					Handle:     "bob",
+					Properties: garden.Properties{gardener.CPUSetExclusiveCPUsKey: "3"},
+				})
+				return err
+			}
+
+			It("allocates them and passes them to the containerizer", func() {
+				Expect(create()).To(Succeed())
+
+				Expect(allocator.AllocateCallCount()).To(Equal(1))
+				handle, count := allocator.AllocateArgsForCall(0)
+				Expect(handle).To(Equal("bob"))
+				Expect(count).To(Equal(3))
+
+				_, spec := containerizer.CreateArgsForCall(0)
+				Expect(spec.CPUSet.CPUs).To(Equal("4-6"))
+			})
+
+			It("records them in the container's properties", func() {
+				Expect(create()).To(Succeed())
+
+				var allProps = make(map[string]string)
+				for i := 0; i < propertyManager.SetCallCount(); i++ {
+					_, name, value := propertyManager.SetArgsForCall(i)
+					allProps[name] = value
+				}
+
+				Expect(allProps).To(HaveKeyWithValue(gardener.CPUSetCPUsKey, "4-6"))
+			})
+
+			It("returns an error when they can't be allocated", func() {
+				allocator.AllocateReturns(nil, errors.New("pool is empty"))
+				Expect(create()).To(MatchError("pool is empty"))
+				Expect(containerizer.CreateCallCount()).To(Equal(0))
+			})
+
+			It("releases them when the container can't be created", func() {
+				containerizer.CreateReturns(errors.New("boom"))
+				Expect(create()).NotTo(Succeed())
+
+				Expect(allocator.ReleaseCallCount()).To(Equal(1))
+				Expect(allocator.ReleaseArgsForCall(0)).To(Equal("bob"))
+			})
+
+			It("releases them when the volume can't be created", func() {
+				volumeCreator.CreateReturns("", nil, errors.New("boom"))
+				Expect(create()).NotTo(Succeed())
+				Expect(allocator.ReleaseCallCount()).To(Equal(1))
+			})
+
+			It("fails when the container also asks for particular CPUs", func() {
+				_, err := gdnr.Create(garden.ContainerSpec{
+					Properties: garden.Properties{gardener.CPUSetExclusiveCPUsKey: "3", gardener.CPUSetCPUsKey: "0"},
+				})
+				Expect(err).To(MatchError(ContainSubstring("can't both be set")))
+				Expect(allocator.AllocateCallCount()).To(Equal(0))
+			})
+
+			It("fails when the count is not a positive number", func() {
+				_, err := gdnr.Create(garden.ContainerSpec{
+					Properties: garden.Properties{gardener.CPUSetExclusiveCPUsKey: "0"},
+				})
+				Expect(err).To(MatchError(ContainSubstring("must be a positive number")))
+			})
+
+			Context("and no exclusive CPU pool is configured", func() {
+				BeforeEach(func() {
+					gdnr.CPUSetAllocator = nil
+				})
+
+				It("fails", func() {
+					Expect(create()).To(MatchError(ContainSubstring("no exclusive CPU pool is configured")))
+				})
+			})
+		})
 	})
 
 	Context("when having a container", func() {
@@ -552,6 +805,27 @@ var _ = Describe("Gardener", func() {
 		})
 	})
 
+	Describe("pausing and resuming a container", func() {
+		It("asks the containerizer to pause the container", func() {
+			Expect(gdnr.Pause("some-handle")).To(Succeed())
+			Expect(containerizer.PauseCallCount()).To(Equal(1))
+			_, handle := containerizer.PauseArgsForCall(0)
+			Expect(handle).To(Equal("some-handle"))
+		})
+
+		It("asks the containerizer to resume the container", func() {
+			Expect(gdnr.Resume("some-handle")).To(Succeed())
+			Expect(containerizer.ResumeCallCount()).To(Equal(1))
+			_, handle := containerizer.ResumeArgsForCall(0)
+			Expect(handle).To(Equal("some-handle"))
+		})
+
+		It("returns the containerizer's errors", func() {
+			containerizer.PauseReturns(errors.New("runc pause: exit status 1"))
+			Expect(gdnr.Pause("some-handle")).To(MatchError("runc pause: exit status 1"))
+		})
+	})
+
 	Describe("destroying a container", func() {
 		It("asks the containerizer to destroy the container", func() {
 			Expect(gdnr.Destroy("some-handle")).To(Succeed())
@@ -582,6 +856,15 @@ var _ = Describe("Gardener", func() {
 			Expect(propertyManager.DestroyKeySpaceArgsForCall(0)).To(Equal("some-handle"))
 		})
 
+		It("releases the container's exclusive CPUs", func() {
+			allocator := new(fakes.FakeCPUSetAllocator)
+			gdnr.CPUSetAllocator = allocator
+
+			Expect(gdnr.Destroy("some-handle")).To(Succeed())
+			Expect(allocator.ReleaseCallCount()).To(Equal(1))
+			Expect(allocator.ReleaseArgsForCall(0)).To(Equal("some-handle"))
+		})
+
 		Context("when containerizer fails to destroy the container", func() {
 			BeforeEach(func() {
 				containerizer.DestroyReturns(errors.New("containerized deletion failed"))
@@ -598,6 +881,14 @@ var _ = Describe("Gardener", func() {
 
 				Expect(networker.DestroyCallCount()).To(Equal(0))
 			})
+
+			It("keeps the container's exclusive CPUs", func() {
+				allocator := new(fakes.FakeCPUSetAllocator)
+				gdnr.CPUSetAllocator = allocator
+
+				Expect(gdnr.Destroy("some-handle")).NotTo(Succeed())
+				Expect(allocator.ReleaseCallCount()).To(Equal(0))
+			})
 		})
 
 		Context("when network deletion fails", func() {
@@ -627,6 +918,14 @@ var _ = Describe("Gardener", func() {
 				err := gdnr.Destroy("some-handle")
 				Expect(err).To(MatchError("rootfs deletion failed"))
 			})
+
+			It("still releases the container's exclusive CPUs", func() {
+				allocator := new(fakes.FakeCPUSetAllocator)
+				gdnr.CPUSetAllocator = allocator
+
+				Expect(gdnr.Destroy("some-handle")).NotTo(Succeed())
+				Expect(allocator.ReleaseCallCount()).To(Equal(1))
+			})
 		})
 	})
 
@@ -711,6 +1010,15 @@ var _ = Describe("Gardener", func() {
 			Expect(handle).To(Equal("some-handle"))
 			Expect(name).To(Equal("name"))
 		})
+
+		It("does not let clients set or remove what the container was granted", func() {
+			Expect(container.SetProperty("garden.granted-capabilities", "CAP_SYS_ADMIN")).To(MatchError(ContainSubstring("is reserved")))
+			Expect(container.RemoveProperty("garden.granted-capabilities")).To(MatchError(ContainSubstring("is reserved")))
+			Expect(container.SetProperty("garden.mounted-tmpfs", "/tmp:1g:1777")).To(MatchError(ContainSubstring("is reserved")))
+
+			Expect(propertyManager.SetCallCount()).To(Equal(0))
+			Expect(propertyManager.RemoveCallCount()).To(Equal(0))
+		})
 	})
 
 	Describe("BulkInfo", func() {
@@ -787,6 +1095,15 @@ var _ = Describe("Gardener", func() {
 			Expect(info.State).To(Equal("active"))
 		})
 
+		It("reports the state as 'paused' when the containerizer says the container is paused", func() {
+			containerizer.InfoReturns(gardener.ActualContainerSpec{Paused: true}, nil)
+
+			info, err := container.Info()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(info.State).To(Equal("paused"))
+		})
+
 		It("returns the garden.network.container-ip property from the propertyManager as the ContainerIP", func() {
 			properties[gardener.ContainerIPKey] = "1.2.3.4"
 
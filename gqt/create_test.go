@@ -214,6 +214,27 @@ var _ = Describe("Creating a Container", func() {
 			})
 			Expect(err).NotTo(HaveOccurred())
 		})
+
+		It("should set the hostname to the handle, matching /etc/hosts", func() {
+			container, err := client.Create(garden.ContainerSpec{
+				Handle: "hostname_banana",
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			out := gbytes.NewBuffer()
+			proc, err := container.Run(garden.ProcessSpec{
+				Path: "sh",
+				Args: []string{"-c", "hostname && cat /etc/hosts"},
+			}, garden.ProcessIO{
+				Stdout: io.MultiWriter(GinkgoWriter, out),
+				Stderr: GinkgoWriter,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(proc.Wait()).To(Equal(0))
+
+			Expect(out).To(gbytes.Say("hostname-banana\n"))
+			Expect(out).To(gbytes.Say(" hostname-banana\n"))
+		})
 	})
 
 	Context("when creating a container fails", func() {
@@ -235,14 +256,14 @@ var _ = Describe("Creating a Container", func() {
 })
 
 func initProcessPID(handle string) int {
-	Eventually(fmt.Sprintf("/run/opencontainer/containers/%s/state.json", handle)).Should(BeAnExistingFile())
+	Eventually(fmt.Sprintf("/run/opencontainer/containers-%d/%s/state.json", GinkgoParallelNode(), handle)).Should(BeAnExistingFile())
 
 	state := struct {
 		Pid int `json:"init_process_pid"`
 	}{}
 
 	Eventually(func() error {
-		stateFile, err := os.Open(fmt.Sprintf("/run/opencontainer/containers/%s/state.json", handle))
+		stateFile, err := os.Open(fmt.Sprintf("/run/opencontainer/containers-%d/%s/state.json", GinkgoParallelNode(), handle))
 		Expect(err).NotTo(HaveOccurred())
 		defer stateFile.Close()
 
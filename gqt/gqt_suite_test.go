@@ -2,7 +2,6 @@ package gqt_test
 
 import (
 	"os"
-	"os/exec"
 	"path"
 	"runtime"
 	"time"
@@ -23,7 +22,7 @@ var defaultRuntime = map[string]string{
 
 var ginkgoIO = garden.ProcessIO{Stdout: GinkgoWriter, Stderr: GinkgoWriter}
 
-var ociRuntimeBin, gardenBin, initBin, kawasakiBin, iodaemonBin, nstarBin string
+var ociRuntimeBin, gardenBin, initBin, kawasakiBin, iodaemonBin string
 
 func TestGqt(t *testing.T) {
 	RegisterFailHandler(Fail)
@@ -49,13 +48,6 @@ func TestGqt(t *testing.T) {
 
 			bins["init_bin_path"], err = gexec.Build("github.com/cloudfoundry-incubator/guardian/cmd/init")
 			Expect(err).NotTo(HaveOccurred())
-
-			cmd := exec.Command("make")
-			cmd.Dir = "../rundmc/nstar"
-			cmd.Stdout = GinkgoWriter
-			cmd.Stderr = GinkgoWriter
-			Expect(cmd.Run()).To(Succeed())
-			bins["nstar_bin_path"] = "../rundmc/nstar/nstar"
 		}
 
 		data, err := json.Marshal(bins)
@@ -69,7 +61,6 @@ func TestGqt(t *testing.T) {
 		ociRuntimeBin = bins["oci_runtime_path"]
 		gardenBin = bins["garden_bin_path"]
 		iodaemonBin = bins["iodaemon_bin_path"]
-		nstarBin = bins["nstar_bin_path"]
 		kawasakiBin = bins["kawasaki_bin_path"]
 		initBin = bins["init_bin_path"]
 	})
@@ -97,5 +88,5 @@ func startGarden(argv ...string) *runner.RunningGarden {
 		argv = append(argv, "--networkModulePath="+networkModulePath)
 	}
 
-	return runner.Start(gardenBin, initBin, kawasakiBin, iodaemonBin, nstarBin, argv...)
+	return runner.Start(gardenBin, initBin, kawasakiBin, iodaemonBin, argv...)
 }
@@ -24,7 +24,6 @@ import (
 
 var RootFSPath = os.Getenv("GARDEN_TEST_ROOTFS")
 var GraphRoot = os.Getenv("GARDEN_TEST_GRAPHPATH")
-var TarPath = os.Getenv("GARDEN_TAR_PATH")
 
 type RunningGarden struct {
 	client.Client
@@ -43,7 +42,7 @@ type RunningGarden struct {
 	logger lager.Logger
 }
 
-func Start(bin, initBin, kawasakiBin, iodaemonBin, nstarBin string, argv ...string) *RunningGarden {
+func Start(bin, initBin, kawasakiBin, iodaemonBin string, argv ...string) *RunningGarden {
 	network := "unix"
 	addr := fmt.Sprintf("/tmp/garden_%d.sock", GinkgoParallelNode())
 	tmpDir := filepath.Join(
@@ -71,7 +70,7 @@ func Start(bin, initBin, kawasakiBin, iodaemonBin, nstarBin string, argv ...stri
 		Client: client.New(connection.New(network, addr)),
 	}
 
-	c := cmd(tmpDir, depotDir, graphPath, network, addr, bin, initBin, kawasakiBin, iodaemonBin, nstarBin, TarPath, RootFSPath, argv...)
+	c := cmd(tmpDir, depotDir, graphPath, network, addr, bin, initBin, kawasakiBin, iodaemonBin, RootFSPath, argv...)
 	r.runner = ginkgomon.New(ginkgomon.Config{
 		Name:              "guardian",
 		Command:           c,
@@ -127,7 +126,7 @@ func (r *RunningGarden) Stop() error {
 	return err
 }
 
-func cmd(tmpdir, depotDir, graphPath, network, addr, bin, initBin, kawasakiBin, iodaemonBin, nstarBin, tarBin, rootFSPath string, argv ...string) *exec.Cmd {
+func cmd(tmpdir, depotDir, graphPath, network, addr, bin, initBin, kawasakiBin, iodaemonBin, rootFSPath string, argv ...string) *exec.Cmd {
 	Expect(os.MkdirAll(tmpdir, 0755)).To(Succeed())
 
 	snapshotsPath := filepath.Join(tmpdir, "snapshots")
@@ -161,8 +160,6 @@ func cmd(tmpdir, depotDir, graphPath, network, addr, bin, initBin, kawasakiBin,
 	gardenArgs = appendDefaultFlag(gardenArgs, "--initBin", initBin)
 	gardenArgs = appendDefaultFlag(gardenArgs, "--iodaemonBin", iodaemonBin)
 	gardenArgs = appendDefaultFlag(gardenArgs, "--kawasakiBin", kawasakiBin)
-	gardenArgs = appendDefaultFlag(gardenArgs, "--nstarBin", nstarBin)
-	gardenArgs = appendDefaultFlag(gardenArgs, "--tarBin", tarBin)
 	gardenArgs = appendDefaultFlag(gardenArgs, "--logLevel", "debug")
 	gardenArgs = appendDefaultFlag(gardenArgs, "--debugAddr", fmt.Sprintf(":808%d", ginkgo.GinkgoParallelNode()))
 	gardenArgs = appendDefaultFlag(gardenArgs, "--rootfs", rootFSPath)
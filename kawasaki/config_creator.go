@@ -42,13 +42,23 @@ type Creator struct {
 	dnsServers      []net.IP
 }
 
-func NewConfigCreator(idGenerator IDGenerator, interfacePrefix, chainPrefix string, externalIP net.IP, dnsServers []net.IP) *Creator {
+// ValidatePrefixes returns an error if generated interface or chain names
+// would exceed the kernel's limits
+func ValidatePrefixes(interfacePrefix, chainPrefix string) error {
 	if len(interfacePrefix) > maxInterfacePrefixLen {
-		panic("interface prefix is too long")
+		return fmt.Errorf("interface prefix %q is too long (max %d characters)", interfacePrefix, maxInterfacePrefixLen)
 	}
 
 	if len(chainPrefix) > maxChainPrefixLen {
-		panic("chain prefix is too long")
+		return fmt.Errorf("chain prefix %q is too long (max %d characters)", chainPrefix, maxChainPrefixLen)
+	}
+
+	return nil
+}
+
+func NewConfigCreator(idGenerator IDGenerator, interfacePrefix, chainPrefix string, externalIP net.IP, dnsServers []net.IP) *Creator {
+	if err := ValidatePrefixes(interfacePrefix, chainPrefix); err != nil {
+		panic(err)
 	}
 
 	return &Creator{
@@ -51,6 +51,20 @@ var _ = Describe("ConfigCreator", func() {
 		}).To(Panic())
 	})
 
+	Describe("ValidatePrefixes", func() {
+		It("accepts prefixes within the limits", func() {
+			Expect(kawasaki.ValidatePrefixes("w1", "0123456789abcdef")).To(Succeed())
+		})
+
+		It("rejects an interface prefix longer than 2 characters", func() {
+			Expect(kawasaki.ValidatePrefixes("too-long", "wc")).To(MatchError(ContainSubstring("interface prefix")))
+		})
+
+		It("rejects a chain prefix longer than 16 characters", func() {
+			Expect(kawasaki.ValidatePrefixes("w1", "0123456789abcdefg")).To(MatchError(ContainSubstring("chain prefix")))
+		})
+	})
+
 	It("assigns the bridge name based on the subnet", func() {
 		config, err := creator.Create(logger, "banana", subnet, ip)
 		Expect(err).NotTo(HaveOccurred())
@@ -8,11 +8,11 @@ import (
 )
 
 type HostsFileCompiler struct {
-	Handle string
-	IP     net.IP
+	Hostname string
+	IP       net.IP
 }
 
 func (h *HostsFileCompiler) Compile(log lager.Logger) ([]byte, error) {
-	contents := fmt.Sprintf("127.0.0.1 localhost\n%s %s\n", h.IP, h.Handle)
+	contents := fmt.Sprintf("127.0.0.1 localhost\n%s %s\n", h.IP, h.Hostname)
 	return []byte(contents), nil
 }
@@ -20,8 +20,8 @@ var _ = Describe("HostsFileCompiler", func() {
 
 	BeforeEach(func() {
 		compiler = HostsFileCompiler{
-			Handle: "my-handle",
-			IP:     net.ParseIP("123.124.126.128"),
+			Hostname: "my-hostname",
+			IP:       net.ParseIP("123.124.126.128"),
 		}
 
 		log = lagertest.NewTestLogger("test")
@@ -37,7 +37,7 @@ var _ = Describe("HostsFileCompiler", func() {
 		It("should configure the hostname mapping", func() {
 			contents, err := compiler.Compile(log)
 			Expect(err).NotTo(HaveOccurred())
-			Expect(string(contents)).To(ContainSubstring("123.124.126.128 my-handle"))
+			Expect(string(contents)).To(ContainSubstring("123.124.126.128 my-hostname"))
 		})
 	})
 })
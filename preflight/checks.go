@@ -0,0 +1,202 @@
+package preflight
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/cloudfoundry/gunk/command_runner"
+)
+
+// Host builds checks against the host. ProcPath is normally /proc.
+type Host struct {
+	ProcPath      string
+	LookPath      func(file string) (string, error)
+	CommandRunner command_runner.CommandRunner
+}
+
+func NewHost(runner command_runner.CommandRunner) Host {
+	return Host{
+		ProcPath:      "/proc",
+		LookPath:      exec.LookPath,
+		CommandRunner: runner,
+	}
+}
+
+// Binary checks that name is on the PATH. If versionArgs are given, the
+// first line of the binary's output when run with them is reported.
+func (h Host) Binary(name string, severity Severity, advice string, versionArgs ...string) Check {
+	return Check{
+		Name:     name + " on PATH",
+		Severity: severity,
+		Advice:   advice,
+		Run: func() (string, error) {
+			path, err := h.LookPath(name)
+			if err != nil {
+				return "", err
+			}
+
+			if len(versionArgs) == 0 {
+				return path, nil
+			}
+
+			out := new(bytes.Buffer)
+			cmd := exec.Command(path, versionArgs...)
+			cmd.Stdout = out
+			cmd.Stderr = out
+			if err := h.CommandRunner.Run(cmd); err != nil {
+				return path, fmt.Errorf("%s %s: %s: %s", path, strings.Join(versionArgs, " "), err, strings.TrimSpace(out.String()))
+			}
+
+			version := strings.TrimSpace(strings.SplitN(out.String(), "\n", 2)[0])
+			return fmt.Sprintf("%s, %s", path, version), nil
+		},
+	}
+}
+
+// Executable checks that there is an executable file at path
+func (h Host) Executable(name, path string, severity Severity, advice string) Check {
+	return Check{
+		Name:     name,
+		Severity: severity,
+		Advice:   advice,
+		Run: func() (string, error) {
+			info, err := os.Stat(path)
+			if err != nil {
+				return "", err
+			}
+
+			if info.IsDir() || info.Mode()&0111 == 0 {
+				return "", fmt.Errorf("%s is not executable", path)
+			}
+
+			return path, nil
+		},
+	}
+}
+
+// UserNamespaces checks that the kernel supports user namespaces and that
+// they have not been disabled
+func (h Host) UserNamespaces() Check {
+	return Check{
+		Name:     "user namespaces",
+		Severity: Fatal,
+		Advice:   "unprivileged containers need a kernel built with CONFIG_USER_NS, and user.max_user_namespaces must be greater than 0",
+		Run: func() (string, error) {
+			if _, err := os.Stat(filepath.Join(h.ProcPath, "self", "ns", "user")); err != nil {
+				return "", fmt.Errorf("not supported by the kernel: %s", err)
+			}
+
+			max, err := ioutil.ReadFile(filepath.Join(h.ProcPath, "sys", "user", "max_user_namespaces"))
+			if err == nil && strings.TrimSpace(string(max)) == "0" {
+				return "", fmt.Errorf("disabled (user.max_user_namespaces is 0)")
+			}
+
+			return "", nil
+		},
+	}
+}
+
+// Filesystem checks that the kernel supports the named filesystem
+func (h Host) Filesystem(fs string, severity Severity, advice string) Check {
+	return Check{
+		Name:     fs + " filesystem",
+		Severity: severity,
+		Advice:   advice,
+		Run: func() (string, error) {
+			filesystems, err := os.Open(filepath.Join(h.ProcPath, "filesystems"))
+			if err != nil {
+				return "", err
+			}
+			defer filesystems.Close()
+
+			scanner := bufio.NewScanner(filesystems)
+			for scanner.Scan() {
+				fields := strings.Fields(scanner.Text())
+				if len(fields) > 0 && fields[len(fields)-1] == fs {
+					return "", nil
+				}
+			}
+
+			return "", fmt.Errorf("not listed in %s", filesystems.Name())
+		},
+	}
+}
+
+// Cgroups checks that the cgroup controllers which guardian relies on are
+// enabled
+func (h Host) Cgroups(controllers ...string) Check {
+	return Check{
+		Name:     "cgroups",
+		Severity: Fatal,
+		Advice:   "enable the missing controllers, e.g. with the cgroup_enable kernel parameter",
+		Run: func() (string, error) {
+			cgroups, err := os.Open(filepath.Join(h.ProcPath, "cgroups"))
+			if err != nil {
+				return "", err
+			}
+			defer cgroups.Close()
+
+			enabled := make(map[string]bool)
+			scanner := bufio.NewScanner(cgroups)
+			for scanner.Scan() {
+				fields := strings.Fields(scanner.Text())
+				if len(fields) == 4 && fields[3] == "1" {
+					enabled[fields[0]] = true
+				}
+			}
+
+			var missing []string
+			for _, controller := range controllers {
+				if !enabled[controller] {
+					missing = append(missing, controller)
+				}
+			}
+
+			if len(missing) > 0 {
+				return "", fmt.Errorf("controllers not enabled: %s", strings.Join(missing, ", "))
+			}
+
+			return "", nil
+		},
+	}
+}
+
+// WritableDir checks that path is (or can be made) a writable directory
+func WritableDir(name, path string, severity Severity, advice string) Check {
+	return Check{
+		Name:     name,
+		Severity: severity,
+		Advice:   advice,
+		Run: func() (string, error) {
+			if err := os.MkdirAll(path, 0755); err != nil {
+				return "", err
+			}
+
+			f, err := ioutil.TempFile(path, ".preflight")
+			if err != nil {
+				return "", err
+			}
+
+			f.Close()
+			return path, os.Remove(f.Name())
+		},
+	}
+}
+
+// Flag reports the result of validating a command line flag
+func Flag(name string, err error, advice string) Check {
+	return Check{
+		Name:     "-" + name + " flag",
+		Severity: Fatal,
+		Advice:   advice,
+		Run: func() (string, error) {
+			return "", err
+		},
+	}
+}
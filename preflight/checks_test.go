@@ -0,0 +1,212 @@
+package preflight_test
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/cloudfoundry-incubator/guardian/preflight"
+	"github.com/cloudfoundry/gunk/command_runner/fake_command_runner"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Host checks", func() {
+	var (
+		tmpDir   string
+		procPath string
+		runner   *fake_command_runner.FakeCommandRunner
+		host     preflight.Host
+	)
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "preflight")
+		Expect(err).NotTo(HaveOccurred())
+
+		procPath = filepath.Join(tmpDir, "proc")
+		Expect(os.MkdirAll(filepath.Join(procPath, "self", "ns"), 0755)).To(Succeed())
+		Expect(os.MkdirAll(filepath.Join(procPath, "sys", "user"), 0755)).To(Succeed())
+
+		runner = fake_command_runner.New()
+		host = preflight.Host{
+			ProcPath: procPath,
+			LookPath: func(file string) (string, error) {
+				if file == "runc" {
+					return "/usr/local/bin/runc", nil
+				}
+
+				return "", errors.New("executable file not found in $PATH")
+			},
+			CommandRunner: runner,
+		}
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(tmpDir)).To(Succeed())
+	})
+
+	writeProc := func(name, contents string) {
+		Expect(ioutil.WriteFile(filepath.Join(procPath, name), []byte(contents), 0644)).To(Succeed())
+	}
+
+	Describe("Binary", func() {
+		It("reports the path of a binary on the PATH", func() {
+			detail, err := host.Binary("runc", preflight.Fatal, "").Run()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(detail).To(Equal("/usr/local/bin/runc"))
+		})
+
+		It("fails when the binary is not on the PATH", func() {
+			_, err := host.Binary("tar", preflight.Fatal, "").Run()
+			Expect(err).To(MatchError(ContainSubstring("not found")))
+		})
+
+		Context("when version arguments are given", func() {
+			It("reports the first line of the version", func() {
+				runner.WhenRunning(fake_command_runner.CommandSpec{
+					Path: "/usr/local/bin/runc",
+					Args: []string{"--version"},
+				}, func(cmd *exec.Cmd) error {
+					fmt.Fprintf(cmd.Stdout, "runc version 0.0.7\ncommit: abc\n")
+					return nil
+				})
+
+				detail, err := host.Binary("runc", preflight.Fatal, "", "--version").Run()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(detail).To(Equal("/usr/local/bin/runc, runc version 0.0.7"))
+			})
+
+			It("fails when the binary cannot report its version", func() {
+				runner.WhenRunning(fake_command_runner.CommandSpec{
+					Path: "/usr/local/bin/runc",
+				}, func(cmd *exec.Cmd) error {
+					fmt.Fprintf(cmd.Stderr, "bad binary")
+					return errors.New("exit status 1")
+				})
+
+				_, err := host.Binary("runc", preflight.Fatal, "", "--version").Run()
+				Expect(err).To(MatchError(ContainSubstring("bad binary")))
+			})
+		})
+	})
+
+	Describe("Executable", func() {
+		It("passes for an executable file", func() {
+			path := filepath.Join(tmpDir, "iodaemon")
+			Expect(ioutil.WriteFile(path, []byte{}, 0755)).To(Succeed())
+
+			_, err := host.Executable("iodaemon", path, preflight.Fatal, "").Run()
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("fails for a file which is not executable", func() {
+			path := filepath.Join(tmpDir, "iodaemon")
+			Expect(ioutil.WriteFile(path, []byte{}, 0644)).To(Succeed())
+
+			_, err := host.Executable("iodaemon", path, preflight.Fatal, "").Run()
+			Expect(err).To(MatchError(ContainSubstring("is not executable")))
+		})
+
+		It("fails for a missing file", func() {
+			_, err := host.Executable("iodaemon", filepath.Join(tmpDir, "nope"), preflight.Fatal, "").Run()
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("UserNamespaces", func() {
+		It("fails when the kernel does not support them", func() {
+			_, err := host.UserNamespaces().Run()
+			Expect(err).To(MatchError(ContainSubstring("not supported by the kernel")))
+		})
+
+		Context("when the kernel supports them", func() {
+			BeforeEach(func() {
+				writeProc("self/ns/user", "")
+			})
+
+			It("passes", func() {
+				_, err := host.UserNamespaces().Run()
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("fails when they are disabled", func() {
+				writeProc("sys/user/max_user_namespaces", "0\n")
+
+				_, err := host.UserNamespaces().Run()
+				Expect(err).To(MatchError(ContainSubstring("disabled")))
+			})
+		})
+	})
+
+	Describe("Filesystem", func() {
+		BeforeEach(func() {
+			writeProc("filesystems", "nodev\tsysfs\nnodev\ttmpfs\n\text4\nnodev\taufs\n")
+		})
+
+		It("passes when the filesystem is listed", func() {
+			_, err := host.Filesystem("aufs", preflight.Warning, "").Run()
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = host.Filesystem("ext4", preflight.Warning, "").Run()
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("fails when the filesystem is not listed", func() {
+			_, err := host.Filesystem("overlay", preflight.Warning, "").Run()
+			Expect(err).To(MatchError(ContainSubstring("not listed")))
+		})
+	})
+
+	Describe("Cgroups", func() {
+		BeforeEach(func() {
+			writeProc("cgroups", "#subsys_name\thierarchy\tnum_cgroups\tenabled\ncpu\t2\t1\t1\nmemory\t3\t1\t0\n")
+		})
+
+		It("passes when the controllers are enabled", func() {
+			_, err := host.Cgroups("cpu").Run()
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("names the controllers which are missing or disabled", func() {
+			_, err := host.Cgroups("cpu", "memory", "devices").Run()
+			Expect(err).To(MatchError("controllers not enabled: memory, devices"))
+		})
+	})
+
+	Describe("WritableDir", func() {
+		It("passes for a writable directory, creating it if needed", func() {
+			path := filepath.Join(tmpDir, "depot")
+
+			_, err := preflight.WritableDir("depot", path, preflight.Fatal, "").Run()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(path).To(BeADirectory())
+
+			files, err := ioutil.ReadDir(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(files).To(BeEmpty())
+		})
+
+		It("fails when the directory cannot be created", func() {
+			path := filepath.Join(tmpDir, "file")
+			Expect(ioutil.WriteFile(path, []byte{}, 0644)).To(Succeed())
+
+			_, err := preflight.WritableDir("depot", filepath.Join(path, "depot"), preflight.Fatal, "").Run()
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("Flag", func() {
+		It("is fatal, and fails with the validation error", func() {
+			check := preflight.Flag("tag", errors.New("too long"), "use a shorter tag")
+			Expect(check.Severity).To(Equal(preflight.Fatal))
+			Expect(check.Name).To(Equal("-tag flag"))
+
+			_, err := check.Run()
+			Expect(err).To(MatchError("too long"))
+		})
+	})
+})
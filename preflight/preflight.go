@@ -0,0 +1,112 @@
+// Package preflight checks that the host can run containers before guardian
+// starts serving, so that problems are reported up front rather than half way
+// through the first create.
+package preflight
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/pivotal-golang/lager"
+)
+
+type Severity int
+
+const (
+	// Warning checks degrade some features when they fail
+	Warning Severity = iota
+
+	// Fatal checks stop guardian from starting when they fail
+	Fatal
+)
+
+func (s Severity) String() string {
+	if s == Fatal {
+		return "fatal"
+	}
+
+	return "warning"
+}
+
+// Check is a single preflight check. Run returns a detail to include in the
+// report (e.g. a version) or an error. Advice tells the operator how to fix a
+// failure.
+type Check struct {
+	Name     string
+	Severity Severity
+	Advice   string
+	Run      func() (string, error)
+}
+
+type Result struct {
+	Check  Check
+	Detail string
+	Err    error
+}
+
+type Report []Result
+
+// Run runs every check, logging the failures
+func Run(log lager.Logger, checks ...Check) Report {
+	log = log.Session("preflight")
+
+	log.Info("started")
+	defer log.Info("finished")
+
+	var report Report
+	for _, check := range checks {
+		detail, err := check.Run()
+		if err != nil {
+			log.Error("check-failed", err, lager.Data{"check": check.Name, "severity": check.Severity.String()})
+		}
+
+		report = append(report, Result{Check: check, Detail: detail, Err: err})
+	}
+
+	return report
+}
+
+// Fatal returns true if any fatal check failed
+func (r Report) Fatal() bool {
+	for _, result := range r {
+		if result.Err != nil && result.Check.Severity == Fatal {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WriteTo writes a human readable summary of the report
+func (r Report) WriteTo(w io.Writer) (int64, error) {
+	var written int64
+	printf := func(format string, args ...interface{}) error {
+		n, err := fmt.Fprintf(w, format, args...)
+		written += int64(n)
+		return err
+	}
+
+	if err := printf("preflight checks:\n"); err != nil {
+		return written, err
+	}
+
+	for _, result := range r {
+		var err error
+		switch {
+		case result.Err == nil && result.Detail == "":
+			err = printf("  [ ok ] %s\n", result.Check.Name)
+		case result.Err == nil:
+			err = printf("  [ ok ] %s (%s)\n", result.Check.Name, result.Detail)
+		case result.Check.Severity == Fatal:
+			err = printf("  [FAIL] %s: %s\n         %s\n", result.Check.Name, result.Err, result.Check.Advice)
+		default:
+			err = printf("  [WARN] %s: %s\n         %s\n", result.Check.Name, result.Err, result.Check.Advice)
+		}
+
+		if err != nil {
+			return written, err
+		}
+	}
+
+	return written, nil
+}
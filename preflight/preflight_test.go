@@ -0,0 +1,89 @@
+package preflight_test
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/cloudfoundry-incubator/guardian/preflight"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/pivotal-golang/lager/lagertest"
+)
+
+func check(name string, severity preflight.Severity, detail string, err error) preflight.Check {
+	return preflight.Check{
+		Name:     name,
+		Severity: severity,
+		Advice:   "fix " + name,
+		Run: func() (string, error) {
+			return detail, err
+		},
+	}
+}
+
+var _ = Describe("Run", func() {
+	var logger *lagertest.TestLogger
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+	})
+
+	It("runs every check, in order", func() {
+		report := preflight.Run(logger,
+			check("a", preflight.Fatal, "", errors.New("a failed")),
+			check("b", preflight.Warning, "b detail", nil),
+		)
+
+		Expect(report).To(HaveLen(2))
+		Expect(report[0].Check.Name).To(Equal("a"))
+		Expect(report[0].Err).To(MatchError("a failed"))
+		Expect(report[1].Check.Name).To(Equal("b"))
+		Expect(report[1].Detail).To(Equal("b detail"))
+	})
+
+	It("logs failed checks", func() {
+		preflight.Run(logger, check("a", preflight.Warning, "", errors.New("a failed")))
+		Expect(logger).To(gbytes.Say(`check-failed.*"check":"a".*"severity":"warning"`))
+	})
+
+	Describe("Fatal", func() {
+		It("is true when a fatal check has failed", func() {
+			Expect(preflight.Run(logger,
+				check("a", preflight.Warning, "", nil),
+				check("b", preflight.Fatal, "", errors.New("b failed")),
+			).Fatal()).To(BeTrue())
+		})
+
+		It("is false when only warnings have failed", func() {
+			Expect(preflight.Run(logger,
+				check("a", preflight.Warning, "", errors.New("a failed")),
+				check("b", preflight.Fatal, "", nil),
+			).Fatal()).To(BeFalse())
+		})
+	})
+
+	Describe("WriteTo", func() {
+		It("summarises each check, with advice for failures", func() {
+			report := preflight.Run(logger,
+				check("a", preflight.Fatal, "", nil),
+				check("b", preflight.Fatal, "1.2.3", nil),
+				check("c", preflight.Warning, "", errors.New("c failed")),
+				check("d", preflight.Fatal, "", errors.New("d failed")),
+			)
+
+			out := new(bytes.Buffer)
+			_, err := report.WriteTo(out)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(out.String()).To(Equal(`preflight checks:
+  [ ok ] a
+  [ ok ] b (1.2.3)
+  [WARN] c: c failed
+         fix c
+  [FAIL] d: d failed
+         fix d
+`))
+		})
+	})
+})
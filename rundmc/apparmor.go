@@ -0,0 +1,68 @@
+package rundmc
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/cloudfoundry/gunk/command_runner"
+)
+
+// DefaultAppArmorProfileName is the name of the profile in DefaultAppArmorProfile
+const DefaultAppArmorProfileName = "garden-default"
+
+// DefaultAppArmorProfile lets container processes do most things, but stops
+// them from mounting file systems and from writing to the parts of /proc and
+// /sys which are not namespaced.
+const DefaultAppArmorProfile = `#include <tunables/global>
+
+profile garden-default flags=(attach_disconnected,mediate_deleted) {
+  #include <abstractions/base>
+
+  network,
+  capability,
+  file,
+  umount,
+
+  deny mount,
+
+  deny @{PROC}/* w,
+  deny @{PROC}/{[^1-9],[^1-9][^0-9],[^1-9s][^0-9y][^0-9s],[^1-9][^0-9][^0-9][^0-9]*}/** w,
+  deny @{PROC}/sys/[^k]** w,
+  deny @{PROC}/sys/kernel/{?,??,[^s][^h][^m]**} w,
+  deny @{PROC}/sysrq-trigger rwklx,
+  deny @{PROC}/mem rwklx,
+  deny @{PROC}/kmem rwklx,
+  deny @{PROC}/kcore rwklx,
+
+  deny /sys/[^f]*/** wklx,
+  deny /sys/f[^s]*/** wklx,
+  deny /sys/fs/[^c]*/** wklx,
+  deny /sys/fs/c[^g]*/** wklx,
+  deny /sys/fs/cg[^r]*/** wklx,
+  deny /sys/firmware/efi/efivars/** rwklx,
+  deny /sys/kernel/security/** rwklx,
+}
+`
+
+// AppArmorStarter loads (or reloads) an AppArmor profile in to the kernel
+// so that containers can be confined by it
+type AppArmorStarter struct {
+	Profile       string
+	CommandRunner command_runner.CommandRunner
+}
+
+func (s *AppArmorStarter) Start() error {
+	stderr := new(bytes.Buffer)
+
+	cmd := exec.Command("apparmor_parser", "--replace")
+	cmd.Stdin = strings.NewReader(s.Profile)
+	cmd.Stderr = stderr
+
+	if err := s.CommandRunner.Run(cmd); err != nil {
+		return fmt.Errorf("loading apparmor profile: %s: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	return nil
+}
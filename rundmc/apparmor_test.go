@@ -0,0 +1,65 @@
+package rundmc_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os/exec"
+
+	"github.com/cloudfoundry-incubator/guardian/rundmc"
+	"github.com/cloudfoundry/gunk/command_runner/fake_command_runner"
+	. "github.com/cloudfoundry/gunk/command_runner/fake_command_runner/matchers"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("AppArmorStarter", func() {
+	var (
+		runner  *fake_command_runner.FakeCommandRunner
+		starter *rundmc.AppArmorStarter
+	)
+
+	BeforeEach(func() {
+		runner = fake_command_runner.New()
+		starter = &rundmc.AppArmorStarter{
+			Profile:       "profile banana {}",
+			CommandRunner: runner,
+		}
+	})
+
+	It("loads the profile with apparmor_parser", func() {
+		var loaded []byte
+		runner.WhenRunning(fake_command_runner.CommandSpec{
+			Path: "apparmor_parser",
+		}, func(cmd *exec.Cmd) error {
+			var err error
+			loaded, err = ioutil.ReadAll(cmd.Stdin)
+			return err
+		})
+
+		Expect(starter.Start()).To(Succeed())
+		Expect(runner).To(HaveExecutedSerially(fake_command_runner.CommandSpec{
+			Path: "apparmor_parser",
+			Args: []string{"--replace"},
+		}))
+		Expect(string(loaded)).To(Equal("profile banana {}"))
+	})
+
+	Context("when apparmor_parser fails", func() {
+		BeforeEach(func() {
+			runner.WhenRunning(fake_command_runner.CommandSpec{
+				Path: "apparmor_parser",
+			}, func(cmd *exec.Cmd) error {
+				cmd.Stderr.Write([]byte("syntax error\n"))
+				return errors.New("exit status 1")
+			})
+		})
+
+		It("returns an error including its stderr", func() {
+			Expect(starter.Start()).To(MatchError("loading apparmor profile: exit status 1: syntax error"))
+		})
+	})
+
+	It("names the default profile DefaultAppArmorProfileName", func() {
+		Expect(rundmc.DefaultAppArmorProfile).To(ContainSubstring("profile " + rundmc.DefaultAppArmorProfileName + " "))
+	})
+})
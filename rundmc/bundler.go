@@ -1,25 +1,53 @@
 package rundmc
 
 import (
+	"github.com/cloudfoundry-incubator/garden"
 	"github.com/cloudfoundry-incubator/goci"
 	"github.com/cloudfoundry-incubator/guardian/gardener"
 )
 
 //go:generate counterfeiter . BundlerRule
 type BundlerRule interface {
-	Apply(bndle *goci.Bndl, spec gardener.DesiredContainerSpec) *goci.Bndl
+	Apply(bndle *goci.Bndl, spec gardener.DesiredContainerSpec) (*goci.Bndl, error)
+}
+
+//go:generate counterfeiter . Granter
+
+// Granter is a rule which grants a container something it asked for (e.g.
+// extra capabilities). What was granted is only recorded in the container's
+// properties once the container has been created.
+type Granter interface {
+	Grants(spec gardener.DesiredContainerSpec) garden.Properties
 }
 
 type BundleTemplate struct {
 	Rules []BundlerRule
 }
 
-func (b BundleTemplate) Generate(spec gardener.DesiredContainerSpec) *goci.Bndl {
+func (b BundleTemplate) Generate(spec gardener.DesiredContainerSpec) (*goci.Bndl, error) {
 	var bndl *goci.Bndl
 
 	for _, rule := range b.Rules {
-		bndl = rule.Apply(bndl, spec)
+		var err error
+		if bndl, err = rule.Apply(bndl, spec); err != nil {
+			return nil, err
+		}
+	}
+
+	return bndl, nil
+}
+
+// Grants collects what the template's rules granted a container which has
+// been created from spec
+func (b BundleTemplate) Grants(spec gardener.DesiredContainerSpec) garden.Properties {
+	grants := garden.Properties{}
+	for _, rule := range b.Rules {
+		if granter, ok := rule.(Granter); ok {
+			for name, value := range granter.Grants(spec) {
+				grants[name] = value
+			}
+		}
 	}
 
-	return bndl
+	return grants
 }
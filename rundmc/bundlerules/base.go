@@ -10,10 +10,10 @@ type Base struct {
 	UnprivilegedBase *goci.Bndl
 }
 
-func (r Base) Apply(bndl *goci.Bndl, spec gardener.DesiredContainerSpec) *goci.Bndl {
+func (r Base) Apply(bndl *goci.Bndl, spec gardener.DesiredContainerSpec) (*goci.Bndl, error) {
 	if spec.Privileged {
-		return r.PrivilegedBase
+		return r.PrivilegedBase, nil
 	} else {
-		return r.UnprivilegedBase
+		return r.UnprivilegedBase, nil
 	}
 }
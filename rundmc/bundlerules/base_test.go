@@ -28,9 +28,10 @@ var _ = Describe("Base", func() {
 
 	Context("when it is privileged", func() {
 		It("should use the correct base", func() {
-			retBndl := rule.Apply(nil, gardener.DesiredContainerSpec{
+			retBndl, err := rule.Apply(nil, gardener.DesiredContainerSpec{
 				Privileged: true,
 			})
+			Expect(err).NotTo(HaveOccurred())
 
 			Expect(retBndl).To(Equal(privilegeBndl))
 		})
@@ -38,9 +39,10 @@ var _ = Describe("Base", func() {
 
 	Context("when it is not privileged", func() {
 		It("should use the correct base", func() {
-			retBndl := rule.Apply(nil, gardener.DesiredContainerSpec{
+			retBndl, err := rule.Apply(nil, gardener.DesiredContainerSpec{
 				Privileged: false,
 			})
+			Expect(err).NotTo(HaveOccurred())
 
 			Expect(retBndl).To(Equal(unprivilegeBndl))
 		})
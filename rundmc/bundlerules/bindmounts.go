@@ -1,30 +1,210 @@
 package bundlerules
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
 	"github.com/cloudfoundry-incubator/garden"
 	"github.com/cloudfoundry-incubator/goci"
 	"github.com/cloudfoundry-incubator/guardian/gardener"
 	"github.com/opencontainers/specs"
 )
 
+// BindMountOptionsProperty is a comma-separated list of extra options for
+// the container's bind mounts, each the mount's destination followed by one
+// or more colon-separated options (e.g. "/var/data:rbind:rslave")
+const BindMountOptionsProperty = "garden.bind-mount-options"
+
+// PropagationModes are the mount propagation modes a bind mount may ask for
+var PropagationModes = []string{"rprivate", "rslave", "rshared"}
+
+// BindMounts adds the container's bind mounts to the bundle. Container-origin
+// sources are resolved, symlinks included, within the container's rootfs;
+// host sources must exist and, if AllowedSourcePrefixes is not empty, be
+// beneath one of them.
 type BindMounts struct {
+	AllowedSourcePrefixes []string
 }
 
-func (b BindMounts) Apply(bndl *goci.Bndl, spec gardener.DesiredContainerSpec) *goci.Bndl {
+func (b BindMounts) Apply(bndl *goci.Bndl, spec gardener.DesiredContainerSpec) (*goci.Bndl, error) {
+	options, err := bindMountOptions(spec.Properties[BindMountOptionsProperty], spec.BindMounts)
+	if err != nil {
+		return nil, err
+	}
+
 	var mounts []specs.Mount
 	for _, m := range spec.BindMounts {
+		if !filepath.IsAbs(m.DstPath) {
+			return nil, fmt.Errorf("bind mount %s: destination must be absolute", m.DstPath)
+		}
+
+		source, err := b.source(m, spec.RootFSPath)
+		if err != nil {
+			return nil, fmt.Errorf("bind mount %s: %s", m.DstPath, err)
+		}
+
+		bindOpt, propagation := "bind", ""
+		for _, opt := range options[filepath.Clean(m.DstPath)] {
+			if opt == "rbind" {
+				bindOpt = "rbind"
+			} else {
+				propagation = opt
+			}
+		}
+
 		modeOpt := "ro"
 		if m.Mode == garden.BindMountModeRW {
 			modeOpt = "rw"
 		}
 
+		opts := []string{bindOpt, modeOpt}
+		if propagation != "" {
+			opts = append(opts, propagation)
+		}
+
 		mounts = append(mounts, specs.Mount{
 			Destination: m.DstPath,
-			Source:      m.SrcPath,
+			Source:      source,
 			Type:        "bind",
-			Options:     []string{"bind", modeOpt},
+			Options:     opts,
 		})
 	}
 
-	return bndl.WithMounts(mounts...)
+	return bndl.WithMounts(mounts...), nil
+}
+
+func (b BindMounts) source(m garden.BindMount, rootFSPath string) (string, error) {
+	if m.Origin == garden.BindMountOriginContainer {
+		source, err := followSymlinksInRootFS(rootFSPath, m.SrcPath)
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("source %s does not exist in the container", m.SrcPath)
+		}
+
+		if err != nil {
+			return "", fmt.Errorf("source %s: %s", m.SrcPath, err)
+		}
+
+		return source, nil
+	}
+
+	if !filepath.IsAbs(m.SrcPath) {
+		return "", fmt.Errorf("source %s must be absolute", m.SrcPath)
+	}
+
+	source, err := filepath.EvalSymlinks(m.SrcPath)
+	if err != nil {
+		return "", fmt.Errorf("source %s does not exist", m.SrcPath)
+	}
+
+	if !b.allowed(source) {
+		return "", fmt.Errorf("source %s is not allowed (allowed: %s)", m.SrcPath, strings.Join(b.AllowedSourcePrefixes, ", "))
+	}
+
+	return source, nil
+}
+
+// followSymlinksInRootFS resolves path as the container would see it, with
+// rootFSPath as its root: ".." and absolute symlinks stop at the rootfs
+// rather than climbing out in to the host's filesystem.
+func followSymlinksInRootFS(rootFSPath, path string) (string, error) {
+	resolved := "/"
+	remaining := filepath.Clean("/" + path)
+
+	for links := 0; remaining != ""; {
+		var part string
+		remaining = strings.TrimPrefix(remaining, "/")
+		if i := strings.Index(remaining, "/"); i >= 0 {
+			part, remaining = remaining[:i], remaining[i:]
+		} else {
+			part, remaining = remaining, ""
+		}
+
+		switch part {
+		case "", ".":
+			continue
+		case "..":
+			resolved = filepath.Dir(resolved)
+			continue
+		}
+
+		next := filepath.Join(resolved, part)
+		info, err := os.Lstat(filepath.Join(rootFSPath, next))
+		if err != nil {
+			return "", err
+		}
+
+		if info.Mode()&os.ModeSymlink == 0 {
+			resolved = next
+			continue
+		}
+
+		if links++; links > 255 {
+			return "", fmt.Errorf("too many levels of symbolic links")
+		}
+
+		target, err := os.Readlink(filepath.Join(rootFSPath, next))
+		if err != nil {
+			return "", err
+		}
+
+		if filepath.IsAbs(target) {
+			resolved = "/"
+		}
+
+		remaining = "/" + target + remaining
+	}
+
+	return filepath.Join(rootFSPath, resolved), nil
+}
+
+func (b BindMounts) allowed(source string) bool {
+	if len(b.AllowedSourcePrefixes) == 0 {
+		return true
+	}
+
+	for _, prefix := range b.AllowedSourcePrefixes {
+		prefix = filepath.Clean(prefix)
+		if source == prefix || strings.HasPrefix(source, strings.TrimSuffix(prefix, "/")+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func bindMountOptions(property string, bindMounts []garden.BindMount) (map[string][]string, error) {
+	destinations := make(map[string]bool)
+	for _, m := range bindMounts {
+		destinations[filepath.Clean(m.DstPath)] = true
+	}
+
+	options := make(map[string][]string)
+	for _, entry := range list(property) {
+		parts := strings.Split(entry, ":")
+		dst := filepath.Clean(parts[0])
+		if len(parts) < 2 || !destinations[dst] {
+			return nil, fmt.Errorf("bind mount options %q: must name the destination of a bind mount followed by options", entry)
+		}
+
+		propagations := 0
+		for _, opt := range parts[1:] {
+			switch {
+			case opt == "rbind":
+			case contains(PropagationModes, opt):
+				propagations++
+			default:
+				return nil, fmt.Errorf("bind mount options %q: unknown option %q (allowed: rbind, %s)", entry, opt, strings.Join(PropagationModes, ", "))
+			}
+		}
+
+		if propagations > 1 {
+			return nil, fmt.Errorf("bind mount options %q: only one propagation mode may be given", entry)
+		}
+
+		options[dst] = append(options[dst], parts[1:]...)
+	}
+
+	return options, nil
 }
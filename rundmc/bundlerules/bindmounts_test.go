@@ -1,7 +1,12 @@
 package bundlerules_test
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
 	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
 	. "github.com/onsi/gomega"
 	"github.com/opencontainers/specs"
 
@@ -12,40 +17,261 @@ import (
 )
 
 var _ = Describe("BindMountsRule", func() {
-	var newBndl *goci.Bndl
+	var (
+		hostDir   string
+		rootFSDir string
+		rule      bundlerules.BindMounts
+	)
 
 	BeforeEach(func() {
-		newBndl = bundlerules.BindMounts{}.Apply(goci.Bundle(), gardener.DesiredContainerSpec{
-			BindMounts: []garden.BindMount{
-				{
-					SrcPath: "/path/to/ro/src",
-					DstPath: "/path/to/ro/dest",
-					Mode:    garden.BindMountModeRO,
-				},
-				{
-					SrcPath: "/path/to/rw/src",
-					DstPath: "/path/to/rw/dest",
-					Mode:    garden.BindMountModeRW,
-				},
-			},
-		})
+		var err error
+		hostDir, err = ioutil.TempDir("", "bindmounts-host")
+		Expect(err).NotTo(HaveOccurred())
+		hostDir, err = filepath.EvalSymlinks(hostDir)
+		Expect(err).NotTo(HaveOccurred())
+
+		rootFSDir, err = ioutil.TempDir("", "bindmounts-rootfs")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(os.MkdirAll(filepath.Join(hostDir, "ro", "src"), 0755)).To(Succeed())
+		Expect(os.MkdirAll(filepath.Join(hostDir, "rw", "src"), 0755)).To(Succeed())
+		Expect(os.MkdirAll(filepath.Join(rootFSDir, "var", "data"), 0755)).To(Succeed())
+
+		rule = bundlerules.BindMounts{}
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(hostDir)).To(Succeed())
+		Expect(os.RemoveAll(rootFSDir)).To(Succeed())
 	})
 
+	apply := func(props garden.Properties, bindMounts ...garden.BindMount) (*goci.Bndl, error) {
+		return rule.Apply(goci.Bundle(), gardener.DesiredContainerSpec{
+			RootFSPath: rootFSDir,
+			BindMounts: bindMounts,
+			Properties: props,
+		})
+	}
+
 	It("adds mounts in the bundle spec", func() {
+		newBndl, err := apply(nil,
+			garden.BindMount{
+				SrcPath: filepath.Join(hostDir, "ro", "src"),
+				DstPath: "/path/to/ro/dest",
+				Mode:    garden.BindMountModeRO,
+			},
+			garden.BindMount{
+				SrcPath: filepath.Join(hostDir, "rw", "src"),
+				DstPath: "/path/to/rw/dest",
+				Mode:    garden.BindMountModeRW,
+			},
+		)
+		Expect(err).NotTo(HaveOccurred())
+
 		Expect(newBndl.Mounts()).To(HaveLen(2))
 
 		Expect(newBndl.Mounts()).To(ContainElement(specs.Mount{
 			Destination: "/path/to/ro/dest",
 			Type:        "bind",
-			Source:      "/path/to/ro/src",
+			Source:      filepath.Join(hostDir, "ro", "src"),
 			Options:     []string{"bind", "ro"},
 		}))
 
 		Expect(newBndl.Mounts()).To(ContainElement(specs.Mount{
 			Destination: "/path/to/rw/dest",
 			Type:        "bind",
-			Source:      "/path/to/rw/src",
+			Source:      filepath.Join(hostDir, "rw", "src"),
 			Options:     []string{"bind", "rw"},
 		}))
 	})
+
+	Context("when the source is in the container", func() {
+		It("resolves it relative to the container's rootfs", func() {
+			newBndl, err := apply(nil, garden.BindMount{
+				SrcPath: "/var/data",
+				DstPath: "/data",
+				Origin:  garden.BindMountOriginContainer,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(newBndl.Mounts()).To(ConsistOf(specs.Mount{
+				Destination: "/data",
+				Type:        "bind",
+				Source:      filepath.Join(rootFSDir, "var", "data"),
+				Options:     []string{"bind", "ro"},
+			}))
+		})
+
+		It("does not let the source climb out of the rootfs", func() {
+			Expect(os.MkdirAll(filepath.Join(rootFSDir, "etc"), 0755)).To(Succeed())
+
+			newBndl, err := apply(nil, garden.BindMount{
+				SrcPath: "../../../etc",
+				DstPath: "/data",
+				Origin:  garden.BindMountOriginContainer,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(newBndl.Mounts()[0].Source).To(Equal(filepath.Join(rootFSDir, "etc")))
+		})
+
+		Context("and it is reached through a symlink in the rootfs", func() {
+			var hostTarget string
+
+			BeforeEach(func() {
+				hostTarget = filepath.Join(hostDir, "rw", "src")
+				Expect(os.MkdirAll(filepath.Join(rootFSDir, hostTarget), 0755)).To(Succeed())
+			})
+
+			mountSource := func(srcPath string) string {
+				newBndl, err := apply(nil, garden.BindMount{
+					SrcPath: srcPath,
+					DstPath: "/data",
+					Origin:  garden.BindMountOriginContainer,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				return newBndl.Mounts()[0].Source
+			}
+
+			It("resolves absolute symlinks within the rootfs", func() {
+				Expect(os.Symlink(hostTarget, filepath.Join(rootFSDir, "var", "link"))).To(Succeed())
+				Expect(mountSource("/var/link")).To(Equal(filepath.Join(rootFSDir, hostTarget)))
+			})
+
+			It("resolves symlinked parent directories within the rootfs", func() {
+				Expect(os.Symlink(filepath.Dir(hostTarget), filepath.Join(rootFSDir, "var", "link"))).To(Succeed())
+				Expect(mountSource("/var/link/src")).To(Equal(filepath.Join(rootFSDir, hostTarget)))
+			})
+
+			It("does not let relative symlinks climb out of the rootfs", func() {
+				Expect(os.Symlink("../../../../../../.."+hostTarget, filepath.Join(rootFSDir, "var", "link"))).To(Succeed())
+				Expect(mountSource("/var/link")).To(Equal(filepath.Join(rootFSDir, hostTarget)))
+			})
+
+			It("returns an error when the symlinks loop", func() {
+				Expect(os.Symlink("/var/loop", filepath.Join(rootFSDir, "var", "loop"))).To(Succeed())
+
+				_, err := apply(nil, garden.BindMount{
+					SrcPath: "/var/loop",
+					DstPath: "/data",
+					Origin:  garden.BindMountOriginContainer,
+				})
+				Expect(err).To(MatchError(ContainSubstring("too many levels of symbolic links")))
+			})
+
+			It("returns an error when a symlink dangles", func() {
+				Expect(os.Symlink("/not/there", filepath.Join(rootFSDir, "var", "link"))).To(Succeed())
+
+				_, err := apply(nil, garden.BindMount{
+					SrcPath: "/var/link",
+					DstPath: "/data",
+					Origin:  garden.BindMountOriginContainer,
+				})
+				Expect(err).To(MatchError(ContainSubstring("/var/link does not exist in the container")))
+			})
+		})
+
+		It("does not check it against the allowed source prefixes", func() {
+			rule.AllowedSourcePrefixes = []string{hostDir}
+
+			_, err := apply(nil, garden.BindMount{
+				SrcPath: "/var/data",
+				DstPath: "/data",
+				Origin:  garden.BindMountOriginContainer,
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("returns an error when it does not exist", func() {
+			_, err := apply(nil, garden.BindMount{
+				SrcPath: "/not/there",
+				DstPath: "/data",
+				Origin:  garden.BindMountOriginContainer,
+			})
+			Expect(err).To(MatchError(ContainSubstring("/not/there does not exist in the container")))
+		})
+	})
+
+	Context("when the source is on the host", func() {
+		It("returns an error when it does not exist", func() {
+			_, err := apply(nil, garden.BindMount{SrcPath: filepath.Join(hostDir, "missing"), DstPath: "/data"})
+			Expect(err).To(MatchError(ContainSubstring("missing does not exist")))
+		})
+
+		It("returns an error when it is relative", func() {
+			_, err := apply(nil, garden.BindMount{SrcPath: "ro/src", DstPath: "/data"})
+			Expect(err).To(MatchError(ContainSubstring("must be absolute")))
+		})
+
+		Context("and source prefixes are allowed", func() {
+			BeforeEach(func() {
+				rule.AllowedSourcePrefixes = []string{filepath.Join(hostDir, "ro")}
+			})
+
+			It("allows sources beneath an allowed prefix", func() {
+				_, err := apply(nil, garden.BindMount{SrcPath: filepath.Join(hostDir, "ro", "src"), DstPath: "/data"})
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("rejects sources outside every allowed prefix", func() {
+				_, err := apply(nil, garden.BindMount{SrcPath: filepath.Join(hostDir, "rw", "src"), DstPath: "/data"})
+				Expect(err).To(MatchError(ContainSubstring("is not allowed")))
+			})
+
+			It("rejects sources which only share a prefix's name", func() {
+				Expect(os.MkdirAll(filepath.Join(hostDir, "roots"), 0755)).To(Succeed())
+
+				_, err := apply(nil, garden.BindMount{SrcPath: filepath.Join(hostDir, "roots"), DstPath: "/data"})
+				Expect(err).To(MatchError(ContainSubstring("is not allowed")))
+			})
+
+			It("rejects symlinks which lead outside every allowed prefix", func() {
+				link := filepath.Join(hostDir, "ro", "link")
+				Expect(os.Symlink(filepath.Join(hostDir, "rw", "src"), link)).To(Succeed())
+
+				_, err := apply(nil, garden.BindMount{SrcPath: link, DstPath: "/data"})
+				Expect(err).To(MatchError(ContainSubstring("is not allowed")))
+			})
+		})
+	})
+
+	It("returns an error when the destination is relative", func() {
+		_, err := apply(nil, garden.BindMount{SrcPath: filepath.Join(hostDir, "ro", "src"), DstPath: "data"})
+		Expect(err).To(MatchError(ContainSubstring("destination must be absolute")))
+	})
+
+	Context("when the container asks for bind mount options", func() {
+		It("adds a recursive bind and propagation mode to the mount", func() {
+			newBndl, err := apply(
+				garden.Properties{bundlerules.BindMountOptionsProperty: "/data:rbind:rslave"},
+				garden.BindMount{SrcPath: filepath.Join(hostDir, "ro", "src"), DstPath: "/data", Mode: garden.BindMountModeRW},
+			)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(newBndl.Mounts()[0].Options).To(Equal([]string{"rbind", "rw", "rslave"}))
+		})
+
+		It("leaves other mounts alone", func() {
+			newBndl, err := apply(
+				garden.Properties{bundlerules.BindMountOptionsProperty: "/data:rshared"},
+				garden.BindMount{SrcPath: filepath.Join(hostDir, "ro", "src"), DstPath: "/data"},
+				garden.BindMount{SrcPath: filepath.Join(hostDir, "rw", "src"), DstPath: "/other"},
+			)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(newBndl.Mounts()[0].Options).To(Equal([]string{"bind", "ro", "rshared"}))
+			Expect(newBndl.Mounts()[1].Options).To(Equal([]string{"bind", "ro"}))
+		})
+
+		DescribeTable("rejects invalid options",
+			func(options, message string) {
+				_, err := apply(
+					garden.Properties{bundlerules.BindMountOptionsProperty: options},
+					garden.BindMount{SrcPath: filepath.Join(hostDir, "ro", "src"), DstPath: "/data"},
+				)
+				Expect(err).To(MatchError(ContainSubstring(message)))
+			},
+			Entry("an unknown option", "/data:rprivate:bananas", `unknown option "bananas"`),
+			Entry("two propagation modes", "/data:rslave:rshared", "only one propagation mode"),
+			Entry("no options", "/data", "must name the destination of a bind mount"),
+			Entry("an unknown destination", "/other:rbind", "must name the destination of a bind mount"),
+		)
+	})
 })
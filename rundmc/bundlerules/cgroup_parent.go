@@ -0,0 +1,46 @@
+package bundlerules
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/cloudfoundry-incubator/goci"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+)
+
+//go:generate counterfeiter . CgroupParentResolver
+type CgroupParentResolver interface {
+	Path(parent string) (string, error)
+}
+
+// CgroupParent places the container's cgroup under a parent named by one of
+// its properties (e.g. its org guid), so that the limits of the parent apply
+// to all of the containers in it. Containers without the property stay
+// directly under the root cgroup. The parent is only created when the
+// container is started.
+type CgroupParent struct {
+	Property string
+	Parents  CgroupParentResolver
+}
+
+func (r CgroupParent) Apply(bndl *goci.Bndl, spec gardener.DesiredContainerSpec) (*goci.Bndl, error) {
+	if r.Property == "" {
+		return bndl, nil
+	}
+
+	parent, ok := spec.Properties[r.Property]
+	if !ok || parent == "" {
+		return bndl, nil
+	}
+
+	parentPath, err := r.Parents.Path(parent)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", r.Property, err)
+	}
+
+	cgroupsPath := path.Join(parentPath, spec.Handle)
+
+	newBndl := *bndl
+	newBndl.Spec.Linux.CgroupsPath = &cgroupsPath
+	return &newBndl, nil
+}
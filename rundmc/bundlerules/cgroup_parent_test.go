@@ -0,0 +1,71 @@
+package bundlerules_test
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/goci"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/rundmc/bundlerules"
+	"github.com/cloudfoundry-incubator/guardian/rundmc/bundlerules/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CgroupParentRule", func() {
+	var (
+		fakeParents *fakes.FakeCgroupParentResolver
+		rule        bundlerules.CgroupParent
+	)
+
+	BeforeEach(func() {
+		fakeParents = new(fakes.FakeCgroupParentResolver)
+		fakeParents.PathStub = func(parent string) (string, error) {
+			return "/garden-parents/" + parent, nil
+		}
+
+		rule = bundlerules.CgroupParent{Property: "org-guid", Parents: fakeParents}
+	})
+
+	It("places the container under the parent named by the property", func() {
+		newBndl, err := rule.Apply(goci.Bundle(), gardener.DesiredContainerSpec{
+			Handle:     "some-handle",
+			Properties: garden.Properties{"org-guid": "some-org"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(fakeParents.PathArgsForCall(0)).To(Equal("some-org"))
+		Expect(*newBndl.Spec.Linux.CgroupsPath).To(Equal("/garden-parents/some-org/some-handle"))
+	})
+
+	It("leaves the bundle alone when the container does not have the property", func() {
+		bndl := goci.Bundle()
+		newBndl, err := rule.Apply(bndl, gardener.DesiredContainerSpec{Handle: "some-handle"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(newBndl).To(Equal(bndl))
+		Expect(fakeParents.PathCallCount()).To(Equal(0))
+	})
+
+	It("leaves the bundle alone when no property is configured", func() {
+		rule.Property = ""
+
+		bndl := goci.Bundle()
+		newBndl, err := rule.Apply(bndl, gardener.DesiredContainerSpec{
+			Handle:     "some-handle",
+			Properties: garden.Properties{"": "some-org"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(newBndl).To(Equal(bndl))
+	})
+
+	It("returns an error when the parent's name is invalid", func() {
+		fakeParents.PathStub = nil
+		fakeParents.PathReturns("", errors.New("invalid cgroup parent"))
+
+		_, err := rule.Apply(goci.Bundle(), gardener.DesiredContainerSpec{
+			Handle:     "some-handle",
+			Properties: garden.Properties{"org-guid": "../some-org"},
+		})
+		Expect(err).To(MatchError("org-guid: invalid cgroup parent"))
+	})
+})
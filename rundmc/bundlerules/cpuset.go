@@ -0,0 +1,41 @@
+package bundlerules
+
+import (
+	"github.com/cloudfoundry-incubator/goci"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/opencontainers/specs"
+)
+
+// CPUSet pins the container to the CPUs and memory nodes in its spec, which
+// the gardener has already checked against the host
+type CPUSet struct {
+}
+
+func (r CPUSet) Apply(bndl *goci.Bndl, spec gardener.DesiredContainerSpec) (*goci.Bndl, error) {
+	if spec.CPUSet.CPUs == "" && spec.CPUSet.Mems == "" {
+		return bndl, nil
+	}
+
+	resources := &specs.Resources{}
+	if bndl.Spec.Linux.Resources != nil {
+		*resources = *bndl.Spec.Linux.Resources
+	}
+
+	cpu := &specs.CPU{}
+	if resources.CPU != nil {
+		*cpu = *resources.CPU
+	}
+
+	if spec.CPUSet.CPUs != "" {
+		cpus := spec.CPUSet.CPUs
+		cpu.Cpus = &cpus
+	}
+
+	if spec.CPUSet.Mems != "" {
+		mems := spec.CPUSet.Mems
+		cpu.Mems = &mems
+	}
+
+	resources.CPU = cpu
+	return bndl.WithResources(resources), nil
+}
@@ -0,0 +1,43 @@
+package bundlerules_test
+
+import (
+	"github.com/cloudfoundry-incubator/goci"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/rundmc/bundlerules"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/opencontainers/specs"
+)
+
+var _ = Describe("CPUSetRule", func() {
+	It("pins the container to the CPUs and memory nodes in its spec", func() {
+		newBndl, err := bundlerules.CPUSet{}.Apply(goci.Bundle(), gardener.DesiredContainerSpec{
+			CPUSet: gardener.CPUSet{CPUs: "0-3,8", Mems: "1"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(*newBndl.Resources().CPU.Cpus).To(Equal("0-3,8"))
+		Expect(*newBndl.Resources().CPU.Mems).To(Equal("1"))
+	})
+
+	It("leaves the bundle alone when the spec has no cpuset", func() {
+		bndl := goci.Bundle()
+		newBndl, err := bundlerules.CPUSet{}.Apply(bndl, gardener.DesiredContainerSpec{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(newBndl).To(Equal(bndl))
+	})
+
+	It("does not clobber or modify the bundle's other resources", func() {
+		shares := uint64(512)
+		bndl := goci.Bundle().WithResources(&specs.Resources{CPU: &specs.CPU{Shares: &shares}})
+
+		newBndl, err := bundlerules.CPUSet{}.Apply(bndl, gardener.DesiredContainerSpec{
+			CPUSet: gardener.CPUSet{CPUs: "2"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(*newBndl.Resources().CPU.Shares).To(BeNumerically("==", 512))
+		Expect(newBndl.Resources().CPU.Mems).To(BeNil())
+		Expect(bndl.Resources().CPU.Cpus).To(BeNil())
+	})
+})
@@ -0,0 +1,201 @@
+package bundlerules
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/goci"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/opencontainers/specs"
+)
+
+// Containers request extra capabilities (e.g. CAP_SYS_PTRACE) and devices
+// (e.g. /dev/fuse) by setting these properties to comma-separated lists
+const CapabilitiesProperty = "garden.capabilities"
+const DevicesProperty = "garden.devices"
+
+// The capabilities and devices which were granted are recorded in these
+// properties
+const GrantedCapabilitiesProperty = gardener.GrantedPropertyPrefix + "capabilities"
+const GrantedDevicesProperty = gardener.GrantedPropertyPrefix + "devices"
+
+// DeviceNode is a device which can be created in, and accessed from, a
+// container
+type DeviceNode struct {
+	Path  string
+	Type  string
+	Major int64
+	Minor int64
+}
+
+// ParseDeviceNode parses a device in the form path:type:major:minor, for
+// example /dev/fuse:c:10:229
+func ParseDeviceNode(s string) (DeviceNode, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 4 {
+		return DeviceNode{}, fmt.Errorf("device %q is not in the form path:type:major:minor", s)
+	}
+
+	major, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return DeviceNode{}, fmt.Errorf("device %q: invalid major number: %s", s, err)
+	}
+
+	minor, err := strconv.ParseInt(parts[3], 10, 64)
+	if err != nil {
+		return DeviceNode{}, fmt.Errorf("device %q: invalid minor number: %s", s, err)
+	}
+
+	device := DeviceNode{Path: parts[0], Type: parts[1], Major: major, Minor: minor}
+	if !filepath.IsAbs(device.Path) {
+		return DeviceNode{}, fmt.Errorf("device %q: path is not absolute", s)
+	}
+
+	if device.Type != "b" && device.Type != "c" {
+		return DeviceNode{}, fmt.Errorf("device %q: type must be b or c", s)
+	}
+
+	return device, nil
+}
+
+// ExtraPrivileges grants containers the capabilities and devices they
+// request, as long as the operator has allowed them
+type ExtraPrivileges struct {
+	AllowedCapabilities []string
+	AllowedDevices      []DeviceNode
+}
+
+func (r ExtraPrivileges) Validate() error {
+	for _, capability := range r.AllowedCapabilities {
+		if !knownCapabilities[capability] {
+			return fmt.Errorf("unknown capability %q", capability)
+		}
+	}
+
+	return nil
+}
+
+func (r ExtraPrivileges) Apply(bndl *goci.Bndl, spec gardener.DesiredContainerSpec) (*goci.Bndl, error) {
+	capabilities := list(spec.Properties[CapabilitiesProperty])
+	devicePaths := list(spec.Properties[DevicesProperty])
+	if len(capabilities) == 0 && len(devicePaths) == 0 {
+		return bndl, nil
+	}
+
+	newBndl := *bndl
+
+	for _, capability := range capabilities {
+		if !contains(r.AllowedCapabilities, capability) {
+			return nil, fmt.Errorf("capability %s is not allowed (allowed: %s)", capability, describe(r.AllowedCapabilities))
+		}
+
+		if !contains(newBndl.Spec.Linux.Capabilities, capability) {
+			newBndl.Spec.Linux.Capabilities = append(append([]string{}, newBndl.Spec.Linux.Capabilities...), capability)
+		}
+	}
+
+	resources := &specs.Resources{}
+	if bndl.Spec.Linux.Resources != nil {
+		*resources = *bndl.Spec.Linux.Resources
+	}
+
+	for _, path := range devicePaths {
+		device, ok := r.allowedDevice(path)
+		if !ok {
+			return nil, fmt.Errorf("device %s is not allowed (allowed: %s)", path, describe(r.allowedDevicePaths()))
+		}
+
+		deviceType := rune(device.Type[0])
+		access := "rwm"
+		major, minor := device.Major, device.Minor
+		fileMode := uint32(0666)
+		root := uint32(0)
+
+		resources.Devices = append(append([]specs.DeviceCgroup{}, resources.Devices...), specs.DeviceCgroup{
+			Allow:  true,
+			Type:   &deviceType,
+			Major:  &major,
+			Minor:  &minor,
+			Access: &access,
+		})
+
+		newBndl.Spec.Linux.Devices = append(append([]specs.Device{}, newBndl.Spec.Linux.Devices...), specs.Device{
+			Path:     device.Path,
+			Type:     deviceType,
+			Major:    device.Major,
+			Minor:    device.Minor,
+			FileMode: &fileMode,
+			UID:      &root,
+			GID:      &root,
+		})
+	}
+	newBndl.Spec.Linux.Resources = resources
+
+	return &newBndl, nil
+}
+
+// Grants says which capabilities and devices a container created from spec
+// was granted
+func (r ExtraPrivileges) Grants(spec gardener.DesiredContainerSpec) garden.Properties {
+	capabilities := list(spec.Properties[CapabilitiesProperty])
+	devicePaths := list(spec.Properties[DevicesProperty])
+	if len(capabilities) == 0 && len(devicePaths) == 0 {
+		return nil
+	}
+
+	return garden.Properties{
+		GrantedCapabilitiesProperty: strings.Join(capabilities, ","),
+		GrantedDevicesProperty:      strings.Join(devicePaths, ","),
+	}
+}
+
+func (r ExtraPrivileges) allowedDevice(path string) (DeviceNode, bool) {
+	for _, device := range r.AllowedDevices {
+		if device.Path == path {
+			return device, true
+		}
+	}
+
+	return DeviceNode{}, false
+}
+
+func (r ExtraPrivileges) allowedDevicePaths() []string {
+	var paths []string
+	for _, device := range r.AllowedDevices {
+		paths = append(paths, device.Path)
+	}
+
+	return paths
+}
+
+func list(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
+func describe(allowed []string) string {
+	if len(allowed) == 0 {
+		return "none"
+	}
+
+	return strings.Join(allowed, ", ")
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,166 @@
+package bundlerules_test
+
+import (
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/goci"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/rundmc/bundlerules"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"github.com/opencontainers/specs"
+)
+
+var _ = Describe("ExtraPrivilegesRule", func() {
+	var (
+		rule    bundlerules.ExtraPrivileges
+		bndl    *goci.Bndl
+		fuse    bundlerules.DeviceNode
+		denyAll specs.DeviceCgroup
+	)
+
+	BeforeEach(func() {
+		fuse = bundlerules.DeviceNode{Path: "/dev/fuse", Type: "c", Major: 10, Minor: 229}
+
+		rule = bundlerules.ExtraPrivileges{
+			AllowedCapabilities: []string{"CAP_SYS_PTRACE", "CAP_NET_ADMIN"},
+			AllowedDevices:      []bundlerules.DeviceNode{fuse},
+		}
+
+		rwm := "rwm"
+		denyAll = specs.DeviceCgroup{Allow: false, Access: &rwm}
+		bndl = goci.Bundle().
+			WithCapabilities("CAP_CHOWN").
+			WithResources(&specs.Resources{Devices: []specs.DeviceCgroup{denyAll}})
+	})
+
+	apply := func(props garden.Properties) (*goci.Bndl, error) {
+		return rule.Apply(bndl, gardener.DesiredContainerSpec{Handle: "fred", Properties: props})
+	}
+
+	grants := func(props garden.Properties) garden.Properties {
+		return rule.Grants(gardener.DesiredContainerSpec{Handle: "fred", Properties: props})
+	}
+
+	Context("when the container does not ask for anything", func() {
+		It("returns the bundle unchanged", func() {
+			newBndl, err := apply(garden.Properties{"foo": "bar"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(newBndl).To(Equal(bndl))
+		})
+
+		It("grants nothing", func() {
+			Expect(grants(garden.Properties{"foo": "bar"})).To(BeEmpty())
+		})
+	})
+
+	Context("when the container asks for allowed capabilities", func() {
+		It("adds them to the bundle", func() {
+			newBndl, err := apply(garden.Properties{bundlerules.CapabilitiesProperty: "CAP_SYS_PTRACE, CAP_NET_ADMIN"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(newBndl.Spec.Linux.Capabilities).To(Equal([]string{"CAP_CHOWN", "CAP_SYS_PTRACE", "CAP_NET_ADMIN"}))
+		})
+
+		It("says what was granted", func() {
+			Expect(grants(garden.Properties{bundlerules.CapabilitiesProperty: "CAP_SYS_PTRACE, CAP_NET_ADMIN"})).To(Equal(garden.Properties{
+				bundlerules.GrantedCapabilitiesProperty: "CAP_SYS_PTRACE,CAP_NET_ADMIN",
+				bundlerules.GrantedDevicesProperty:      "",
+			}))
+		})
+
+		It("does not modify the original bundle", func() {
+			apply(garden.Properties{bundlerules.CapabilitiesProperty: "CAP_SYS_PTRACE"})
+			Expect(bndl.Spec.Linux.Capabilities).To(Equal([]string{"CAP_CHOWN"}))
+		})
+	})
+
+	Context("when the container asks for a capability which is not allowed", func() {
+		It("returns a clear error", func() {
+			_, err := apply(garden.Properties{bundlerules.CapabilitiesProperty: "CAP_SYS_PTRACE,CAP_SYS_ADMIN"})
+			Expect(err).To(MatchError("capability CAP_SYS_ADMIN is not allowed (allowed: CAP_SYS_PTRACE, CAP_NET_ADMIN)"))
+		})
+	})
+
+	Context("when the container asks for an allowed device", func() {
+		var newBndl *goci.Bndl
+
+		BeforeEach(func() {
+			var err error
+			newBndl, err = apply(garden.Properties{bundlerules.DevicesProperty: "/dev/fuse"})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("allows access to it in the device cgroup", func() {
+			Expect(newBndl.Resources().Devices).To(HaveLen(2))
+			Expect(newBndl.Resources().Devices[0]).To(Equal(denyAll))
+
+			allowed := newBndl.Resources().Devices[1]
+			Expect(allowed.Allow).To(BeTrue())
+			Expect(*allowed.Type).To(Equal('c'))
+			Expect(*allowed.Major).To(BeEquivalentTo(10))
+			Expect(*allowed.Minor).To(BeEquivalentTo(229))
+			Expect(*allowed.Access).To(Equal("rwm"))
+		})
+
+		It("creates the device node in the container", func() {
+			Expect(newBndl.Spec.Linux.Devices).To(HaveLen(1))
+			Expect(newBndl.Spec.Linux.Devices[0].Path).To(Equal("/dev/fuse"))
+			Expect(newBndl.Spec.Linux.Devices[0].Type).To(Equal('c'))
+			Expect(newBndl.Spec.Linux.Devices[0].Major).To(BeEquivalentTo(10))
+			Expect(newBndl.Spec.Linux.Devices[0].Minor).To(BeEquivalentTo(229))
+		})
+
+		It("says what was granted", func() {
+			Expect(grants(garden.Properties{bundlerules.DevicesProperty: "/dev/fuse"})).To(HaveKeyWithValue(bundlerules.GrantedDevicesProperty, "/dev/fuse"))
+		})
+
+		It("does not modify the original bundle", func() {
+			Expect(bndl.Resources().Devices).To(HaveLen(1))
+			Expect(bndl.Spec.Linux.Devices).To(BeEmpty())
+		})
+	})
+
+	Context("when the container asks for a device which is not allowed", func() {
+		It("returns a clear error", func() {
+			_, err := apply(garden.Properties{bundlerules.DevicesProperty: "/dev/kmsg"})
+			Expect(err).To(MatchError("device /dev/kmsg is not allowed (allowed: /dev/fuse)"))
+		})
+	})
+
+	Context("when nothing is allowed", func() {
+		It("says so", func() {
+			rule = bundlerules.ExtraPrivileges{}
+			_, err := apply(garden.Properties{bundlerules.CapabilitiesProperty: "CAP_SYS_PTRACE"})
+			Expect(err).To(MatchError("capability CAP_SYS_PTRACE is not allowed (allowed: none)"))
+		})
+	})
+
+	Describe("Validate", func() {
+		It("accepts known capabilities", func() {
+			Expect(rule.Validate()).To(Succeed())
+		})
+
+		It("rejects unknown capabilities", func() {
+			rule.AllowedCapabilities = []string{"CAP_SUPERPOWERS"}
+			Expect(rule.Validate()).To(MatchError(`unknown capability "CAP_SUPERPOWERS"`))
+		})
+	})
+
+	Describe("ParseDeviceNode", func() {
+		It("parses path:type:major:minor", func() {
+			Expect(bundlerules.ParseDeviceNode("/dev/fuse:c:10:229")).To(Equal(fuse))
+		})
+
+		DescribeTable("rejects invalid devices",
+			func(s, message string) {
+				_, err := bundlerules.ParseDeviceNode(s)
+				Expect(err).To(MatchError(ContainSubstring(message)))
+			},
+			Entry("too few fields", "/dev/fuse:c:10", "is not in the form path:type:major:minor"),
+			Entry("bad major number", "/dev/fuse:c:ten:229", "invalid major number"),
+			Entry("bad minor number", "/dev/fuse:c:10:lots", "invalid minor number"),
+			Entry("relative path", "dev/fuse:c:10:229", "path is not absolute"),
+			Entry("bad type", "/dev/fuse:x:10:229", "type must be b or c"),
+		)
+	})
+})
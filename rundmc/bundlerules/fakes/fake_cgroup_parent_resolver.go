@@ -0,0 +1,55 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/guardian/rundmc/bundlerules"
+)
+
+type FakeCgroupParentResolver struct {
+	PathStub        func(parent string) (string, error)
+	pathMutex       sync.RWMutex
+	pathArgsForCall []struct {
+		parent string
+	}
+	pathReturns struct {
+		result1 string
+		result2 error
+	}
+}
+
+func (fake *FakeCgroupParentResolver) Path(parent string) (string, error) {
+	fake.pathMutex.Lock()
+	fake.pathArgsForCall = append(fake.pathArgsForCall, struct {
+		parent string
+	}{parent})
+	fake.pathMutex.Unlock()
+	if fake.PathStub != nil {
+		return fake.PathStub(parent)
+	} else {
+		return fake.pathReturns.result1, fake.pathReturns.result2
+	}
+}
+
+func (fake *FakeCgroupParentResolver) PathCallCount() int {
+	fake.pathMutex.RLock()
+	defer fake.pathMutex.RUnlock()
+	return len(fake.pathArgsForCall)
+}
+
+func (fake *FakeCgroupParentResolver) PathArgsForCall(i int) string {
+	fake.pathMutex.RLock()
+	defer fake.pathMutex.RUnlock()
+	return fake.pathArgsForCall[i].parent
+}
+
+func (fake *FakeCgroupParentResolver) PathReturns(result1 string, result2 error) {
+	fake.PathStub = nil
+	fake.pathReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+var _ bundlerules.CgroupParentResolver = new(FakeCgroupParentResolver)
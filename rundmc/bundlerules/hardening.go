@@ -0,0 +1,53 @@
+package bundlerules
+
+import (
+	"github.com/cloudfoundry-incubator/goci"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/opencontainers/specs"
+)
+
+// DefaultMaskedPaths leak information about the host, so are hidden from
+// unprivileged containers
+var DefaultMaskedPaths = []string{
+	"/proc/kcore",
+	"/proc/latency_stats",
+	"/proc/sched_debug",
+	"/proc/timer_list",
+	"/proc/timer_stats",
+	"/sys/firmware",
+}
+
+// DefaultReadonlyPaths are not namespaced, so unprivileged containers must
+// not write to them
+var DefaultReadonlyPaths = []string{
+	"/proc/asound",
+	"/proc/bus",
+	"/proc/fs",
+	"/proc/irq",
+	"/proc/sys",
+	"/proc/sysrq-trigger",
+}
+
+// Hardening mounts a read-only sysfs in every container, and masks and
+// makes read-only sensitive paths in unprivileged containers
+type Hardening struct {
+	MaskedPaths   []string
+	ReadonlyPaths []string
+}
+
+func (r Hardening) Apply(bndl *goci.Bndl, spec gardener.DesiredContainerSpec) (*goci.Bndl, error) {
+	newBndl := bndl.WithMounts(specs.Mount{
+		Type:        "sysfs",
+		Source:      "sysfs",
+		Destination: "/sys",
+		Options:     []string{"nosuid", "noexec", "nodev", "ro"},
+	})
+
+	if spec.Privileged {
+		return newBndl, nil
+	}
+
+	newBndl.Spec.Linux.MaskedPaths = r.MaskedPaths
+	newBndl.Spec.Linux.ReadonlyPaths = r.ReadonlyPaths
+	return newBndl, nil
+}
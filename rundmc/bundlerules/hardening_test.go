@@ -0,0 +1,84 @@
+package bundlerules_test
+
+import (
+	"github.com/cloudfoundry-incubator/goci"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/rundmc/bundlerules"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/opencontainers/specs"
+)
+
+var _ = Describe("HardeningRule", func() {
+	var (
+		rule bundlerules.Hardening
+		bndl *goci.Bndl
+	)
+
+	sysfs := specs.Mount{
+		Type:        "sysfs",
+		Source:      "sysfs",
+		Destination: "/sys",
+		Options:     []string{"nosuid", "noexec", "nodev", "ro"},
+	}
+
+	BeforeEach(func() {
+		rule = bundlerules.Hardening{
+			MaskedPaths:   []string{"/proc/kcore"},
+			ReadonlyPaths: []string{"/proc/sys"},
+		}
+
+		bndl = goci.Bundle().WithMounts(specs.Mount{Type: "proc", Source: "proc", Destination: "/proc"})
+	})
+
+	Context("when the container is unprivileged", func() {
+		It("mounts a read-only sysfs", func() {
+			newBndl, err := rule.Apply(bndl, gardener.DesiredContainerSpec{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(newBndl.Mounts()).To(ConsistOf(
+				specs.Mount{Type: "proc", Source: "proc", Destination: "/proc"},
+				sysfs,
+			))
+		})
+
+		It("masks the masked paths", func() {
+			newBndl, err := rule.Apply(bndl, gardener.DesiredContainerSpec{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(newBndl.Spec.Linux.MaskedPaths).To(Equal([]string{"/proc/kcore"}))
+		})
+
+		It("makes the read-only paths read-only", func() {
+			newBndl, err := rule.Apply(bndl, gardener.DesiredContainerSpec{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(newBndl.Spec.Linux.ReadonlyPaths).To(Equal([]string{"/proc/sys"}))
+		})
+	})
+
+	Context("when the container is privileged", func() {
+		It("mounts a read-only sysfs", func() {
+			newBndl, err := rule.Apply(bndl, gardener.DesiredContainerSpec{Privileged: true})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(newBndl.Mounts()).To(ContainElement(sysfs))
+		})
+
+		It("does not mask or make any paths read-only", func() {
+			newBndl, err := rule.Apply(bndl, gardener.DesiredContainerSpec{Privileged: true})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(newBndl.Spec.Linux.MaskedPaths).To(BeEmpty())
+			Expect(newBndl.Spec.Linux.ReadonlyPaths).To(BeEmpty())
+		})
+	})
+
+	It("does not modify the original bundle", func() {
+		rule.Apply(bndl, gardener.DesiredContainerSpec{})
+		Expect(bndl.Mounts()).To(HaveLen(1))
+		Expect(bndl.Spec.Linux.MaskedPaths).To(BeEmpty())
+	})
+
+	It("masks the /proc files which leak host information by default", func() {
+		Expect(bundlerules.DefaultMaskedPaths).To(ContainElement("/proc/kcore"))
+		Expect(bundlerules.DefaultMaskedPaths).To(ContainElement("/proc/sched_debug"))
+		Expect(bundlerules.DefaultMaskedPaths).To(ContainElement("/proc/timer_list"))
+		Expect(bundlerules.DefaultReadonlyPaths).To(ContainElement("/proc/sys"))
+	})
+})
@@ -17,9 +17,10 @@ var _ = Describe("Hooks", func() {
 	DescribeTable("the envirionment should contain", func(envVar string) {
 		rule := bundlerules.Hooks{LogFilePattern: "/path/to/%s.log"}
 
-		newBndl := rule.Apply(goci.Bundle(), gardener.DesiredContainerSpec{
+		newBndl, err := rule.Apply(goci.Bundle(), gardener.DesiredContainerSpec{
 			Handle: "fred",
 		})
+		Expect(err).NotTo(HaveOccurred())
 
 		Expect(newBndl.PrestartHooks()[0].Env).To(
 			ContainElement(envVar),
@@ -30,7 +31,7 @@ var _ = Describe("Hooks", func() {
 	)
 
 	It("adds the prestart and poststop hooks of the passed bundle", func() {
-		newBndl := bundlerules.Hooks{}.Apply(goci.Bundle(), gardener.DesiredContainerSpec{
+		newBndl, err := bundlerules.Hooks{}.Apply(goci.Bundle(), gardener.DesiredContainerSpec{
 			NetworkHooks: gardener.Hooks{
 				Prestart: gardener.Hook{
 					Path: "/path/to/bananas/network",
@@ -42,6 +43,7 @@ var _ = Describe("Hooks", func() {
 				},
 			},
 		})
+		Expect(err).NotTo(HaveOccurred())
 
 		Expect(pathAndArgsOf(newBndl.PrestartHooks())).To(ContainElement(PathAndArgs{
 			Path: "/path/to/bananas/network",
@@ -0,0 +1,78 @@
+package bundlerules
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/goci"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/opencontainers/specs"
+)
+
+// HostnameProperty overrides the container's hostname, which is otherwise
+// its handle
+const HostnameProperty = "garden.hostname"
+
+const maxHostnameLength = 63
+
+// Hostname sets the hostname of the container's UTS namespace. Kawasaki maps
+// the same name to the container's IP in its /etc/hosts. Containers whose
+// profile does not unshare the UTS namespace keep the host's hostname, and
+// may not ask for another.
+type Hostname struct{}
+
+func (r Hostname) Apply(bndl *goci.Bndl, spec gardener.DesiredContainerSpec) (*goci.Bndl, error) {
+	override, overridden := spec.Properties[HostnameProperty]
+	if !hasPrivateNamespace(bndl, specs.UTSNamespace) {
+		if overridden {
+			return nil, fmt.Errorf("%s: the container shares the host's UTS namespace, so its hostname cannot be set", HostnameProperty)
+		}
+
+		return bndl, nil
+	}
+
+	hostname := spec.Handle
+	if overridden {
+		hostname = override
+	}
+
+	newBndl := *bndl
+	newBndl.Spec.Spec.Hostname = SanitizeHostname(hostname)
+	return &newBndl, nil
+}
+
+// SanitizeHostname turns a name in to a valid hostname (a single label of
+// at most 63 letters, digits and hyphens, not starting or ending with a
+// hyphen) by replacing other characters with hyphens and truncating it
+func SanitizeHostname(name string) string {
+	hostname := strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+
+		return '-'
+	}, name)
+
+	hostname = strings.Trim(hostname, "-")
+	if len(hostname) > maxHostnameLength {
+		hostname = strings.TrimRight(hostname[:maxHostnameLength], "-")
+	}
+
+	if hostname == "" {
+		return "container"
+	}
+
+	return hostname
+}
+
+// hasPrivateNamespace is true when the bundle unshares a new namespace of the
+// given type, rather than sharing the host's or joining another
+func hasPrivateNamespace(bndl *goci.Bndl, nsType specs.NamespaceType) bool {
+	for _, ns := range bndl.Namespaces() {
+		if ns.Type == nsType && ns.Path == "" {
+			return true
+		}
+	}
+
+	return false
+}
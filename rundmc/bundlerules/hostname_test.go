@@ -0,0 +1,81 @@
+package bundlerules_test
+
+import (
+	"strings"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/goci"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/rundmc/bundlerules"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"github.com/opencontainers/specs"
+)
+
+var _ = Describe("HostnameRule", func() {
+	var bndl *goci.Bndl
+
+	BeforeEach(func() {
+		bndl = goci.Bundle().WithNamespace(goci.UTSNamespace)
+	})
+
+	It("sets the hostname to the container's handle", func() {
+		newBndl, err := bundlerules.Hostname{}.Apply(bndl, gardener.DesiredContainerSpec{Handle: "fred"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(newBndl.Spec.Hostname).To(Equal("fred"))
+	})
+
+	It("lets the container override the hostname", func() {
+		newBndl, err := bundlerules.Hostname{}.Apply(bndl, gardener.DesiredContainerSpec{
+			Handle:     "fred",
+			Properties: garden.Properties{bundlerules.HostnameProperty: "george"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(newBndl.Spec.Hostname).To(Equal("george"))
+	})
+
+	It("sanitizes the hostname", func() {
+		newBndl, err := bundlerules.Hostname{}.Apply(bndl, gardener.DesiredContainerSpec{Handle: "fred_and.george"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(newBndl.Spec.Hostname).To(Equal("fred-and-george"))
+	})
+
+	It("does not modify the original bundle", func() {
+		bundlerules.Hostname{}.Apply(bndl, gardener.DesiredContainerSpec{Handle: "fred"})
+		Expect(bndl.Spec.Hostname).To(BeEmpty())
+	})
+
+	Context("when the bundle does not unshare the UTS namespace", func() {
+		BeforeEach(func() {
+			bndl = goci.Bundle().WithNamespaces(goci.MountNamespace, specs.Namespace{Type: specs.UTSNamespace, Path: "/proc/1/ns/uts"})
+		})
+
+		It("leaves the hostname alone", func() {
+			newBndl, err := bundlerules.Hostname{}.Apply(bndl, gardener.DesiredContainerSpec{Handle: "fred"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(newBndl.Spec.Hostname).To(BeEmpty())
+		})
+
+		It("refuses to override the hostname", func() {
+			_, err := bundlerules.Hostname{}.Apply(bndl, gardener.DesiredContainerSpec{
+				Handle:     "fred",
+				Properties: garden.Properties{bundlerules.HostnameProperty: "george"},
+			})
+			Expect(err).To(MatchError(ContainSubstring("shares the host's UTS namespace")))
+		})
+	})
+
+	DescribeTable("SanitizeHostname",
+		func(name, hostname string) {
+			Expect(bundlerules.SanitizeHostname(name)).To(Equal(hostname))
+		},
+		Entry("a valid hostname", "my-container-1", "my-container-1"),
+		Entry("a uuid", "0a4ee2a5-b7a8-4b0c-6f33-4c5d9d27c6b7", "0a4ee2a5-b7a8-4b0c-6f33-4c5d9d27c6b7"),
+		Entry("invalid characters", "my_container.example com", "my-container-example-com"),
+		Entry("leading and trailing hyphens", "--fred--", "fred"),
+		Entry("a long name", strings.Repeat("a", 70), strings.Repeat("a", 63)),
+		Entry("a long name which is truncated before a hyphen", strings.Repeat("a", 62)+"_b", strings.Repeat("a", 62)),
+		Entry("nothing valid", "___", "container"),
+	)
+})
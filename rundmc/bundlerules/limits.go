@@ -1,15 +1,73 @@
 package bundlerules
 
 import (
+	"fmt"
+	"strconv"
+
 	"github.com/cloudfoundry-incubator/goci"
 	"github.com/cloudfoundry-incubator/guardian/gardener"
 	"github.com/opencontainers/specs"
 )
 
+// SwapLimitProperty is the number of bytes of swap the container may use on
+// top of its memory limit. Containers get no swap if it is not set.
+const SwapLimitProperty = "garden.swap-limit"
+
+// OOMScoreAdjProperty sets the oom_score_adj, from -1000 to 1000, of the
+// container's processes. Only privileged containers may set a negative score.
+const OOMScoreAdjProperty = "garden.oom-score-adj"
+
+// DisableOOMKillerProperty, when "true", makes the container freeze rather
+// than have a process killed when it runs out of memory
+const DisableOOMKillerProperty = "garden.disable-oom-killer"
+
 type Limits struct {
 }
 
-func (l Limits) Apply(bndl *goci.Bndl, spec gardener.DesiredContainerSpec) *goci.Bndl {
+func (l Limits) Apply(bndl *goci.Bndl, spec gardener.DesiredContainerSpec) (*goci.Bndl, error) {
 	limit := uint64(spec.Limits.Memory.LimitInBytes)
-	return bndl.WithMemoryLimit(specs.Memory{Limit: &limit, Swap: &limit})
+
+	swap := limit
+	if value, ok := spec.Properties[SwapLimitProperty]; ok {
+		extra, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s: must be a number of bytes", SwapLimitProperty)
+		}
+
+		if limit == 0 {
+			return nil, fmt.Errorf("%s: the container must have a memory limit", SwapLimitProperty)
+		}
+
+		swap = limit + extra
+	}
+
+	resources := &specs.Resources{}
+	if bndl.Spec.Linux.Resources != nil {
+		*resources = *bndl.Spec.Linux.Resources
+	}
+	resources.Memory = &specs.Memory{Limit: &limit, Swap: &swap}
+
+	if value, ok := spec.Properties[OOMScoreAdjProperty]; ok {
+		adj, err := strconv.Atoi(value)
+		if err != nil || adj < -1000 || adj > 1000 {
+			return nil, fmt.Errorf("%s: must be a number from -1000 to 1000", OOMScoreAdjProperty)
+		}
+
+		if adj < 0 && !spec.Privileged {
+			return nil, fmt.Errorf("%s: only privileged containers may be given a negative score", OOMScoreAdjProperty)
+		}
+
+		resources.OOMScoreAdj = &adj
+	}
+
+	if spec.Properties[DisableOOMKillerProperty] == "true" {
+		if limit == 0 {
+			return nil, fmt.Errorf("%s: the container must have a memory limit", DisableOOMKillerProperty)
+		}
+
+		disable := true
+		resources.DisableOOMKiller = &disable
+	}
+
+	return bndl.WithResources(resources), nil
 }
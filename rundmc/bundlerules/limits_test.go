@@ -2,6 +2,7 @@ package bundlerules_test
 
 import (
 	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
 	. "github.com/onsi/gomega"
 	"github.com/opencontainers/specs"
 
@@ -13,11 +14,12 @@ import (
 
 var _ = Describe("LimitsRule", func() {
 	It("sets the correct memory limit in bundle resources", func() {
-		newBndl := bundlerules.Limits{}.Apply(goci.Bundle(), gardener.DesiredContainerSpec{
+		newBndl, err := bundlerules.Limits{}.Apply(goci.Bundle(), gardener.DesiredContainerSpec{
 			Limits: garden.Limits{
 				Memory: garden.MemoryLimits{LimitInBytes: 4096},
 			},
 		})
+		Expect(err).NotTo(HaveOccurred())
 
 		Expect(*(newBndl.Resources().Memory.Limit)).To(BeNumerically("==", 4096))
 		Expect(*(newBndl.Resources().Memory.Swap)).To(BeNumerically("==", 4096))
@@ -31,13 +33,88 @@ var _ = Describe("LimitsRule", func() {
 			},
 		)
 
-		newBndl := bundlerules.Limits{}.Apply(bndl, gardener.DesiredContainerSpec{
+		newBndl, err := bundlerules.Limits{}.Apply(bndl, gardener.DesiredContainerSpec{
 			Limits: garden.Limits{
 				Memory: garden.MemoryLimits{LimitInBytes: 4096},
 			},
 		})
+		Expect(err).NotTo(HaveOccurred())
 
 		Expect(*(newBndl.Resources().Memory.Limit)).To(BeNumerically("==", 4096))
 		Expect(newBndl.Resources().Devices).To(Equal(bndl.Resources().Devices))
 	})
+
+	apply := func(privileged bool, props garden.Properties) (*goci.Bndl, error) {
+		return bundlerules.Limits{}.Apply(goci.Bundle(), gardener.DesiredContainerSpec{
+			Privileged: privileged,
+			Limits: garden.Limits{
+				Memory: garden.MemoryLimits{LimitInBytes: 4096},
+			},
+			Properties: props,
+		})
+	}
+
+	It("gives the container the swap it asks for on top of its memory limit", func() {
+		newBndl, err := apply(false, garden.Properties{bundlerules.SwapLimitProperty: "1024"})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(*(newBndl.Resources().Memory.Limit)).To(BeNumerically("==", 4096))
+		Expect(*(newBndl.Resources().Memory.Swap)).To(BeNumerically("==", 5120))
+	})
+
+	It("sets the oom_score_adj the container asks for", func() {
+		newBndl, err := apply(false, garden.Properties{bundlerules.OOMScoreAdjProperty: "500"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*(newBndl.Resources().OOMScoreAdj)).To(Equal(500))
+	})
+
+	It("lets privileged containers ask for a negative oom_score_adj", func() {
+		newBndl, err := apply(true, garden.Properties{bundlerules.OOMScoreAdjProperty: "-500"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*(newBndl.Resources().OOMScoreAdj)).To(Equal(-500))
+	})
+
+	It("disables the OOM killer when the container asks", func() {
+		newBndl, err := apply(false, garden.Properties{bundlerules.DisableOOMKillerProperty: "true"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*(newBndl.Resources().DisableOOMKiller)).To(BeTrue())
+	})
+
+	It("leaves the OOM behaviour alone by default", func() {
+		newBndl, err := apply(false, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(newBndl.Resources().OOMScoreAdj).To(BeNil())
+		Expect(newBndl.Resources().DisableOOMKiller).To(BeNil())
+	})
+
+	It("does not modify the original bundle's resources", func() {
+		bndl := goci.Bundle().WithResources(&specs.Resources{})
+
+		_, err := bundlerules.Limits{}.Apply(bndl, gardener.DesiredContainerSpec{
+			Properties: garden.Properties{bundlerules.OOMScoreAdjProperty: "500"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(bndl.Resources().OOMScoreAdj).To(BeNil())
+		Expect(bndl.Resources().Memory).To(BeNil())
+	})
+
+	DescribeTable("rejects invalid requests",
+		func(props garden.Properties, message string) {
+			_, err := apply(false, props)
+			Expect(err).To(MatchError(ContainSubstring(message)))
+		},
+		Entry("a bad swap limit", garden.Properties{bundlerules.SwapLimitProperty: "lots"}, "must be a number of bytes"),
+		Entry("a bad oom_score_adj", garden.Properties{bundlerules.OOMScoreAdjProperty: "high"}, "must be a number from -1000 to 1000"),
+		Entry("an oom_score_adj out of range", garden.Properties{bundlerules.OOMScoreAdjProperty: "1001"}, "must be a number from -1000 to 1000"),
+		Entry("a negative oom_score_adj", garden.Properties{bundlerules.OOMScoreAdjProperty: "-1"}, "only privileged containers"),
+	)
+
+	DescribeTable("requires a memory limit",
+		func(props garden.Properties) {
+			_, err := bundlerules.Limits{}.Apply(goci.Bundle(), gardener.DesiredContainerSpec{Properties: props})
+			Expect(err).To(MatchError(ContainSubstring("must have a memory limit")))
+		},
+		Entry("for swap", garden.Properties{bundlerules.SwapLimitProperty: "1024"}),
+		Entry("to disable the OOM killer", garden.Properties{bundlerules.DisableOOMKillerProperty: "true"}),
+	)
 })
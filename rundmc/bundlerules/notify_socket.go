@@ -0,0 +1,29 @@
+package bundlerules
+
+import (
+	"fmt"
+
+	"github.com/cloudfoundry-incubator/goci"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/opencontainers/specs"
+)
+
+// NotifySocket bind mounts the socket which rundmc.StartChecker listens on in
+// to the container, and tells the init process where to find it. It must be
+// applied after InitProcess.
+type NotifySocket struct {
+	SocketPathPattern string
+	ContainerPath     string
+}
+
+func (r NotifySocket) Apply(bndl *goci.Bndl, spec gardener.DesiredContainerSpec) (*goci.Bndl, error) {
+	process := bndl.Spec.Spec.Process
+	process.Env = append(append([]string{}, process.Env...), "NOTIFY_SOCKET="+r.ContainerPath)
+
+	return bndl.WithMounts(specs.Mount{
+		Type:        "bind",
+		Source:      fmt.Sprintf(r.SocketPathPattern, spec.Handle),
+		Destination: r.ContainerPath,
+		Options:     []string{"bind"},
+	}).WithProcess(process), nil
+}
@@ -0,0 +1,49 @@
+package bundlerules_test
+
+import (
+	"github.com/cloudfoundry-incubator/goci"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/rundmc/bundlerules"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/opencontainers/specs"
+)
+
+var _ = Describe("NotifySocketRule", func() {
+	var (
+		bndl    *goci.Bndl
+		newBndl *goci.Bndl
+	)
+
+	BeforeEach(func() {
+		bndl = goci.Bundle().WithProcess(specs.Process{
+			Args: []string{"/tmp/garden-init"},
+			Env:  []string{"FOO=bar"},
+		})
+
+		var err error
+		newBndl, err = bundlerules.NotifySocket{
+			SocketPathPattern: "/depot/%s/notify.sock",
+			ContainerPath:     "/tmp/garden-notify.sock",
+		}.Apply(bndl, gardener.DesiredContainerSpec{Handle: "fred"})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("bind mounts the container's notify socket", func() {
+		Expect(newBndl.Mounts()).To(ConsistOf(specs.Mount{
+			Type:        "bind",
+			Source:      "/depot/fred/notify.sock",
+			Destination: "/tmp/garden-notify.sock",
+			Options:     []string{"bind"},
+		}))
+	})
+
+	It("tells the init process where the socket is", func() {
+		Expect(newBndl.Spec.Process.Args).To(Equal([]string{"/tmp/garden-init"}))
+		Expect(newBndl.Spec.Process.Env).To(Equal([]string{"FOO=bar", "NOTIFY_SOCKET=/tmp/garden-notify.sock"}))
+	})
+
+	It("does not modify the original bundle", func() {
+		Expect(bndl.Spec.Process.Env).To(Equal([]string{"FOO=bar"}))
+	})
+})
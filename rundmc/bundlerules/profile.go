@@ -0,0 +1,269 @@
+package bundlerules
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry-incubator/goci"
+	"github.com/opencontainers/specs"
+)
+
+// Profiles describe the base bundles which every container starts from.
+// Operators can supply their own as JSON in place of DefaultProfiles.
+type Profiles struct {
+	Privileged   Profile `json:"privileged"`
+	Unprivileged Profile `json:"unprivileged"`
+}
+
+type Profile struct {
+	Namespaces   []string      `json:"namespaces"`
+	Capabilities []string      `json:"capabilities"`
+	Devices      []Device      `json:"devices"`
+	Mounts       []specs.Mount `json:"mounts"`
+}
+
+// Device is an entry in the device cgroup allow-list. Everything else is
+// denied. A nil Major or Minor matches any number.
+type Device struct {
+	Type   string `json:"type"`
+	Major  *int64 `json:"major,omitempty"`
+	Minor  *int64 `json:"minor,omitempty"`
+	Access string `json:"access"`
+}
+
+var defaultCapabilities = []string{
+	"CAP_CHOWN",
+	"CAP_DAC_OVERRIDE",
+	"CAP_FSETID",
+	"CAP_FOWNER",
+	"CAP_MKNOD",
+	"CAP_NET_RAW",
+	"CAP_SETGID",
+	"CAP_SETUID",
+	"CAP_SETFCAP",
+	"CAP_SETPCAP",
+	"CAP_NET_BIND_SERVICE",
+	"CAP_SYS_CHROOT",
+	"CAP_KILL",
+	"CAP_AUDIT_WRITE",
+}
+
+var defaultDevices = []Device{
+	{Type: "c", Major: number(1), Minor: number(3), Access: "rwm"}, // null
+	{Type: "c", Major: number(5), Minor: number(0), Access: "rwm"}, // tty
+	{Type: "c", Major: number(1), Minor: number(8), Access: "rwm"}, // random
+	{Type: "c", Major: number(1), Minor: number(9), Access: "rwm"}, // urandom
+	{Type: "c", Major: number(1), Minor: number(5), Access: "rwm"}, // zero
+	{Type: "c", Major: number(1), Minor: number(7), Access: "rwm"}, // full
+}
+
+var defaultMounts = []specs.Mount{
+	{Type: "proc", Source: "proc", Destination: "/proc"},
+	{Type: "tmpfs", Source: "tmpfs", Destination: "/dev/shm"},
+	{Type: "devpts", Source: "devpts", Destination: "/dev/pts",
+		Options: []string{"nosuid", "noexec", "newinstance", "ptmxmode=0666", "mode=0620"}},
+}
+
+// DefaultProfiles are used when the operator does not supply any
+var DefaultProfiles = Profiles{
+	Privileged: Profile{
+		Namespaces:   []string{"network", "pid", "uts", "ipc", "mount"},
+		Capabilities: defaultCapabilities,
+		Devices:      defaultDevices,
+		Mounts:       defaultMounts,
+	},
+	Unprivileged: Profile{
+		Namespaces:   []string{"network", "pid", "uts", "ipc", "mount", "user"},
+		Capabilities: defaultCapabilities,
+		Devices:      defaultDevices,
+		Mounts:       defaultMounts,
+	},
+}
+
+// LoadProfiles reads and validates profiles from a JSON file
+func LoadProfiles(path string) (Profiles, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Profiles{}, err
+	}
+	defer f.Close()
+
+	var profiles Profiles
+	if err := json.NewDecoder(f).Decode(&profiles); err != nil {
+		return Profiles{}, fmt.Errorf("parsing bundle profiles %s: %s", path, err)
+	}
+
+	return profiles, profiles.Validate()
+}
+
+func (p Profiles) Validate() error {
+	if err := p.Privileged.Validate(); err != nil {
+		return fmt.Errorf("privileged profile: %s", err)
+	}
+
+	if p.Privileged.hasNamespace("user") {
+		return fmt.Errorf("privileged profile: must not have a user namespace")
+	}
+
+	if err := p.Unprivileged.Validate(); err != nil {
+		return fmt.Errorf("unprivileged profile: %s", err)
+	}
+
+	if !p.Unprivileged.hasNamespace("user") {
+		return fmt.Errorf("unprivileged profile: must have a user namespace")
+	}
+
+	return nil
+}
+
+func (p Profile) Validate() error {
+	seen := make(map[string]bool)
+	for _, ns := range p.Namespaces {
+		if !knownNamespaces[ns] {
+			return fmt.Errorf("unknown namespace %q", ns)
+		}
+
+		if seen[ns] {
+			return fmt.Errorf("duplicate namespace %q", ns)
+		}
+
+		seen[ns] = true
+	}
+
+	if !seen["mount"] {
+		return fmt.Errorf("a mount namespace is required")
+	}
+
+	for _, capability := range p.Capabilities {
+		if !knownCapabilities[capability] {
+			return fmt.Errorf("unknown capability %q", capability)
+		}
+	}
+
+	for _, device := range p.Devices {
+		if device.Type != "a" && device.Type != "b" && device.Type != "c" {
+			return fmt.Errorf("device type must be one of a, b or c, not %q", device.Type)
+		}
+
+		for _, access := range device.Access {
+			if access != 'r' && access != 'w' && access != 'm' {
+				return fmt.Errorf("device access must be made up of r, w and m, not %q", device.Access)
+			}
+		}
+	}
+
+	for _, mount := range p.Mounts {
+		if mount.Type == "" {
+			return fmt.Errorf("mount %s has no type", mount.Destination)
+		}
+
+		if !filepath.IsAbs(mount.Destination) {
+			return fmt.Errorf("mount destination %q is not absolute", mount.Destination)
+		}
+	}
+
+	return nil
+}
+
+// Bundle returns a bundle with the profile's namespaces, capabilities,
+// devices and mounts
+func (p Profile) Bundle() *goci.Bndl {
+	var namespaces []specs.Namespace
+	for _, ns := range p.Namespaces {
+		namespaces = append(namespaces, specs.Namespace{Type: specs.NamespaceType(ns)})
+	}
+
+	rwm := "rwm"
+	devices := []specs.DeviceCgroup{{Allow: false, Access: &rwm}}
+	for _, device := range p.Devices {
+		devices = append(devices, device.cgroup())
+	}
+
+	return goci.Bundle().
+		WithNamespaces(namespaces...).
+		WithCapabilities(p.Capabilities...).
+		WithResources(&specs.Resources{Devices: devices}).
+		WithMounts(p.Mounts...)
+}
+
+func (p Profile) hasNamespace(ns string) bool {
+	for _, n := range p.Namespaces {
+		if n == ns {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (d Device) cgroup() specs.DeviceCgroup {
+	deviceType := rune(d.Type[0])
+	access := d.Access
+	if access == "" {
+		access = "rwm"
+	}
+
+	return specs.DeviceCgroup{
+		Allow:  true,
+		Type:   &deviceType,
+		Major:  d.Major,
+		Minor:  d.Minor,
+		Access: &access,
+	}
+}
+
+func number(n int64) *int64 {
+	return &n
+}
+
+var knownNamespaces = map[string]bool{
+	"network": true,
+	"pid":     true,
+	"uts":     true,
+	"ipc":     true,
+	"mount":   true,
+	"user":    true,
+}
+
+var knownCapabilities = map[string]bool{
+	"CAP_AUDIT_CONTROL":    true,
+	"CAP_AUDIT_READ":       true,
+	"CAP_AUDIT_WRITE":      true,
+	"CAP_BLOCK_SUSPEND":    true,
+	"CAP_CHOWN":            true,
+	"CAP_DAC_OVERRIDE":     true,
+	"CAP_DAC_READ_SEARCH":  true,
+	"CAP_FOWNER":           true,
+	"CAP_FSETID":           true,
+	"CAP_IPC_LOCK":         true,
+	"CAP_IPC_OWNER":        true,
+	"CAP_KILL":             true,
+	"CAP_LEASE":            true,
+	"CAP_LINUX_IMMUTABLE":  true,
+	"CAP_MAC_ADMIN":        true,
+	"CAP_MAC_OVERRIDE":     true,
+	"CAP_MKNOD":            true,
+	"CAP_NET_ADMIN":        true,
+	"CAP_NET_BIND_SERVICE": true,
+	"CAP_NET_BROADCAST":    true,
+	"CAP_NET_RAW":          true,
+	"CAP_SETFCAP":          true,
+	"CAP_SETGID":           true,
+	"CAP_SETPCAP":          true,
+	"CAP_SETUID":           true,
+	"CAP_SYSLOG":           true,
+	"CAP_SYS_ADMIN":        true,
+	"CAP_SYS_BOOT":         true,
+	"CAP_SYS_CHROOT":       true,
+	"CAP_SYS_MODULE":       true,
+	"CAP_SYS_NICE":         true,
+	"CAP_SYS_PACCT":        true,
+	"CAP_SYS_PTRACE":       true,
+	"CAP_SYS_RAWIO":        true,
+	"CAP_SYS_RESOURCE":     true,
+	"CAP_SYS_TIME":         true,
+	"CAP_SYS_TTY_CONFIG":   true,
+	"CAP_WAKE_ALARM":       true,
+}
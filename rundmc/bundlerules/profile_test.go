@@ -0,0 +1,190 @@
+package bundlerules_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/opencontainers/specs"
+
+	"github.com/cloudfoundry-incubator/goci"
+	"github.com/cloudfoundry-incubator/guardian/rundmc/bundlerules"
+)
+
+var _ = Describe("Profiles", func() {
+	Describe("DefaultProfiles", func() {
+		It("are valid", func() {
+			Expect(bundlerules.DefaultProfiles.Validate()).To(Succeed())
+		})
+
+		It("only give unprivileged containers a user namespace", func() {
+			Expect(bundlerules.DefaultProfiles.Privileged.Bundle().Spec.Linux.Namespaces).NotTo(ContainElement(goci.UserNamespace))
+			Expect(bundlerules.DefaultProfiles.Unprivileged.Bundle().Spec.Linux.Namespaces).To(ContainElement(goci.UserNamespace))
+		})
+	})
+
+	Describe("Bundle", func() {
+		var bndl *goci.Bndl
+
+		BeforeEach(func() {
+			one, three := int64(1), int64(3)
+			bndl = bundlerules.Profile{
+				Namespaces:   []string{"mount", "network"},
+				Capabilities: []string{"CAP_CHOWN"},
+				Devices:      []bundlerules.Device{{Type: "c", Major: &one, Minor: &three, Access: "rw"}},
+				Mounts:       []specs.Mount{{Type: "proc", Source: "proc", Destination: "/proc"}},
+			}.Bundle()
+		})
+
+		It("has the profile's namespaces", func() {
+			Expect(bndl.Spec.Linux.Namespaces).To(ConsistOf(goci.MountNamespace, goci.NetworkNamespace))
+		})
+
+		It("has the profile's capabilities", func() {
+			Expect(bndl.Spec.Linux.Capabilities).To(ConsistOf("CAP_CHOWN"))
+		})
+
+		It("has the profile's mounts", func() {
+			Expect(bndl.Mounts()).To(ConsistOf(specs.Mount{Type: "proc", Source: "proc", Destination: "/proc"}))
+		})
+
+		It("denies every device except those in the profile", func() {
+			devices := bndl.Resources().Devices
+			Expect(devices).To(HaveLen(2))
+
+			Expect(devices[0].Allow).To(BeFalse())
+			Expect(*devices[0].Access).To(Equal("rwm"))
+
+			Expect(devices[1].Allow).To(BeTrue())
+			Expect(*devices[1].Type).To(Equal('c'))
+			Expect(*devices[1].Major).To(BeEquivalentTo(1))
+			Expect(*devices[1].Minor).To(BeEquivalentTo(3))
+			Expect(*devices[1].Access).To(Equal("rw"))
+		})
+	})
+
+	Describe("Validate", func() {
+		var profiles bundlerules.Profiles
+
+		BeforeEach(func() {
+			profiles = bundlerules.Profiles{
+				Privileged:   bundlerules.Profile{Namespaces: []string{"mount"}},
+				Unprivileged: bundlerules.Profile{Namespaces: []string{"mount", "user"}},
+			}
+		})
+
+		It("accepts a minimal pair of profiles", func() {
+			Expect(profiles.Validate()).To(Succeed())
+		})
+
+		It("requires the unprivileged profile to have a user namespace", func() {
+			profiles.Unprivileged.Namespaces = []string{"mount"}
+			Expect(profiles.Validate()).To(MatchError("unprivileged profile: must have a user namespace"))
+		})
+
+		It("requires the privileged profile not to have a user namespace", func() {
+			profiles.Privileged.Namespaces = []string{"mount", "user"}
+			Expect(profiles.Validate()).To(MatchError("privileged profile: must not have a user namespace"))
+		})
+
+		It("requires a mount namespace", func() {
+			profiles.Privileged.Namespaces = []string{"network"}
+			Expect(profiles.Validate()).To(MatchError("privileged profile: a mount namespace is required"))
+		})
+
+		It("rejects unknown namespaces", func() {
+			profiles.Privileged.Namespaces = []string{"mount", "cgroup-ish"}
+			Expect(profiles.Validate()).To(MatchError(ContainSubstring(`unknown namespace "cgroup-ish"`)))
+		})
+
+		It("rejects duplicate namespaces", func() {
+			profiles.Privileged.Namespaces = []string{"mount", "mount"}
+			Expect(profiles.Validate()).To(MatchError(ContainSubstring(`duplicate namespace "mount"`)))
+		})
+
+		It("rejects unknown capabilities", func() {
+			profiles.Privileged.Capabilities = []string{"CHOWN"}
+			Expect(profiles.Validate()).To(MatchError(ContainSubstring(`unknown capability "CHOWN"`)))
+		})
+
+		It("rejects bad device types", func() {
+			profiles.Privileged.Devices = []bundlerules.Device{{Type: "x", Access: "rwm"}}
+			Expect(profiles.Validate()).To(MatchError(ContainSubstring("device type")))
+		})
+
+		It("rejects bad device access", func() {
+			profiles.Privileged.Devices = []bundlerules.Device{{Type: "c", Access: "rwx"}}
+			Expect(profiles.Validate()).To(MatchError(ContainSubstring("device access")))
+		})
+
+		It("rejects mounts without a type", func() {
+			profiles.Privileged.Mounts = []specs.Mount{{Source: "proc", Destination: "/proc"}}
+			Expect(profiles.Validate()).To(MatchError(ContainSubstring("has no type")))
+		})
+
+		It("rejects relative mount destinations", func() {
+			profiles.Privileged.Mounts = []specs.Mount{{Type: "proc", Source: "proc", Destination: "proc"}}
+			Expect(profiles.Validate()).To(MatchError(ContainSubstring("is not absolute")))
+		})
+	})
+
+	Describe("LoadProfiles", func() {
+		var path string
+
+		BeforeEach(func() {
+			dir, err := ioutil.TempDir("", "profiles")
+			Expect(err).NotTo(HaveOccurred())
+
+			path = filepath.Join(dir, "profiles.json")
+		})
+
+		AfterEach(func() {
+			Expect(os.RemoveAll(filepath.Dir(path))).To(Succeed())
+		})
+
+		It("loads profiles from JSON", func() {
+			Expect(ioutil.WriteFile(path, []byte(`{
+				"privileged": {
+					"namespaces": ["mount", "network"],
+					"capabilities": ["CAP_KILL"],
+					"devices": [{"type": "c", "major": 1, "minor": 3, "access": "rwm"}],
+					"mounts": [{"type": "tmpfs", "source": "tmpfs", "destination": "/dev/shm"}]
+				},
+				"unprivileged": {
+					"namespaces": ["mount", "user"]
+				}
+			}`), 0644)).To(Succeed())
+
+			profiles, err := bundlerules.LoadProfiles(path)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(profiles.Privileged.Namespaces).To(Equal([]string{"mount", "network"}))
+			Expect(profiles.Privileged.Capabilities).To(Equal([]string{"CAP_KILL"}))
+			Expect(profiles.Privileged.Devices).To(HaveLen(1))
+			Expect(*profiles.Privileged.Devices[0].Minor).To(BeEquivalentTo(3))
+			Expect(profiles.Privileged.Mounts).To(Equal([]specs.Mount{{Type: "tmpfs", Source: "tmpfs", Destination: "/dev/shm"}}))
+			Expect(profiles.Unprivileged.Namespaces).To(Equal([]string{"mount", "user"}))
+		})
+
+		It("validates the profiles", func() {
+			Expect(ioutil.WriteFile(path, []byte(`{"privileged": {"namespaces": ["mount"]}, "unprivileged": {"namespaces": ["mount"]}}`), 0644)).To(Succeed())
+
+			_, err := bundlerules.LoadProfiles(path)
+			Expect(err).To(MatchError("unprivileged profile: must have a user namespace"))
+		})
+
+		It("fails on bad JSON", func() {
+			Expect(ioutil.WriteFile(path, []byte(`{`), 0644)).To(Succeed())
+
+			_, err := bundlerules.LoadProfiles(path)
+			Expect(err).To(MatchError(ContainSubstring("parsing bundle profiles")))
+		})
+
+		It("fails when the file does not exist", func() {
+			_, err := bundlerules.LoadProfiles(path)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
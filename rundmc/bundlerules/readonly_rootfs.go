@@ -0,0 +1,51 @@
+package bundlerules
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/cloudfoundry-incubator/goci"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/opencontainers/specs"
+)
+
+// ReadonlyRootFSProperty makes the container's root filesystem read-only
+// when it is set to "true"
+const ReadonlyRootFSProperty = "garden.readonly-rootfs"
+
+// DNSFiles are bind mounted from the container's bundle directory when its
+// root filesystem is read-only, so that kawasaki can still write them. The
+// depot creates the files along with the bundle.
+var DNSFiles = []string{"/etc/hosts", "/etc/resolv.conf"}
+
+// ReadonlyRootFS makes a container's root filesystem read-only if it asks for
+// that. It must be applied after RootFS. Writable scratch mounts are added by
+// Tmpfs.
+type ReadonlyRootFS struct {
+	BundlePathPattern string
+}
+
+func (r ReadonlyRootFS) Apply(bndl *goci.Bndl, spec gardener.DesiredContainerSpec) (*goci.Bndl, error) {
+	if spec.Properties[ReadonlyRootFSProperty] != "true" {
+		return bndl, nil
+	}
+
+	bundlePath := fmt.Sprintf(r.BundlePathPattern, spec.Handle)
+	newBndl := bndl.WithMounts(dnsMounts(bundlePath)...)
+	newBndl.Spec.Spec.Root.Readonly = true
+	return newBndl, nil
+}
+
+func dnsMounts(bundlePath string) []specs.Mount {
+	var mounts []specs.Mount
+	for _, file := range DNSFiles {
+		mounts = append(mounts, specs.Mount{
+			Type:        "bind",
+			Source:      filepath.Join(bundlePath, file),
+			Destination: file,
+			Options:     []string{"bind"},
+		})
+	}
+
+	return mounts
+}
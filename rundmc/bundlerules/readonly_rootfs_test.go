@@ -0,0 +1,88 @@
+package bundlerules_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/goci"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/rundmc/bundlerules"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/opencontainers/specs"
+)
+
+var _ = Describe("ReadonlyRootFSRule", func() {
+	var (
+		depotDir string
+		rule     bundlerules.ReadonlyRootFS
+		bndl     *goci.Bndl
+	)
+
+	BeforeEach(func() {
+		var err error
+		depotDir, err = ioutil.TempDir("", "depot")
+		Expect(err).NotTo(HaveOccurred())
+
+		rule = bundlerules.ReadonlyRootFS{
+			BundlePathPattern: filepath.Join(depotDir, "%s"),
+		}
+
+		bndl = goci.Bundle().WithRootFS("/path/to/rootfs")
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(depotDir)).To(Succeed())
+	})
+
+	apply := func(props garden.Properties) (*goci.Bndl, error) {
+		return rule.Apply(bndl, gardener.DesiredContainerSpec{Handle: "fred", Properties: props})
+	}
+
+	Context("when the container does not ask for a read-only rootfs", func() {
+		It("leaves the rootfs writable", func() {
+			newBndl, err := apply(nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(newBndl.Spec.Root.Readonly).To(BeFalse())
+			Expect(newBndl.Mounts()).To(BeEmpty())
+		})
+
+		It("does not create anything in the depot", func() {
+			apply(nil)
+			Expect(filepath.Join(depotDir, "fred")).NotTo(BeADirectory())
+		})
+	})
+
+	Context("when the container asks for a read-only rootfs", func() {
+		var newBndl *goci.Bndl
+
+		BeforeEach(func() {
+			var err error
+			newBndl, err = apply(garden.Properties{bundlerules.ReadonlyRootFSProperty: "true"})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("makes the rootfs read-only", func() {
+			Expect(newBndl.Spec.Root.Readonly).To(BeTrue())
+			Expect(newBndl.Spec.Root.Path).To(Equal("/path/to/rootfs"))
+		})
+
+		It("leaves creating the DNS files to the depot, so that nothing is left behind if a later rule fails", func() {
+			Expect(filepath.Join(depotDir, "fred")).NotTo(BeADirectory())
+		})
+
+		It("bind mounts the DNS files in to the container", func() {
+			Expect(newBndl.Mounts()).To(ConsistOf(
+				specs.Mount{Type: "bind", Source: filepath.Join(depotDir, "fred", "etc", "hosts"), Destination: "/etc/hosts", Options: []string{"bind"}},
+				specs.Mount{Type: "bind", Source: filepath.Join(depotDir, "fred", "etc", "resolv.conf"), Destination: "/etc/resolv.conf", Options: []string{"bind"}},
+			))
+		})
+
+		It("does not modify the original bundle", func() {
+			Expect(bndl.Spec.Root.Readonly).To(BeFalse())
+			Expect(bndl.Mounts()).To(BeEmpty())
+		})
+	})
+})
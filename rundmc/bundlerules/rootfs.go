@@ -18,13 +18,13 @@ type RootFS struct {
 	MkdirChowner MkdirChowner
 }
 
-func (r RootFS) Apply(bndl *goci.Bndl, spec gardener.DesiredContainerSpec) *goci.Bndl {
+func (r RootFS) Apply(bndl *goci.Bndl, spec gardener.DesiredContainerSpec) (*goci.Bndl, error) {
 	os.RemoveAll(path.Join(spec.RootFSPath, "dev"))
 	r.mkdirAsContainerRoot(filepath.Join(spec.RootFSPath, ".pivot_root"), 0700)
 	r.mkdirAsContainerRoot(filepath.Join(spec.RootFSPath, "dev"), 0755)
 	r.mkdirAsContainerRoot(filepath.Join(spec.RootFSPath, "proc"), 0755)
 	r.mkdirAsContainerRoot(filepath.Join(spec.RootFSPath, "sys"), 0755)
-	return bndl.WithRootFS(spec.RootFSPath)
+	return bndl.WithRootFS(spec.RootFSPath), nil
 }
 
 func (r RootFS) mkdirAsContainerRoot(path string, perms os.FileMode) {
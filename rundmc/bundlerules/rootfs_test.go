@@ -38,9 +38,11 @@ var _ = Describe("RootFS", func() {
 		Expect(ioutil.WriteFile(path.Join(rootfsPath, "dev", "foo"), []byte("blah"), 0700)).To(Succeed())
 		Expect(os.MkdirAll(path.Join(rootfsPath, "notdev", "shm"), 0700)).To(Succeed())
 
-		returnedBundle = rule.Apply(goci.Bundle(), gardener.DesiredContainerSpec{
+		var err error
+		returnedBundle, err = rule.Apply(goci.Bundle(), gardener.DesiredContainerSpec{
 			RootFSPath: rootfsPath,
 		})
+		Expect(err).NotTo(HaveOccurred())
 	})
 
 	AfterEach(func() {
@@ -0,0 +1,299 @@
+package bundlerules
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/goci"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/opencontainers/specs"
+)
+
+// SeccompProperty can be set to SeccompUnconfined on a privileged container
+// to run it without a seccomp filter. It is ignored for unprivileged
+// containers, which are always filtered.
+const SeccompProperty = "garden.seccomp"
+const SeccompUnconfined = "unconfined"
+
+// Seccomp resolves Profile for each container, against the host's
+// architecture and kernel and the container's capabilities
+type Seccomp struct {
+	Profile SeccompProfile
+
+	// Arch is the host's GOARCH, runtime.GOARCH if empty
+	Arch string
+
+	// KernelVersion is the host's kernel release (e.g. "4.4.0-21-generic").
+	// Rules with a minKernel never apply when it is empty.
+	KernelVersion string
+}
+
+func (r Seccomp) Apply(bndl *goci.Bndl, spec gardener.DesiredContainerSpec) (*goci.Bndl, error) {
+	if spec.Privileged && spec.Properties[SeccompProperty] == SeccompUnconfined {
+		return bndl, nil
+	}
+
+	arch := r.Arch
+	if arch == "" {
+		arch = runtime.GOARCH
+	}
+
+	newBndl := *bndl
+	newBndl.Spec.Linux.Seccomp = r.Profile.Resolve(arch, r.KernelVersion, bndl.Spec.Linux.Capabilities)
+	return &newBndl, nil
+}
+
+// SeccompProfile is a docker-compatible seccomp profile. It lists syscalls
+// either one at a time (`name`) or in groups (`names`), and its rules may
+// only apply on some architectures and kernels, or to containers with (or
+// without) some capabilities.
+type SeccompProfile struct {
+	DefaultAction specs.Action     `json:"defaultAction"`
+	Architectures []specs.Arch     `json:"architectures"`
+	ArchMap       []SeccompArchMap `json:"archMap"`
+	Syscalls      []SeccompRule    `json:"syscalls"`
+}
+
+// SeccompArchMap lists the architectures a native architecture's syscalls
+// can also be made in (e.g. x86 on x86_64)
+type SeccompArchMap struct {
+	Architecture     specs.Arch   `json:"architecture"`
+	SubArchitectures []specs.Arch `json:"subArchitectures"`
+}
+
+type SeccompRule struct {
+	Name     string        `json:"name"`
+	Names    []string      `json:"names"`
+	Action   specs.Action  `json:"action"`
+	Args     []specs.Arg   `json:"args"`
+	Includes SeccompFilter `json:"includes"`
+	Excludes SeccompFilter `json:"excludes"`
+}
+
+// SeccompFilter is a rule's condition. A rule only applies when everything
+// in its includes matches, and when nothing in its excludes does.
+type SeccompFilter struct {
+	Arches    []string `json:"arches"`
+	Caps      []string `json:"caps"`
+	MinKernel string   `json:"minKernel"`
+}
+
+// seccompArches maps GOARCH to the architecture seccomp calls it
+var seccompArches = map[string]specs.Arch{
+	"386":      "SCMP_ARCH_X86",
+	"amd64":    "SCMP_ARCH_X86_64",
+	"arm":      "SCMP_ARCH_ARM",
+	"arm64":    "SCMP_ARCH_AARCH64",
+	"mips":     "SCMP_ARCH_MIPS",
+	"mips64":   "SCMP_ARCH_MIPS64",
+	"mips64le": "SCMP_ARCH_MIPSEL64",
+	"mipsle":   "SCMP_ARCH_MIPSEL",
+	"ppc64":    "SCMP_ARCH_PPC64",
+	"ppc64le":  "SCMP_ARCH_PPC64LE",
+	"s390":     "SCMP_ARCH_S390",
+	"s390x":    "SCMP_ARCH_S390X",
+}
+
+// Resolve picks out the architectures and syscall rules which apply to a
+// container with caps on a host with the given GOARCH and kernel release
+func (p SeccompProfile) Resolve(arch, kernelVersion string, caps []string) specs.Seccomp {
+	profile := specs.Seccomp{
+		DefaultAction: p.DefaultAction,
+		Architectures: p.Architectures,
+	}
+
+	for _, archMap := range p.ArchMap {
+		if archMap.Architecture == seccompArches[arch] {
+			profile.Architectures = append(profile.Architectures, archMap.Architecture)
+			profile.Architectures = append(profile.Architectures, archMap.SubArchitectures...)
+		}
+	}
+
+	for _, rule := range p.Syscalls {
+		if !rule.appliesTo(arch, kernelVersion, caps) {
+			continue
+		}
+
+		for _, name := range rule.names() {
+			profile.Syscalls = append(profile.Syscalls, specs.Syscall{
+				Name:   name,
+				Action: rule.Action,
+				Args:   rule.Args,
+			})
+		}
+	}
+
+	return profile
+}
+
+func (r SeccompRule) names() []string {
+	if r.Name == "" {
+		return r.Names
+	}
+
+	return append([]string{r.Name}, r.Names...)
+}
+
+func (r SeccompRule) appliesTo(arch, kernelVersion string, caps []string) bool {
+	if len(r.Includes.Arches) > 0 && !contains(r.Includes.Arches, arch) {
+		return false
+	}
+
+	for _, capability := range r.Includes.Caps {
+		if !contains(caps, capability) {
+			return false
+		}
+	}
+
+	if r.Includes.MinKernel != "" && !kernelAtLeast(kernelVersion, r.Includes.MinKernel) {
+		return false
+	}
+
+	if contains(r.Excludes.Arches, arch) {
+		return false
+	}
+
+	for _, capability := range r.Excludes.Caps {
+		if contains(caps, capability) {
+			return false
+		}
+	}
+
+	if r.Excludes.MinKernel != "" && kernelAtLeast(kernelVersion, r.Excludes.MinKernel) {
+		return false
+	}
+
+	return true
+}
+
+// kernelAtLeast compares the major and minor versions of a kernel release
+// (e.g. "4.4.0-21-generic") with min (e.g. "4.8"). A release which cannot
+// be parsed is never at least anything.
+func kernelAtLeast(release, min string) bool {
+	major, minor, ok := parseKernelVersion(release)
+	if !ok {
+		return false
+	}
+
+	minMajor, minMinor, _ := parseKernelVersion(min)
+	return major > minMajor || (major == minMajor && minor >= minMinor)
+}
+
+func parseKernelVersion(version string) (int, int, bool) {
+	parts := strings.SplitN(version, ".", 3)
+	if len(parts) < 2 {
+		return 0, 0, false
+	}
+
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, false
+	}
+
+	minor := parts[1]
+	if i := strings.IndexFunc(minor, func(r rune) bool { return r < '0' || r > '9' }); i >= 0 {
+		minor = minor[:i]
+	}
+
+	minorNum, err := strconv.Atoi(minor)
+	if err != nil {
+		return 0, 0, false
+	}
+
+	return major, minorNum, true
+}
+
+// LoadSeccompProfile reads a docker-compatible seccomp profile
+func LoadSeccompProfile(path string) (SeccompProfile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return SeccompProfile{}, err
+	}
+	defer f.Close()
+
+	var profile SeccompProfile
+	if err := json.NewDecoder(f).Decode(&profile); err != nil {
+		return SeccompProfile{}, fmt.Errorf("parsing seccomp profile %s: %s", path, err)
+	}
+
+	if err := ValidateSeccompProfile(profile); err != nil {
+		return SeccompProfile{}, fmt.Errorf("seccomp profile %s: %s", path, err)
+	}
+
+	return profile, nil
+}
+
+func ValidateSeccompProfile(profile SeccompProfile) error {
+	if !knownSeccompActions[profile.DefaultAction] {
+		return fmt.Errorf("unknown default action %q", profile.DefaultAction)
+	}
+
+	if len(profile.Architectures) > 0 && len(profile.ArchMap) > 0 {
+		return fmt.Errorf("use either architectures or archMap, not both")
+	}
+
+	architectures := profile.Architectures
+	for _, archMap := range profile.ArchMap {
+		architectures = append(architectures, archMap.Architecture)
+		architectures = append(architectures, archMap.SubArchitectures...)
+	}
+
+	for _, arch := range architectures {
+		if !strings.HasPrefix(string(arch), "SCMP_ARCH_") {
+			return fmt.Errorf("unknown architecture %q", arch)
+		}
+	}
+
+	for _, rule := range profile.Syscalls {
+		names := rule.names()
+		if len(names) == 0 {
+			return fmt.Errorf("syscall rule has no name")
+		}
+
+		for _, name := range names {
+			if name == "" {
+				return fmt.Errorf("syscall rule has an empty name")
+			}
+		}
+
+		if !knownSeccompActions[rule.Action] {
+			return fmt.Errorf("syscall %s: unknown action %q", names[0], rule.Action)
+		}
+
+		for _, arg := range rule.Args {
+			if !knownSeccompOperators[arg.Op] {
+				return fmt.Errorf("syscall %s: unknown operator %q", names[0], arg.Op)
+			}
+		}
+
+		for _, min := range []string{rule.Includes.MinKernel, rule.Excludes.MinKernel} {
+			if _, _, ok := parseKernelVersion(min); min != "" && !ok {
+				return fmt.Errorf("syscall %s: minKernel %q must be major.minor, e.g. 4.8", names[0], min)
+			}
+		}
+	}
+
+	return nil
+}
+
+var knownSeccompActions = map[specs.Action]bool{
+	"SCMP_ACT_KILL":  true,
+	"SCMP_ACT_TRAP":  true,
+	"SCMP_ACT_ERRNO": true,
+	"SCMP_ACT_TRACE": true,
+	"SCMP_ACT_ALLOW": true,
+}
+
+var knownSeccompOperators = map[specs.Operator]bool{
+	"SCMP_CMP_NE":        true,
+	"SCMP_CMP_LT":        true,
+	"SCMP_CMP_LE":        true,
+	"SCMP_CMP_EQ":        true,
+	"SCMP_CMP_GE":        true,
+	"SCMP_CMP_GT":        true,
+	"SCMP_CMP_MASKED_EQ": true,
+}
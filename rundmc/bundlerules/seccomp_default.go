@@ -0,0 +1,416 @@
+package bundlerules
+
+import "github.com/opencontainers/specs"
+
+// DefaultSeccompProfile is based on docker's default profile. It denies
+// everything but the syscalls a container needs. Syscalls which would let
+// it act on the host (mounting, unsharing and entering namespaces, tracing
+// other processes, loading kernel modules, changing the clock) are only
+// allowed if it has the capability the kernel checks for them.
+var DefaultSeccompProfile = SeccompProfile{
+	DefaultAction: "SCMP_ACT_ERRNO",
+	ArchMap: []SeccompArchMap{
+		{Architecture: "SCMP_ARCH_X86_64", SubArchitectures: []specs.Arch{"SCMP_ARCH_X86", "SCMP_ARCH_X32"}},
+		{Architecture: "SCMP_ARCH_AARCH64", SubArchitectures: []specs.Arch{"SCMP_ARCH_ARM"}},
+		{Architecture: "SCMP_ARCH_MIPS64", SubArchitectures: []specs.Arch{"SCMP_ARCH_MIPS", "SCMP_ARCH_MIPS64N32"}},
+		{Architecture: "SCMP_ARCH_MIPSEL64", SubArchitectures: []specs.Arch{"SCMP_ARCH_MIPSEL", "SCMP_ARCH_MIPSEL64N32"}},
+		{Architecture: "SCMP_ARCH_PPC64LE", SubArchitectures: []specs.Arch{"SCMP_ARCH_PPC64", "SCMP_ARCH_PPC"}},
+		{Architecture: "SCMP_ARCH_S390X", SubArchitectures: []specs.Arch{"SCMP_ARCH_S390"}},
+	},
+	Syscalls: append([]SeccompRule{
+		{Names: allowedSyscalls, Action: "SCMP_ACT_ALLOW"},
+		allowPersonality(0x0),
+		allowPersonality(0x0008),
+		allowPersonality(0x20000),
+		allowPersonality(0x20008),
+		allowPersonality(0xffffffff),
+		{
+			Names:    []string{"sync_file_range2"},
+			Action:   "SCMP_ACT_ALLOW",
+			Includes: SeccompFilter{Arches: []string{"ppc64le"}},
+		},
+		{
+			Names:    []string{"arm_fadvise64_64", "arm_sync_file_range", "sync_file_range2", "breakpoint", "cacheflush", "set_tls"},
+			Action:   "SCMP_ACT_ALLOW",
+			Includes: SeccompFilter{Arches: []string{"arm", "arm64"}},
+		},
+		{
+			Names:    []string{"arch_prctl"},
+			Action:   "SCMP_ACT_ALLOW",
+			Includes: SeccompFilter{Arches: []string{"amd64"}},
+		},
+		{
+			Names:    []string{"modify_ldt"},
+			Action:   "SCMP_ACT_ALLOW",
+			Includes: SeccompFilter{Arches: []string{"amd64", "386"}},
+		},
+		{
+			Names:    []string{"s390_pci_mmio_read", "s390_pci_mmio_write", "s390_runtime_instr"},
+			Action:   "SCMP_ACT_ALLOW",
+			Includes: SeccompFilter{Arches: []string{"s390", "s390x"}},
+		},
+		{
+			// clone may only make new namespaces with CAP_SYS_ADMIN. s390
+			// swaps clone's first two arguments.
+			Names:    []string{"clone"},
+			Action:   "SCMP_ACT_ALLOW",
+			Args:     []specs.Arg{{Index: 0, Value: cloneNamespaceFlags, ValueTwo: 0, Op: "SCMP_CMP_MASKED_EQ"}},
+			Excludes: SeccompFilter{Caps: []string{"CAP_SYS_ADMIN"}, Arches: []string{"s390", "s390x"}},
+		},
+		{
+			Names:    []string{"clone"},
+			Action:   "SCMP_ACT_ALLOW",
+			Args:     []specs.Arg{{Index: 1, Value: cloneNamespaceFlags, ValueTwo: 0, Op: "SCMP_CMP_MASKED_EQ"}},
+			Includes: SeccompFilter{Arches: []string{"s390", "s390x"}},
+			Excludes: SeccompFilter{Caps: []string{"CAP_SYS_ADMIN"}},
+		},
+	}, capabilitySyscalls...),
+}
+
+// cloneNamespaceFlags are CLONE_NEWNS, CLONE_NEWCGROUP, CLONE_NEWUTS,
+// CLONE_NEWIPC, CLONE_NEWUSER, CLONE_NEWPID and CLONE_NEWNET
+const cloneNamespaceFlags = 0x7E020000
+
+func allowPersonality(persona uint64) SeccompRule {
+	return SeccompRule{
+		Names:  []string{"personality"},
+		Action: "SCMP_ACT_ALLOW",
+		Args:   []specs.Arg{{Index: 0, Value: persona, Op: "SCMP_CMP_EQ"}},
+	}
+}
+
+var capabilitySyscalls = []SeccompRule{
+	allowWith("CAP_DAC_READ_SEARCH", "open_by_handle_at"),
+	allowWith("CAP_SYS_ADMIN",
+		"bpf", "clone", "fanotify_init", "lookup_dcookie", "mount", "name_to_handle_at", "perf_event_open",
+		"quotactl", "setdomainname", "sethostname", "setns", "syslog", "umount", "umount2", "unshare",
+	),
+	allowWith("CAP_SYS_BOOT", "reboot"),
+	allowWith("CAP_SYS_CHROOT", "chroot"),
+	allowWith("CAP_SYS_MODULE", "delete_module", "init_module", "finit_module"),
+	allowWith("CAP_SYS_PACCT", "acct"),
+	allowWith("CAP_SYS_PTRACE", "kcmp", "process_vm_readv", "process_vm_writev", "ptrace"),
+	allowWith("CAP_SYS_RAWIO", "iopl", "ioperm"),
+	allowWith("CAP_SYS_TIME", "adjtimex", "clock_adjtime", "clock_settime", "settimeofday", "stime"),
+	allowWith("CAP_SYS_TTY_CONFIG", "vhangup"),
+	allowWith("CAP_SYS_NICE", "get_mempolicy", "mbind", "set_mempolicy"),
+	allowWith("CAP_SYSLOG", "syslog"),
+}
+
+func allowWith(capability string, names ...string) SeccompRule {
+	return SeccompRule{
+		Names:    names,
+		Action:   "SCMP_ACT_ALLOW",
+		Includes: SeccompFilter{Caps: []string{capability}},
+	}
+}
+
+var allowedSyscalls = []string{
+	"accept",
+	"accept4",
+	"access",
+	"alarm",
+	"bind",
+	"brk",
+	"capget",
+	"capset",
+	"chdir",
+	"chmod",
+	"chown",
+	"chown32",
+	"clock_getres",
+	"clock_gettime",
+	"clock_nanosleep",
+	"close",
+	"connect",
+	"copy_file_range",
+	"creat",
+	"dup",
+	"dup2",
+	"dup3",
+	"epoll_create",
+	"epoll_create1",
+	"epoll_ctl",
+	"epoll_ctl_old",
+	"epoll_pwait",
+	"epoll_wait",
+	"epoll_wait_old",
+	"eventfd",
+	"eventfd2",
+	"execve",
+	"execveat",
+	"exit",
+	"exit_group",
+	"faccessat",
+	"fadvise64",
+	"fadvise64_64",
+	"fallocate",
+	"fanotify_mark",
+	"fchdir",
+	"fchmod",
+	"fchmodat",
+	"fchown",
+	"fchown32",
+	"fchownat",
+	"fcntl",
+	"fcntl64",
+	"fdatasync",
+	"fgetxattr",
+	"flistxattr",
+	"flock",
+	"fork",
+	"fremovexattr",
+	"fsetxattr",
+	"fstat",
+	"fstat64",
+	"fstatat64",
+	"fstatfs",
+	"fstatfs64",
+	"fsync",
+	"ftruncate",
+	"ftruncate64",
+	"futex",
+	"futimesat",
+	"getcpu",
+	"getcwd",
+	"getdents",
+	"getdents64",
+	"getegid",
+	"getegid32",
+	"geteuid",
+	"geteuid32",
+	"getgid",
+	"getgid32",
+	"getgroups",
+	"getgroups32",
+	"getitimer",
+	"getpeername",
+	"getpgid",
+	"getpgrp",
+	"getpid",
+	"getppid",
+	"getpriority",
+	"getrandom",
+	"getresgid",
+	"getresgid32",
+	"getresuid",
+	"getresuid32",
+	"getrlimit",
+	"get_robust_list",
+	"getrusage",
+	"getsid",
+	"getsockname",
+	"getsockopt",
+	"get_thread_area",
+	"gettid",
+	"gettimeofday",
+	"getuid",
+	"getuid32",
+	"getxattr",
+	"inotify_add_watch",
+	"inotify_init",
+	"inotify_init1",
+	"inotify_rm_watch",
+	"io_cancel",
+	"ioctl",
+	"io_destroy",
+	"io_getevents",
+	"ioprio_get",
+	"ioprio_set",
+	"io_setup",
+	"io_submit",
+	"ipc",
+	"kill",
+	"lchown",
+	"lchown32",
+	"lgetxattr",
+	"link",
+	"linkat",
+	"listen",
+	"listxattr",
+	"llistxattr",
+	"_llseek",
+	"lremovexattr",
+	"lseek",
+	"lsetxattr",
+	"lstat",
+	"lstat64",
+	"madvise",
+	"memfd_create",
+	"mincore",
+	"mkdir",
+	"mkdirat",
+	"mknod",
+	"mknodat",
+	"mlock",
+	"mlock2",
+	"mlockall",
+	"mmap",
+	"mmap2",
+	"mprotect",
+	"mq_getsetattr",
+	"mq_notify",
+	"mq_open",
+	"mq_timedreceive",
+	"mq_timedsend",
+	"mq_unlink",
+	"mremap",
+	"msgctl",
+	"msgget",
+	"msgrcv",
+	"msgsnd",
+	"msync",
+	"munlock",
+	"munlockall",
+	"munmap",
+	"nanosleep",
+	"newfstatat",
+	"_newselect",
+	"open",
+	"openat",
+	"pause",
+	"pipe",
+	"pipe2",
+	"poll",
+	"ppoll",
+	"prctl",
+	"pread64",
+	"preadv",
+	"preadv2",
+	"prlimit64",
+	"pselect6",
+	"pwrite64",
+	"pwritev",
+	"pwritev2",
+	"read",
+	"readahead",
+	"readlink",
+	"readlinkat",
+	"readv",
+	"recv",
+	"recvfrom",
+	"recvmmsg",
+	"recvmsg",
+	"remap_file_pages",
+	"removexattr",
+	"rename",
+	"renameat",
+	"renameat2",
+	"restart_syscall",
+	"rmdir",
+	"rt_sigaction",
+	"rt_sigpending",
+	"rt_sigprocmask",
+	"rt_sigqueueinfo",
+	"rt_sigreturn",
+	"rt_sigsuspend",
+	"rt_sigtimedwait",
+	"rt_tgsigqueueinfo",
+	"sched_getaffinity",
+	"sched_getattr",
+	"sched_getparam",
+	"sched_get_priority_max",
+	"sched_get_priority_min",
+	"sched_getscheduler",
+	"sched_rr_get_interval",
+	"sched_setaffinity",
+	"sched_setattr",
+	"sched_setparam",
+	"sched_setscheduler",
+	"sched_yield",
+	"seccomp",
+	"select",
+	"semctl",
+	"semget",
+	"semop",
+	"semtimedop",
+	"send",
+	"sendfile",
+	"sendfile64",
+	"sendmmsg",
+	"sendmsg",
+	"sendto",
+	"setfsgid",
+	"setfsgid32",
+	"setfsuid",
+	"setfsuid32",
+	"setgid",
+	"setgid32",
+	"setgroups",
+	"setgroups32",
+	"setitimer",
+	"setpgid",
+	"setpriority",
+	"setregid",
+	"setregid32",
+	"setresgid",
+	"setresgid32",
+	"setresuid",
+	"setresuid32",
+	"setreuid",
+	"setreuid32",
+	"setrlimit",
+	"set_robust_list",
+	"setsid",
+	"setsockopt",
+	"set_thread_area",
+	"set_tid_address",
+	"setuid",
+	"setuid32",
+	"setxattr",
+	"shmat",
+	"shmctl",
+	"shmdt",
+	"shmget",
+	"shutdown",
+	"sigaltstack",
+	"signalfd",
+	"signalfd4",
+	"sigprocmask",
+	"sigreturn",
+	"socket",
+	"socketcall",
+	"socketpair",
+	"splice",
+	"stat",
+	"stat64",
+	"statfs",
+	"statfs64",
+	"statx",
+	"symlink",
+	"symlinkat",
+	"sync",
+	"sync_file_range",
+	"syncfs",
+	"sysinfo",
+	"tee",
+	"tgkill",
+	"time",
+	"timer_create",
+	"timer_delete",
+	"timer_getoverrun",
+	"timer_gettime",
+	"timer_settime",
+	"timerfd_create",
+	"timerfd_gettime",
+	"timerfd_settime",
+	"times",
+	"tkill",
+	"truncate",
+	"truncate64",
+	"ugetrlimit",
+	"umask",
+	"uname",
+	"unlink",
+	"unlinkat",
+	"utime",
+	"utimensat",
+	"utimes",
+	"vfork",
+	"vmsplice",
+	"wait4",
+	"waitid",
+	"waitpid",
+	"write",
+	"writev",
+}
@@ -0,0 +1,394 @@
+package bundlerules_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/goci"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/rundmc/bundlerules"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/opencontainers/specs"
+)
+
+var _ = Describe("SeccompRule", func() {
+	var (
+		profile bundlerules.SeccompProfile
+		bndl    *goci.Bndl
+	)
+
+	BeforeEach(func() {
+		profile = bundlerules.SeccompProfile{
+			DefaultAction: "SCMP_ACT_ALLOW",
+			Architectures: []specs.Arch{"SCMP_ARCH_X86_64"},
+			Syscalls:      []bundlerules.SeccompRule{{Name: "reboot", Action: "SCMP_ACT_ERRNO"}},
+		}
+
+		bndl = goci.Bundle()
+	})
+
+	renderedSeccomp := func(b *goci.Bndl) map[string]interface{} {
+		data, err := json.Marshal(b.Spec)
+		Expect(err).NotTo(HaveOccurred())
+
+		var config struct {
+			Linux struct {
+				Seccomp map[string]interface{} `json:"seccomp"`
+			} `json:"linux"`
+		}
+		Expect(json.Unmarshal(data, &config)).To(Succeed())
+
+		return config.Linux.Seccomp
+	}
+
+	It("renders the profile in to the config.json of unprivileged containers", func() {
+		newBndl, err := bundlerules.Seccomp{Profile: profile}.Apply(bndl, gardener.DesiredContainerSpec{})
+		Expect(err).NotTo(HaveOccurred())
+
+		seccomp := renderedSeccomp(newBndl)
+		Expect(seccomp).To(HaveKeyWithValue("defaultAction", "SCMP_ACT_ALLOW"))
+		Expect(seccomp).To(HaveKeyWithValue("architectures", ConsistOf("SCMP_ARCH_X86_64")))
+		Expect(seccomp["syscalls"]).To(ConsistOf(And(
+			HaveKeyWithValue("name", "reboot"),
+			HaveKeyWithValue("action", "SCMP_ACT_ERRNO"),
+		)))
+	})
+
+	It("does not modify the original bundle", func() {
+		bundlerules.Seccomp{Profile: profile}.Apply(bndl, gardener.DesiredContainerSpec{})
+		Expect(bndl.Spec.Linux.Seccomp.DefaultAction).To(BeEmpty())
+	})
+
+	It("renders the profile for privileged containers too", func() {
+		newBndl, err := bundlerules.Seccomp{Profile: profile}.Apply(bndl, gardener.DesiredContainerSpec{Privileged: true})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(renderedSeccomp(newBndl)).To(HaveKeyWithValue("defaultAction", "SCMP_ACT_ALLOW"))
+	})
+
+	Context("when the container opts out of seccomp", func() {
+		var properties garden.Properties
+
+		BeforeEach(func() {
+			properties = garden.Properties{bundlerules.SeccompProperty: bundlerules.SeccompUnconfined}
+		})
+
+		It("does not filter a privileged container", func() {
+			newBndl, err := bundlerules.Seccomp{Profile: profile}.Apply(bndl, gardener.DesiredContainerSpec{
+				Privileged: true,
+				Properties: properties,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(renderedSeccomp(newBndl)).To(HaveKeyWithValue("defaultAction", ""))
+		})
+
+		It("still filters an unprivileged container", func() {
+			newBndl, err := bundlerules.Seccomp{Profile: profile}.Apply(bndl, gardener.DesiredContainerSpec{
+				Properties: properties,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(renderedSeccomp(newBndl)).To(HaveKeyWithValue("defaultAction", "SCMP_ACT_ALLOW"))
+		})
+	})
+
+	It("resolves conditional rules against the container's capabilities", func() {
+		profile.Syscalls = append(profile.Syscalls, bundlerules.SeccompRule{
+			Name:     "ptrace",
+			Action:   "SCMP_ACT_ERRNO",
+			Excludes: bundlerules.SeccompFilter{Caps: []string{"CAP_SYS_PTRACE"}},
+		})
+
+		bndl = bndl.WithCapabilities("CAP_SYS_PTRACE")
+		newBndl, err := bundlerules.Seccomp{Profile: profile}.Apply(bndl, gardener.DesiredContainerSpec{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(newBndl.Spec.Linux.Seccomp.Syscalls).To(ConsistOf(specs.Syscall{Name: "reboot", Action: "SCMP_ACT_ERRNO"}))
+	})
+
+	Describe("Resolve", func() {
+		names := func(seccomp specs.Seccomp) []string {
+			var names []string
+			for _, syscall := range seccomp.Syscalls {
+				names = append(names, syscall.Name)
+			}
+
+			return names
+		}
+
+		BeforeEach(func() {
+			profile.Architectures = nil
+			profile.ArchMap = []bundlerules.SeccompArchMap{
+				{Architecture: "SCMP_ARCH_X86_64", SubArchitectures: []specs.Arch{"SCMP_ARCH_X86", "SCMP_ARCH_X32"}},
+				{Architecture: "SCMP_ARCH_AARCH64", SubArchitectures: []specs.Arch{"SCMP_ARCH_ARM"}},
+			}
+		})
+
+		It("picks the architectures mapped from the host's GOARCH", func() {
+			Expect(profile.Resolve("amd64", "", nil).Architectures).To(Equal([]specs.Arch{"SCMP_ARCH_X86_64", "SCMP_ARCH_X86", "SCMP_ARCH_X32"}))
+			Expect(profile.Resolve("arm64", "", nil).Architectures).To(Equal([]specs.Arch{"SCMP_ARCH_AARCH64", "SCMP_ARCH_ARM"}))
+		})
+
+		It("leaves the architectures to seccomp when the host's is not mapped", func() {
+			Expect(profile.Resolve("s390x", "", nil).Architectures).To(BeEmpty())
+		})
+
+		It("expands rules which list syscalls in groups", func() {
+			profile.Syscalls = []bundlerules.SeccompRule{{Name: "read", Names: []string{"write"}, Action: "SCMP_ACT_ALLOW"}}
+			Expect(profile.Resolve("amd64", "", nil).Syscalls).To(Equal([]specs.Syscall{
+				{Name: "read", Action: "SCMP_ACT_ALLOW"},
+				{Name: "write", Action: "SCMP_ACT_ALLOW"},
+			}))
+		})
+
+		It("applies rules included for the host's architecture", func() {
+			profile.Syscalls = []bundlerules.SeccompRule{
+				{Name: "arch_prctl", Action: "SCMP_ACT_ALLOW", Includes: bundlerules.SeccompFilter{Arches: []string{"amd64"}}},
+				{Name: "set_tls", Action: "SCMP_ACT_ALLOW", Excludes: bundlerules.SeccompFilter{Arches: []string{"amd64"}}},
+			}
+
+			Expect(names(profile.Resolve("amd64", "", nil))).To(Equal([]string{"arch_prctl"}))
+			Expect(names(profile.Resolve("arm64", "", nil))).To(Equal([]string{"set_tls"}))
+		})
+
+		It("applies rules included for all of the container's capabilities", func() {
+			profile.Syscalls = []bundlerules.SeccompRule{
+				{Name: "mount", Action: "SCMP_ACT_ALLOW", Includes: bundlerules.SeccompFilter{Caps: []string{"CAP_SYS_ADMIN", "CAP_SYS_CHROOT"}}},
+				{Name: "clone", Action: "SCMP_ACT_ALLOW", Excludes: bundlerules.SeccompFilter{Caps: []string{"CAP_SYS_ADMIN"}}},
+			}
+
+			Expect(names(profile.Resolve("amd64", "", nil))).To(Equal([]string{"clone"}))
+			Expect(names(profile.Resolve("amd64", "", []string{"CAP_SYS_ADMIN"}))).To(BeEmpty())
+			Expect(names(profile.Resolve("amd64", "", []string{"CAP_SYS_ADMIN", "CAP_SYS_CHROOT"}))).To(Equal([]string{"mount"}))
+		})
+
+		It("applies rules included for the host's kernel", func() {
+			profile.Syscalls = []bundlerules.SeccompRule{
+				{Name: "ptrace", Action: "SCMP_ACT_ALLOW", Includes: bundlerules.SeccompFilter{MinKernel: "4.8"}},
+				{Name: "uselib", Action: "SCMP_ACT_ALLOW", Excludes: bundlerules.SeccompFilter{MinKernel: "4.8"}},
+			}
+
+			Expect(names(profile.Resolve("amd64", "4.4.0-21-generic", nil))).To(Equal([]string{"uselib"}))
+			Expect(names(profile.Resolve("amd64", "4.10.3", nil))).To(Equal([]string{"ptrace"}))
+			Expect(names(profile.Resolve("amd64", "5.0", nil))).To(Equal([]string{"ptrace"}))
+			Expect(names(profile.Resolve("amd64", "", nil))).To(Equal([]string{"uselib"}))
+		})
+	})
+
+	Describe("DefaultSeccompProfile", func() {
+		var allowed func(caps ...string) []string
+
+		BeforeEach(func() {
+			allowed = func(caps ...string) []string {
+				var names []string
+				for _, syscall := range bundlerules.DefaultSeccompProfile.Resolve("amd64", "4.4.0", caps).Syscalls {
+					if syscall.Action == "SCMP_ACT_ALLOW" && len(syscall.Args) == 0 {
+						names = append(names, syscall.Name)
+					}
+				}
+
+				return names
+			}
+		})
+
+		It("is valid", func() {
+			Expect(bundlerules.ValidateSeccompProfile(bundlerules.DefaultSeccompProfile)).To(Succeed())
+		})
+
+		It("denies syscalls which are not allowed", func() {
+			Expect(bundlerules.DefaultSeccompProfile.DefaultAction).To(BeEquivalentTo("SCMP_ACT_ERRNO"))
+		})
+
+		It("allows ordinary syscalls", func() {
+			Expect(allowed()).To(ContainElement("read"))
+			Expect(allowed()).To(ContainElement("arch_prctl"))
+		})
+
+		It("only filters the host's architectures", func() {
+			Expect(bundlerules.DefaultSeccompProfile.Resolve("arm64", "", nil).Architectures).To(Equal([]specs.Arch{"SCMP_ARCH_AARCH64", "SCMP_ARCH_ARM"}))
+		})
+
+		It("does not let a container without capabilities escape it", func() {
+			for _, name := range []string{"mount", "umount2", "unshare", "setns", "pivot_root", "ptrace", "init_module", "reboot", "clock_settime"} {
+				Expect(allowed()).NotTo(ContainElement(name))
+			}
+		})
+
+		It("only lets the container clone without making namespaces", func() {
+			Expect(bundlerules.DefaultSeccompProfile.Resolve("amd64", "", nil).Syscalls).To(ContainElement(specs.Syscall{
+				Name:   "clone",
+				Action: "SCMP_ACT_ALLOW",
+				Args:   []specs.Arg{{Index: 0, Value: 0x7E020000, Op: "SCMP_CMP_MASKED_EQ"}},
+			}))
+		})
+
+		It("only allows the default personalities", func() {
+			Expect(allowed()).NotTo(ContainElement("personality"))
+			Expect(bundlerules.DefaultSeccompProfile.Resolve("amd64", "", nil).Syscalls).To(ContainElement(specs.Syscall{
+				Name:   "personality",
+				Action: "SCMP_ACT_ALLOW",
+				Args:   []specs.Arg{{Index: 0, Value: 0, Op: "SCMP_CMP_EQ"}},
+			}))
+		})
+
+		It("allows syscalls which need a capability to containers which have it", func() {
+			Expect(allowed("CAP_SYS_ADMIN")).To(ContainElement("mount"))
+			Expect(allowed("CAP_SYS_ADMIN")).To(ContainElement("clone"))
+			Expect(allowed("CAP_SYS_PTRACE")).To(ContainElement("ptrace"))
+		})
+	})
+
+	Describe("ValidateSeccompProfile", func() {
+		It("rejects unknown default actions", func() {
+			profile.DefaultAction = "SCMP_ACT_PANIC"
+			Expect(bundlerules.ValidateSeccompProfile(profile)).To(MatchError(`unknown default action "SCMP_ACT_PANIC"`))
+		})
+
+		It("rejects unknown architectures", func() {
+			profile.Architectures = []specs.Arch{"x86_64"}
+			Expect(bundlerules.ValidateSeccompProfile(profile)).To(MatchError(`unknown architecture "x86_64"`))
+		})
+
+		It("rejects unknown architectures in the arch map", func() {
+			profile.Architectures = nil
+			profile.ArchMap = []bundlerules.SeccompArchMap{{Architecture: "SCMP_ARCH_X86_64", SubArchitectures: []specs.Arch{"i386"}}}
+			Expect(bundlerules.ValidateSeccompProfile(profile)).To(MatchError(`unknown architecture "i386"`))
+		})
+
+		It("rejects both architectures and an arch map", func() {
+			profile.ArchMap = []bundlerules.SeccompArchMap{{Architecture: "SCMP_ARCH_X86_64"}}
+			Expect(bundlerules.ValidateSeccompProfile(profile)).To(MatchError("use either architectures or archMap, not both"))
+		})
+
+		It("rejects syscall rules without a name", func() {
+			profile.Syscalls = []bundlerules.SeccompRule{{Action: "SCMP_ACT_ERRNO"}}
+			Expect(bundlerules.ValidateSeccompProfile(profile)).To(MatchError("syscall rule has no name"))
+		})
+
+		It("rejects bad minimum kernels", func() {
+			profile.Syscalls[0].Includes.MinKernel = "four"
+			Expect(bundlerules.ValidateSeccompProfile(profile)).To(MatchError(`syscall reboot: minKernel "four" must be major.minor, e.g. 4.8`))
+		})
+
+		It("rejects unknown syscall actions", func() {
+			profile.Syscalls[0].Action = "SCMP_ACT_NOPE"
+			Expect(bundlerules.ValidateSeccompProfile(profile)).To(MatchError(`syscall reboot: unknown action "SCMP_ACT_NOPE"`))
+		})
+
+		It("rejects unknown argument operators", func() {
+			profile.Syscalls[0].Args = []specs.Arg{{Index: 0, Value: 1, Op: "SCMP_CMP_ISH"}}
+			Expect(bundlerules.ValidateSeccompProfile(profile)).To(MatchError(`syscall reboot: unknown operator "SCMP_CMP_ISH"`))
+		})
+	})
+
+	Describe("LoadSeccompProfile", func() {
+		var path string
+
+		BeforeEach(func() {
+			dir, err := ioutil.TempDir("", "seccomp")
+			Expect(err).NotTo(HaveOccurred())
+
+			path = filepath.Join(dir, "seccomp.json")
+		})
+
+		AfterEach(func() {
+			Expect(os.RemoveAll(filepath.Dir(path))).To(Succeed())
+		})
+
+		It("loads a docker profile listing syscalls one at a time", func() {
+			Expect(ioutil.WriteFile(path, []byte(`{
+				"defaultAction": "SCMP_ACT_ERRNO",
+				"architectures": ["SCMP_ARCH_X86_64", "SCMP_ARCH_X86"],
+				"syscalls": [
+					{"name": "read", "action": "SCMP_ACT_ALLOW", "args": []},
+					{"name": "personality", "action": "SCMP_ACT_ALLOW", "args": [{"index": 0, "value": 8, "valueTwo": 0, "op": "SCMP_CMP_EQ"}]}
+				]
+			}`), 0644)).To(Succeed())
+
+			loaded, err := bundlerules.LoadSeccompProfile(path)
+			Expect(err).NotTo(HaveOccurred())
+
+			resolved := loaded.Resolve("amd64", "", nil)
+			Expect(resolved.DefaultAction).To(BeEquivalentTo("SCMP_ACT_ERRNO"))
+			Expect(resolved.Architectures).To(Equal([]specs.Arch{"SCMP_ARCH_X86_64", "SCMP_ARCH_X86"}))
+			Expect(resolved.Syscalls).To(HaveLen(2))
+			Expect(resolved.Syscalls[0].Name).To(Equal("read"))
+			Expect(resolved.Syscalls[1].Args).To(Equal([]specs.Arg{{Index: 0, Value: 8, Op: "SCMP_CMP_EQ"}}))
+		})
+
+		It("loads a docker profile listing syscalls in groups, with an arch map", func() {
+			Expect(ioutil.WriteFile(path, []byte(`{
+				"defaultAction": "SCMP_ACT_ERRNO",
+				"archMap": [{"architecture": "SCMP_ARCH_X86_64", "subArchitectures": ["SCMP_ARCH_X86", "SCMP_ARCH_X32"]}],
+				"syscalls": [{"names": ["read", "write"], "action": "SCMP_ACT_ALLOW"}]
+			}`), 0644)).To(Succeed())
+
+			loaded, err := bundlerules.LoadSeccompProfile(path)
+			Expect(err).NotTo(HaveOccurred())
+
+			resolved := loaded.Resolve("amd64", "", nil)
+			Expect(resolved.Architectures).To(Equal([]specs.Arch{"SCMP_ARCH_X86_64", "SCMP_ARCH_X86", "SCMP_ARCH_X32"}))
+			Expect(resolved.Syscalls).To(Equal([]specs.Syscall{
+				{Name: "read", Action: "SCMP_ACT_ALLOW"},
+				{Name: "write", Action: "SCMP_ACT_ALLOW"},
+			}))
+		})
+
+		It("loads conditional syscall rules, as in docker's default profile", func() {
+			Expect(ioutil.WriteFile(path, []byte(`{
+				"defaultAction": "SCMP_ACT_ERRNO",
+				"archMap": [{"architecture": "SCMP_ARCH_X86_64", "subArchitectures": ["SCMP_ARCH_X86", "SCMP_ARCH_X32"]}],
+				"syscalls": [
+					{"names": ["read"], "action": "SCMP_ACT_ALLOW", "args": [], "comment": "", "includes": {}, "excludes": {}},
+					{"names": ["mount"], "action": "SCMP_ACT_ALLOW", "args": [], "comment": "", "includes": {"caps": ["CAP_SYS_ADMIN"]}, "excludes": {}},
+					{"names": ["arch_prctl"], "action": "SCMP_ACT_ALLOW", "args": [], "comment": "", "includes": {"arches": ["amd64"]}, "excludes": {}},
+					{"names": ["ptrace"], "action": "SCMP_ACT_ALLOW", "args": null, "comment": "", "includes": {"minKernel": "4.8"}, "excludes": {}},
+					{
+						"names": ["clone"],
+						"action": "SCMP_ACT_ALLOW",
+						"args": [{"index": 0, "value": 2114060288, "valueTwo": 0, "op": "SCMP_CMP_MASKED_EQ"}],
+						"comment": "s390 parameter ordering for clone is different",
+						"includes": {},
+						"excludes": {"caps": ["CAP_SYS_ADMIN"], "arches": ["s390", "s390x"]}
+					}
+				]
+			}`), 0644)).To(Succeed())
+
+			loaded, err := bundlerules.LoadSeccompProfile(path)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(loaded.Resolve("amd64", "4.4.0", nil).Syscalls).To(Equal([]specs.Syscall{
+				{Name: "read", Action: "SCMP_ACT_ALLOW", Args: []specs.Arg{}},
+				{Name: "arch_prctl", Action: "SCMP_ACT_ALLOW", Args: []specs.Arg{}},
+				{Name: "clone", Action: "SCMP_ACT_ALLOW", Args: []specs.Arg{{Index: 0, Value: 2114060288, Op: "SCMP_CMP_MASKED_EQ"}}},
+			}))
+			Expect(loaded.Resolve("s390x", "4.8.0", []string{"CAP_SYS_ADMIN"}).Syscalls).To(Equal([]specs.Syscall{
+				{Name: "read", Action: "SCMP_ACT_ALLOW", Args: []specs.Arg{}},
+				{Name: "mount", Action: "SCMP_ACT_ALLOW", Args: []specs.Arg{}},
+				{Name: "ptrace", Action: "SCMP_ACT_ALLOW"},
+			}))
+		})
+
+		It("validates the profile", func() {
+			Expect(ioutil.WriteFile(path, []byte(`{"defaultAction": "SCMP_ACT_WHATEVER"}`), 0644)).To(Succeed())
+
+			_, err := bundlerules.LoadSeccompProfile(path)
+			Expect(err).To(MatchError(ContainSubstring(`unknown default action "SCMP_ACT_WHATEVER"`)))
+		})
+
+		It("fails on bad JSON", func() {
+			Expect(ioutil.WriteFile(path, []byte(`{`), 0644)).To(Succeed())
+
+			_, err := bundlerules.LoadSeccompProfile(path)
+			Expect(err).To(MatchError(ContainSubstring("parsing seccomp profile")))
+		})
+
+		It("fails when the file does not exist", func() {
+			_, err := bundlerules.LoadSeccompProfile(path)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
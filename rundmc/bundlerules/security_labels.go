@@ -0,0 +1,28 @@
+package bundlerules
+
+import (
+	"github.com/cloudfoundry-incubator/goci"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+)
+
+// SecurityLabels confines the container's init process with an AppArmor
+// profile and SELinux labels. Processes run later are given the same labels
+// by runrunc.ExecPreparer. The AppArmor profile only applies to unprivileged
+// containers. It must be applied after InitProcess.
+type SecurityLabels struct {
+	AppArmorProfile     string
+	SELinuxProcessLabel string
+	SELinuxMountLabel   string
+}
+
+func (r SecurityLabels) Apply(bndl *goci.Bndl, spec gardener.DesiredContainerSpec) (*goci.Bndl, error) {
+	process := bndl.Spec.Spec.Process
+	if !spec.Privileged {
+		process.ApparmorProfile = r.AppArmorProfile
+	}
+	process.SelinuxLabel = r.SELinuxProcessLabel
+
+	newBndl := bndl.WithProcess(process)
+	newBndl.Spec.Linux.MountLabel = r.SELinuxMountLabel
+	return newBndl, nil
+}
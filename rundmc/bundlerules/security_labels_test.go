@@ -0,0 +1,53 @@
+package bundlerules_test
+
+import (
+	"github.com/cloudfoundry-incubator/goci"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/rundmc/bundlerules"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/opencontainers/specs"
+)
+
+var _ = Describe("SecurityLabelsRule", func() {
+	var (
+		rule bundlerules.SecurityLabels
+		bndl *goci.Bndl
+	)
+
+	BeforeEach(func() {
+		rule = bundlerules.SecurityLabels{
+			AppArmorProfile:     "garden-default",
+			SELinuxProcessLabel: "system_u:system_r:svirt_lxc_net_t:s0:c1,c2",
+			SELinuxMountLabel:   "system_u:object_r:svirt_sandbox_file_t:s0:c1,c2",
+		}
+
+		bndl = goci.Bundle().WithProcess(specs.Process{Args: []string{"/tmp/garden-init"}})
+	})
+
+	It("sets the apparmor profile and selinux labels of an unprivileged container", func() {
+		newBndl, err := rule.Apply(bndl, gardener.DesiredContainerSpec{})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(newBndl.Spec.Process.Args).To(Equal([]string{"/tmp/garden-init"}))
+		Expect(newBndl.Spec.Process.ApparmorProfile).To(Equal("garden-default"))
+		Expect(newBndl.Spec.Process.SelinuxLabel).To(Equal("system_u:system_r:svirt_lxc_net_t:s0:c1,c2"))
+		Expect(newBndl.Spec.Linux.MountLabel).To(Equal("system_u:object_r:svirt_sandbox_file_t:s0:c1,c2"))
+	})
+
+	It("does not apply the apparmor profile to a privileged container", func() {
+		newBndl, err := rule.Apply(bndl, gardener.DesiredContainerSpec{Privileged: true})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(newBndl.Spec.Process.ApparmorProfile).To(BeEmpty())
+		Expect(newBndl.Spec.Process.SelinuxLabel).To(Equal("system_u:system_r:svirt_lxc_net_t:s0:c1,c2"))
+		Expect(newBndl.Spec.Linux.MountLabel).To(Equal("system_u:object_r:svirt_sandbox_file_t:s0:c1,c2"))
+	})
+
+	It("does not modify the original bundle", func() {
+		rule.Apply(bndl, gardener.DesiredContainerSpec{})
+
+		Expect(bndl.Spec.Process.ApparmorProfile).To(BeEmpty())
+		Expect(bndl.Spec.Linux.MountLabel).To(BeEmpty())
+	})
+})
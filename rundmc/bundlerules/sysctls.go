@@ -0,0 +1,125 @@
+package bundlerules
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/goci"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/opencontainers/specs"
+)
+
+// SysctlsProperty is a comma-separated list of name=value sysctls to set in
+// the container (e.g. "net.core.somaxconn=1024")
+const SysctlsProperty = "garden.sysctls"
+
+// DefaultAllowedSysctls are namespaced, and safe for unprivileged containers
+// to set. A trailing * matches any suffix.
+var DefaultAllowedSysctls = []string{
+	"kernel.shm_rmid_forced",
+	"net.core.somaxconn",
+	"net.ipv4.ip_local_port_range",
+	"net.ipv4.ping_group_range",
+	"net.ipv4.tcp_fin_timeout",
+	"net.ipv4.tcp_keepalive_*",
+	"net.ipv4.tcp_syncookies",
+	"net.ipv4.tcp_tw_reuse",
+}
+
+// namespacedSysctls maps the prefixes of the sysctls which only affect the
+// container's own namespaces to the namespace they belong to
+var namespacedSysctls = map[string]specs.NamespaceType{
+	"kernel.msg": specs.IPCNamespace,
+	"kernel.sem": specs.IPCNamespace,
+	"kernel.shm": specs.IPCNamespace,
+	"fs.mqueue.": specs.IPCNamespace,
+	"net.":       specs.NetworkNamespace,
+}
+
+var (
+	sysctlName  = regexp.MustCompile(`^[a-zA-Z0-9_-]+(\.[a-zA-Z0-9_-]+)+$`)
+	sysctlValue = regexp.MustCompile(`^[a-zA-Z0-9 ._:/-]{1,256}$`)
+)
+
+// Sysctls sets the sysctls a container asks for, as long as they are allowed
+// and the container unshares the namespace they belong to
+type Sysctls struct {
+	Allowed []string
+}
+
+func (r Sysctls) Validate() error {
+	for _, allowed := range r.Allowed {
+		if _, ok := namespaceOf(strings.TrimSuffix(allowed, "*")); !ok {
+			return fmt.Errorf("sysctl %s is not namespaced", allowed)
+		}
+	}
+
+	return nil
+}
+
+func (r Sysctls) Apply(bndl *goci.Bndl, spec gardener.DesiredContainerSpec) (*goci.Bndl, error) {
+	requested := list(spec.Properties[SysctlsProperty])
+	if len(requested) == 0 {
+		return bndl, nil
+	}
+
+	sysctls := make(map[string]string)
+	for name, value := range bndl.Spec.Linux.Sysctl {
+		sysctls[name] = value
+	}
+
+	for _, entry := range requested {
+		i := strings.Index(entry, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("sysctl %q: must be name=value", entry)
+		}
+
+		name, value := strings.TrimSpace(entry[:i]), strings.TrimSpace(entry[i+1:])
+		if name == "" || value == "" {
+			return nil, fmt.Errorf("sysctl %q: must be name=value", entry)
+		}
+
+		if !sysctlName.MatchString(name) {
+			return nil, fmt.Errorf("sysctl %q: name must be dot-separated letters, digits, _ and -", entry)
+		}
+
+		if !sysctlValue.MatchString(value) {
+			return nil, fmt.Errorf("sysctl %q: value must be at most 256 letters, digits, spaces and ._:/-", entry)
+		}
+
+		if !r.allowed(name) {
+			return nil, fmt.Errorf("sysctl %s is not allowed (allowed: %s)", name, describe(r.Allowed))
+		}
+
+		if ns, _ := namespaceOf(name); !hasPrivateNamespace(bndl, ns) {
+			return nil, fmt.Errorf("sysctl %s: the container does not have its own %s namespace", name, ns)
+		}
+
+		sysctls[name] = value
+	}
+
+	newBndl := *bndl
+	newBndl.Spec.Linux.Sysctl = sysctls
+	return &newBndl, nil
+}
+
+func (r Sysctls) allowed(name string) bool {
+	for _, allowed := range r.Allowed {
+		if name == allowed || (strings.HasSuffix(allowed, "*") && strings.HasPrefix(name, strings.TrimSuffix(allowed, "*"))) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func namespaceOf(name string) (specs.NamespaceType, bool) {
+	for prefix, ns := range namespacedSysctls {
+		if strings.HasPrefix(name, prefix) {
+			return ns, true
+		}
+	}
+
+	return "", false
+}
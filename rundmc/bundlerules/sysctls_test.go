@@ -0,0 +1,100 @@
+package bundlerules_test
+
+import (
+	"strings"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/goci"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/rundmc/bundlerules"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"github.com/opencontainers/specs"
+)
+
+var _ = Describe("SysctlsRule", func() {
+	var (
+		rule bundlerules.Sysctls
+		bndl *goci.Bndl
+	)
+
+	BeforeEach(func() {
+		rule = bundlerules.Sysctls{Allowed: []string{"net.core.somaxconn", "net.ipv4.tcp_keepalive_*", "net.ipv4.ip_local_port_range", "kernel.shm_rmid_forced"}}
+		bndl = goci.Bundle().WithNamespaces(goci.NetworkNamespace, goci.IPCNamespace)
+	})
+
+	apply := func(bndl *goci.Bndl, sysctls string) (*goci.Bndl, error) {
+		return rule.Apply(bndl, gardener.DesiredContainerSpec{
+			Properties: garden.Properties{bundlerules.SysctlsProperty: sysctls},
+		})
+	}
+
+	It("sets the sysctls the container asks for", func() {
+		newBndl, err := apply(bndl, "net.core.somaxconn=1024, net.ipv4.tcp_keepalive_time=600, net.ipv4.ip_local_port_range=32768 60999, kernel.shm_rmid_forced=1")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(newBndl.Spec.Linux.Sysctl).To(Equal(map[string]string{
+			"net.core.somaxconn":           "1024",
+			"net.ipv4.tcp_keepalive_time":  "600",
+			"net.ipv4.ip_local_port_range": "32768 60999",
+			"kernel.shm_rmid_forced":       "1",
+		}))
+	})
+
+	It("refuses net. sysctls unless the container has its own network namespace", func() {
+		bndl = goci.Bundle().WithNamespaces(goci.IPCNamespace, specs.Namespace{Type: specs.NetworkNamespace, Path: "/proc/1/ns/net"})
+
+		_, err := apply(bndl, "net.core.somaxconn=1024")
+		Expect(err).To(MatchError("sysctl net.core.somaxconn: the container does not have its own network namespace"))
+	})
+
+	It("refuses IPC sysctls unless the container has its own IPC namespace", func() {
+		bndl = goci.Bundle().WithNamespaces(goci.NetworkNamespace)
+
+		_, err := apply(bndl, "kernel.shm_rmid_forced=1")
+		Expect(err).To(MatchError("sysctl kernel.shm_rmid_forced: the container does not have its own ipc namespace"))
+	})
+
+	It("leaves the bundle alone when the container asks for none", func() {
+		newBndl, err := apply(bndl, "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(newBndl).To(Equal(bndl))
+	})
+
+	It("keeps sysctls already in the bundle, without modifying it", func() {
+		bndl.Spec.Linux.Sysctl = map[string]string{"net.ipv4.tcp_syncookies": "1"}
+
+		newBndl, err := apply(bndl, "net.core.somaxconn=1024")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(newBndl.Spec.Linux.Sysctl).To(HaveKeyWithValue("net.ipv4.tcp_syncookies", "1"))
+		Expect(newBndl.Spec.Linux.Sysctl).To(HaveKeyWithValue("net.core.somaxconn", "1024"))
+		Expect(bndl.Spec.Linux.Sysctl).To(HaveLen(1))
+	})
+
+	DescribeTable("rejects invalid sysctls",
+		func(sysctls, message string) {
+			_, err := apply(bndl, sysctls)
+			Expect(err).To(MatchError(ContainSubstring(message)))
+		},
+		Entry("a sysctl which is not allowed", "kernel.panic=1", "sysctl kernel.panic is not allowed"),
+		Entry("a sysctl which only shares a prefix with one allowed", "net.core.somaxconn_x=1", "is not allowed"),
+		Entry("no value", "net.core.somaxconn", "must be name=value"),
+		Entry("an empty value", "net.core.somaxconn=", "must be name=value"),
+		Entry("a name which climbs out of /proc/sys", "net.ipv4.tcp_keepalive_/../../kernel/panic=1", "name must be"),
+		Entry("a name with an empty part", "net..core=1", "name must be"),
+		Entry("a value with a newline", "net.core.somaxconn=1\n2", "value must be"),
+		Entry("a value which is too long", "net.core.somaxconn="+strings.Repeat("1", 257), "value must be"),
+	)
+
+	Describe("Validate", func() {
+		It("accepts the default allowed sysctls", func() {
+			Expect(bundlerules.Sysctls{Allowed: bundlerules.DefaultAllowedSysctls}.Validate()).To(Succeed())
+		})
+
+		It("rejects sysctls which are not namespaced", func() {
+			Expect(bundlerules.Sysctls{Allowed: []string{"vm.*"}}.Validate()).To(MatchError("sysctl vm.* is not namespaced"))
+		})
+	})
+})
@@ -0,0 +1,167 @@
+package bundlerules
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/goci"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/opencontainers/specs"
+)
+
+// ShmSizeProperty overrides the size of the container's /dev/shm
+const ShmSizeProperty = "garden.shm-size"
+
+// ScratchMountsProperty is a comma-separated list of writable tmpfs mounts
+// to add to the container, each path[:size[:mode]] (e.g.
+// "/tmp:64m,/cache:128m:0755,/var/run")
+const ScratchMountsProperty = "garden.scratch-mounts"
+
+// MountedTmpfsProperty records all of the container's tmpfs mounts,
+// including /dev/shm, each as path:size:mode, so that they are reported in
+// its info
+const MountedTmpfsProperty = gardener.MountedTmpfsProperty
+
+const shmPath = "/dev/shm"
+
+var (
+	tmpfsSize = regexp.MustCompile(`^0*[1-9][0-9]*[kmg]?$`)
+	tmpfsMode = regexp.MustCompile(`^[0-7]{3,4}$`)
+)
+
+// Tmpfs gives the container a size-limited /dev/shm, replacing any in the
+// base bundle, and adds the scratch mounts it asks for
+type Tmpfs struct {
+	DefaultShmSize string
+	DefaultSize    string
+}
+
+func (r Tmpfs) Validate() error {
+	if !tmpfsSize.MatchString(r.DefaultShmSize) {
+		return fmt.Errorf("/dev/shm size %q must be a positive number of bytes with an optional k, m or g suffix", r.DefaultShmSize)
+	}
+
+	if !tmpfsSize.MatchString(r.DefaultSize) {
+		return fmt.Errorf("tmpfs size %q must be a positive number of bytes with an optional k, m or g suffix", r.DefaultSize)
+	}
+
+	return nil
+}
+
+func (r Tmpfs) Apply(bndl *goci.Bndl, spec gardener.DesiredContainerSpec) (*goci.Bndl, error) {
+	mounts, err := r.mounts(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	newBndl := *bndl
+	newBndl.Spec.Mounts = nil
+	for _, m := range bndl.Mounts() {
+		if m.Destination != shmPath {
+			newBndl.Spec.Mounts = append(newBndl.Spec.Mounts, m)
+		}
+	}
+
+	return newBndl.WithMounts(mounts...), nil
+}
+
+// Grants lists the container's tmpfs mounts, so that they are recorded once
+// it has been created
+func (r Tmpfs) Grants(spec gardener.DesiredContainerSpec) garden.Properties {
+	mounts, err := r.mounts(spec)
+	if err != nil {
+		return nil
+	}
+
+	var mounted []string
+	for _, m := range mounts {
+		mounted = append(mounted, describeTmpfs(m))
+	}
+
+	return garden.Properties{MountedTmpfsProperty: strings.Join(mounted, ",")}
+}
+
+func (r Tmpfs) mounts(spec gardener.DesiredContainerSpec) ([]specs.Mount, error) {
+	shmSize := r.DefaultShmSize
+	if size, ok := spec.Properties[ShmSizeProperty]; ok {
+		shmSize = size
+	}
+
+	shm, err := tmpfsMount(shmPath, shmSize, "1777")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", ShmSizeProperty, err)
+	}
+	shm.Options = append([]string{"noexec"}, shm.Options...)
+
+	mounts := []specs.Mount{shm}
+	for _, entry := range list(spec.Properties[ScratchMountsProperty]) {
+		mount, err := parseTmpfsMount(entry, r.DefaultSize)
+		if err != nil {
+			return nil, fmt.Errorf("scratch mount %q: %s", entry, err)
+		}
+
+		if mount.Destination == shmPath {
+			return nil, fmt.Errorf("scratch mount %q: use the %s property to size /dev/shm", entry, ShmSizeProperty)
+		}
+
+		mounts = append(mounts, mount)
+	}
+
+	return mounts, nil
+}
+
+// parseTmpfsMount parses path[:size[:mode]], defaulting the mode to 1777
+func parseTmpfsMount(entry, defaultSize string) (specs.Mount, error) {
+	parts := strings.Split(entry, ":")
+	if len(parts) > 3 {
+		return specs.Mount{}, fmt.Errorf("must be path[:size[:mode]]")
+	}
+
+	path, size, mode := parts[0], defaultSize, "1777"
+	if len(parts) > 1 {
+		size = parts[1]
+	}
+
+	if len(parts) > 2 {
+		mode = parts[2]
+	}
+
+	return tmpfsMount(path, size, mode)
+}
+
+func tmpfsMount(path, size, mode string) (specs.Mount, error) {
+	if !filepath.IsAbs(path) || filepath.Clean(path) == "/" {
+		return specs.Mount{}, fmt.Errorf("path must be absolute and not /")
+	}
+
+	if !tmpfsSize.MatchString(size) {
+		return specs.Mount{}, fmt.Errorf("size must be a positive number of bytes with an optional k, m or g suffix")
+	}
+
+	if !tmpfsMode.MatchString(mode) {
+		return specs.Mount{}, fmt.Errorf("mode must be octal, e.g. 0755")
+	}
+
+	return specs.Mount{
+		Type:        "tmpfs",
+		Source:      "tmpfs",
+		Destination: filepath.Clean(path),
+		Options:     []string{"nosuid", "nodev", "mode=" + mode, "size=" + size},
+	}, nil
+}
+
+func describeTmpfs(m specs.Mount) string {
+	var size, mode string
+	for _, opt := range m.Options {
+		if strings.HasPrefix(opt, "size=") {
+			size = strings.TrimPrefix(opt, "size=")
+		} else if strings.HasPrefix(opt, "mode=") {
+			mode = strings.TrimPrefix(opt, "mode=")
+		}
+	}
+
+	return strings.Join([]string{m.Destination, size, mode}, ":")
+}
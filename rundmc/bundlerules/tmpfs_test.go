@@ -0,0 +1,138 @@
+package bundlerules_test
+
+import (
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/goci"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/rundmc/bundlerules"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"github.com/opencontainers/specs"
+)
+
+var _ = Describe("TmpfsRule", func() {
+	var (
+		rule bundlerules.Tmpfs
+		bndl *goci.Bndl
+	)
+
+	BeforeEach(func() {
+		rule = bundlerules.Tmpfs{
+			DefaultShmSize: "64m",
+			DefaultSize:    "32m",
+		}
+
+		bndl = goci.Bundle().WithMounts(
+			specs.Mount{Type: "proc", Source: "proc", Destination: "/proc"},
+			specs.Mount{Type: "tmpfs", Source: "tmpfs", Destination: "/dev/shm"},
+		)
+	})
+
+	apply := func(props garden.Properties) (*goci.Bndl, error) {
+		return rule.Apply(bndl, gardener.DesiredContainerSpec{Handle: "fred", Properties: props})
+	}
+
+	It("replaces the base /dev/shm with one of the default size", func() {
+		newBndl, err := apply(nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(newBndl.Mounts()).To(ConsistOf(
+			specs.Mount{Type: "proc", Source: "proc", Destination: "/proc"},
+			specs.Mount{Type: "tmpfs", Source: "tmpfs", Destination: "/dev/shm",
+				Options: []string{"noexec", "nosuid", "nodev", "mode=1777", "size=64m"}},
+		))
+	})
+
+	It("adds /dev/shm when the base bundle has none", func() {
+		bndl = goci.Bundle()
+
+		newBndl, err := apply(nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(newBndl.Mounts()).To(HaveLen(1))
+		Expect(newBndl.Mounts()[0].Destination).To(Equal("/dev/shm"))
+	})
+
+	It("lets the container size /dev/shm", func() {
+		newBndl, err := apply(garden.Properties{bundlerules.ShmSizeProperty: "1g"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(newBndl.Mounts()).To(ContainElement(specs.Mount{Type: "tmpfs", Source: "tmpfs", Destination: "/dev/shm",
+			Options: []string{"noexec", "nosuid", "nodev", "mode=1777", "size=1g"}}))
+	})
+
+	It("adds the scratch mounts the container asks for", func() {
+		newBndl, err := apply(garden.Properties{bundlerules.ScratchMountsProperty: "/cache:128m:0755, /tmp:16m, /var/run/"})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(newBndl.Mounts()).To(ContainElement(specs.Mount{Type: "tmpfs", Source: "tmpfs", Destination: "/cache",
+			Options: []string{"nosuid", "nodev", "mode=0755", "size=128m"}}))
+		Expect(newBndl.Mounts()).To(ContainElement(specs.Mount{Type: "tmpfs", Source: "tmpfs", Destination: "/tmp",
+			Options: []string{"nosuid", "nodev", "mode=1777", "size=16m"}}))
+		Expect(newBndl.Mounts()).To(ContainElement(specs.Mount{Type: "tmpfs", Source: "tmpfs", Destination: "/var/run",
+			Options: []string{"nosuid", "nodev", "mode=1777", "size=32m"}}))
+	})
+
+	Describe("Grants", func() {
+		grants := func(props garden.Properties) garden.Properties {
+			return rule.Grants(gardener.DesiredContainerSpec{Handle: "fred", Properties: props})
+		}
+
+		It("lists every tmpfs mount, including /dev/shm", func() {
+			Expect(grants(garden.Properties{bundlerules.ScratchMountsProperty: "/cache:128m:0755,/tmp"})).To(Equal(garden.Properties{
+				bundlerules.MountedTmpfsProperty: "/dev/shm:64m:1777,/cache:128m:0755,/tmp:32m:1777",
+			}))
+		})
+
+		It("lists /dev/shm when there are no scratch mounts", func() {
+			Expect(grants(nil)).To(Equal(garden.Properties{bundlerules.MountedTmpfsProperty: "/dev/shm:64m:1777"}))
+		})
+
+		It("grants nothing when the request is invalid", func() {
+			Expect(grants(garden.Properties{bundlerules.ScratchMountsProperty: "tmp"})).To(BeEmpty())
+		})
+	})
+
+	It("does not modify the original bundle", func() {
+		apply(nil)
+		Expect(bndl.Mounts()[1].Options).To(BeEmpty())
+	})
+
+	DescribeTable("rejects invalid requests",
+		func(props garden.Properties, message string) {
+			_, err := apply(props)
+			Expect(err).To(MatchError(ContainSubstring(message)))
+		},
+		Entry("a bad /dev/shm size", garden.Properties{bundlerules.ShmSizeProperty: "lots"}, "size must be"),
+		Entry("a zero /dev/shm size", garden.Properties{bundlerules.ShmSizeProperty: "0"}, "size must be a positive"),
+		Entry("a relative path", garden.Properties{bundlerules.ScratchMountsProperty: "cache"}, "path must be absolute"),
+		Entry("the root", garden.Properties{bundlerules.ScratchMountsProperty: "/:1m"}, "not /"),
+		Entry("a bad size", garden.Properties{bundlerules.ScratchMountsProperty: "/cache:lots"}, "size must be"),
+		Entry("a negative size", garden.Properties{bundlerules.ScratchMountsProperty: "/cache:-1m"}, "size must be"),
+		Entry("a zero size", garden.Properties{bundlerules.ScratchMountsProperty: "/cache:0"}, "size must be a positive"),
+		Entry("a zero size with a suffix", garden.Properties{bundlerules.ScratchMountsProperty: "/cache:00m"}, "size must be a positive"),
+		Entry("a bad mode", garden.Properties{bundlerules.ScratchMountsProperty: "/cache:1m:rwx"}, "mode must be octal"),
+		Entry("too many fields", garden.Properties{bundlerules.ScratchMountsProperty: "/cache:1m:0755:x"}, "must be path[:size[:mode]]"),
+		Entry("/dev/shm", garden.Properties{bundlerules.ScratchMountsProperty: "/dev/shm:1g"}, bundlerules.ShmSizeProperty),
+	)
+
+	Describe("Validate", func() {
+		It("accepts valid default sizes", func() {
+			Expect(rule.Validate()).To(Succeed())
+		})
+
+		It("rejects a bad default /dev/shm size", func() {
+			rule.DefaultShmSize = "big"
+			Expect(rule.Validate()).To(MatchError(ContainSubstring("/dev/shm size")))
+		})
+
+		It("rejects a zero default size, which would be unlimited", func() {
+			rule.DefaultSize = "0"
+			Expect(rule.Validate()).To(MatchError(ContainSubstring("tmpfs size")))
+		})
+
+		It("rejects a bad default tmpfs size", func() {
+			rule.DefaultSize = ""
+			Expect(rule.Validate()).To(MatchError(ContainSubstring("tmpfs size")))
+		})
+	})
+})
@@ -0,0 +1,430 @@
+package rundmc
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/cloudfoundry-incubator/guardian/rundmc/runrunc"
+	"github.com/pivotal-golang/lager"
+)
+
+// unlimitedV1Memory is the smallest memory.limit_in_bytes treated as no
+// limit; the kernel reports an unlimited cgroup as a page-aligned value near
+// the maximum int64
+const unlimitedV1Memory = 1 << 62
+
+var parentName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// CgroupLimits are the limits shared by all of the containers in a parent
+// cgroup. Zero means no limit.
+type CgroupLimits struct {
+	MemoryLimitInBytes uint64 `json:"memory_limit_in_bytes"`
+	CPUShares          uint64 `json:"cpu_shares"`
+}
+
+// CgroupParents manages the parent cgroups which containers are grouped
+// under, e.g. one for each org. Creating and removing a parent are done
+// under a lock for that parent, so a container being released can't remove
+// the parent from under one which is being prepared.
+type CgroupParents struct {
+	// Root is the cgroup which parents are created in, relative to the root
+	// of each hierarchy (e.g. "garden-parents")
+	Root string
+
+	// CgroupPath is where the v1 hierarchies are mounted, one per controller
+	CgroupPath string
+
+	// UnifiedRoot is where the host mounts the cgroup v2 hierarchy, if it
+	// has one
+	UnifiedRoot string
+
+	// LimitsDir is where each parent's limits are kept, so that they are
+	// applied again when the parent is re-created after its last container
+	// was released, or after a restart
+	LimitsDir string
+
+	BundleLoader runrunc.BundleLoader
+	Retrier      Retrier
+	Logger       lager.Logger
+
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+// Path returns the path of the parent cgroup relative to the root of the
+// hierarchies, without creating it
+func (p *CgroupParents) Path(parent string) (string, error) {
+	return p.cgroup(parent)
+}
+
+// Ensure creates the parent cgroup, with the limits last set for it, if it
+// does not already exist, and returns its path relative to the root of the
+// hierarchies
+func (p *CgroupParents) Ensure(parent string) (string, error) {
+	cgroup, err := p.cgroup(parent)
+	if err != nil {
+		return "", err
+	}
+
+	defer p.lock(parent)()
+	return cgroup, p.ensure(parent, cgroup)
+}
+
+func (p *CgroupParents) ensure(parent, cgroup string) error {
+
+	if p.unified() {
+		for _, cgroup := range []string{path.Join(p.UnifiedRoot, p.Root), path.Join(p.UnifiedRoot, p.Root, parent)} {
+			if err := os.MkdirAll(cgroup, 0755); err != nil {
+				return err
+			}
+
+			if err := enableUnifiedControllers(p.Logger, cgroup); err != nil {
+				return err
+			}
+		}
+	}
+
+	limits, err := p.savedLimits(parent)
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return p.applyLimits(cgroup, limits)
+}
+
+// Prepare creates the parent cgroup of the container in the bundle, if it is
+// in one, just before the container is started. The container's own cgroup
+// is created too, so the parent is not empty, and is not removed by Release,
+// while the runtime starts the container in it.
+func (p *CgroupParents) Prepare(log lager.Logger, bundlePath string) error {
+	bndl, err := p.BundleLoader.Load(bundlePath)
+	if err != nil {
+		return err
+	}
+
+	cgroupsPath := bndl.Spec.Linux.CgroupsPath
+	if cgroupsPath == nil || !strings.HasPrefix(*cgroupsPath, path.Join("/", p.Root)+"/") {
+		return nil
+	}
+
+	parent := path.Base(path.Dir(*cgroupsPath))
+	cgroup, err := p.cgroup(parent)
+	if err != nil {
+		return err
+	}
+
+	defer p.lock(parent)()
+
+	if err := p.ensure(parent, cgroup); err != nil {
+		return err
+	}
+
+	hierarchies, err := p.hierarchies()
+	if err != nil {
+		return err
+	}
+
+	for _, hierarchy := range hierarchies {
+		if err := os.MkdirAll(filepath.Join(hierarchy, *cgroupsPath), 0755); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SetLimits limits the total resources of the containers in the parent. The
+// limits are kept, so they outlive the parent cgroup itself.
+func (p *CgroupParents) SetLimits(parent string, limits CgroupLimits) error {
+	cgroup, err := p.cgroup(parent)
+	if err != nil {
+		return err
+	}
+
+	defer p.lock(parent)()
+
+	if err := p.saveLimits(parent, limits); err != nil {
+		return err
+	}
+
+	return p.ensure(parent, cgroup)
+}
+
+func (p *CgroupParents) applyLimits(cgroup string, limits CgroupLimits) error {
+	memory, cpu := "-1", "1024"
+	if limits.MemoryLimitInBytes > 0 {
+		memory = strconv.FormatUint(limits.MemoryLimitInBytes, 10)
+	}
+	if limits.CPUShares > 0 {
+		cpu = strconv.FormatUint(limits.CPUShares, 10)
+	}
+
+	files := map[string]string{
+		path.Join(p.CgroupPath, "memory", cgroup, "memory.limit_in_bytes"): memory,
+		path.Join(p.CgroupPath, "cpu", cgroup, "cpu.shares"):               cpu,
+	}
+
+	if p.unified() {
+		memory, cpu = "max", "100"
+		if limits.MemoryLimitInBytes > 0 {
+			memory = strconv.FormatUint(limits.MemoryLimitInBytes, 10)
+		}
+		if limits.CPUShares > 0 {
+			cpu = strconv.FormatUint(sharesToWeight(limits.CPUShares), 10)
+		}
+
+		files = map[string]string{
+			path.Join(p.UnifiedRoot, cgroup, "memory.max"): memory,
+			path.Join(p.UnifiedRoot, cgroup, "cpu.weight"): cpu,
+		}
+	}
+
+	for file, value := range files {
+		if err := os.MkdirAll(path.Dir(file), 0755); err != nil {
+			return err
+		}
+
+		if err := ioutil.WriteFile(file, []byte(value), 0644); err != nil {
+			return fmt.Errorf("set %s: %s", path.Base(file), err)
+		}
+	}
+
+	return nil
+}
+
+// Limits returns the parent's limits. On cgroup v2 the CPU shares are
+// converted from the cpu.weight, so may differ slightly from those set. The
+// limits of a parent which has been released are those it will be given when
+// it is re-created.
+func (p *CgroupParents) Limits(parent string) (CgroupLimits, error) {
+	cgroup, err := p.cgroup(parent)
+	if err != nil {
+		return CgroupLimits{}, err
+	}
+
+	limits, err := p.cgroupLimits(cgroup)
+	if os.IsNotExist(err) {
+		if saved, savedErr := p.savedLimits(parent); savedErr == nil {
+			return saved, nil
+		}
+	}
+
+	return limits, err
+}
+
+func (p *CgroupParents) cgroupLimits(cgroup string) (CgroupLimits, error) {
+	if p.unified() {
+		memory, err := readCgroupValue(path.Join(p.UnifiedRoot, cgroup, "memory.max"))
+		if err != nil {
+			return CgroupLimits{}, err
+		}
+
+		weight, err := readCgroupValue(path.Join(p.UnifiedRoot, cgroup, "cpu.weight"))
+		if err != nil {
+			return CgroupLimits{}, err
+		}
+
+		return CgroupLimits{MemoryLimitInBytes: memory, CPUShares: weightToShares(weight)}, nil
+	}
+
+	memory, err := readCgroupValue(path.Join(p.CgroupPath, "memory", cgroup, "memory.limit_in_bytes"))
+	if err != nil {
+		return CgroupLimits{}, err
+	}
+	if memory >= unlimitedV1Memory {
+		memory = 0
+	}
+
+	shares, err := readCgroupValue(path.Join(p.CgroupPath, "cpu", cgroup, "cpu.shares"))
+	if err != nil {
+		return CgroupLimits{}, err
+	}
+
+	return CgroupLimits{MemoryLimitInBytes: memory, CPUShares: shares}, nil
+}
+
+// Release removes the cgroup of the container in the bundle, once its
+// processes have exited, and then its parent if the container was the last
+// one in it
+func (p *CgroupParents) Release(log lager.Logger, bundlePath string) error {
+	bndl, err := p.BundleLoader.Load(bundlePath)
+	if err != nil {
+		return err
+	}
+
+	cgroupsPath := bndl.Spec.Linux.CgroupsPath
+	if cgroupsPath == nil || !strings.HasPrefix(*cgroupsPath, path.Join("/", p.Root)+"/") {
+		return nil
+	}
+
+	log = log.Session("release-cgroup", lager.Data{"cgroup": *cgroupsPath})
+
+	defer p.lock(path.Base(path.Dir(*cgroupsPath)))()
+
+	hierarchies, err := p.hierarchies()
+	if err != nil {
+		return err
+	}
+
+	for _, hierarchy := range hierarchies {
+		container := filepath.Join(hierarchy, *cgroupsPath)
+		if err := p.Retrier.Run(func() error { return removeCgroup(container) }); err != nil {
+			log.Error("remove-container-cgroup-failed", err)
+			return err
+		}
+
+		// the parent is busy while it still has other containers in it
+		parent := filepath.Dir(container)
+		if err := removeCgroup(parent); err != nil && !cgroupBusy(err) {
+			log.Error("remove-parent-cgroup-failed", err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// lock locks the parent, returning a function which unlocks it
+func (p *CgroupParents) lock(parent string) func() {
+	p.mu.Lock()
+	if p.locks == nil {
+		p.locks = make(map[string]*sync.Mutex)
+	}
+
+	lock, ok := p.locks[parent]
+	if !ok {
+		lock = new(sync.Mutex)
+		p.locks[parent] = lock
+	}
+	p.mu.Unlock()
+
+	lock.Lock()
+	return lock.Unlock
+}
+
+func (p *CgroupParents) saveLimits(parent string, limits CgroupLimits) error {
+	if err := os.MkdirAll(p.LimitsDir, 0700); err != nil {
+		return err
+	}
+
+	contents, err := json.Marshal(limits)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(p.limitsFile(parent), contents, 0600)
+}
+
+func (p *CgroupParents) savedLimits(parent string) (CgroupLimits, error) {
+	contents, err := ioutil.ReadFile(p.limitsFile(parent))
+	if err != nil {
+		return CgroupLimits{}, err
+	}
+
+	var limits CgroupLimits
+	if err := json.Unmarshal(contents, &limits); err != nil {
+		return CgroupLimits{}, fmt.Errorf("parse limits of cgroup parent %s: %s", parent, err)
+	}
+
+	return limits, nil
+}
+
+func (p *CgroupParents) limitsFile(parent string) string {
+	return filepath.Join(p.LimitsDir, parent+".json")
+}
+
+func (p *CgroupParents) cgroup(parent string) (string, error) {
+	if !parentName.MatchString(parent) {
+		return "", fmt.Errorf("invalid cgroup parent %q: must be letters, numbers, '.', '_' or '-'", parent)
+	}
+
+	return path.Join("/", p.Root, parent), nil
+}
+
+func (p *CgroupParents) unified() bool {
+	return isUnified(p.UnifiedRoot)
+}
+
+func (p *CgroupParents) hierarchies() ([]string, error) {
+	if p.unified() {
+		return []string{p.UnifiedRoot}, nil
+	}
+
+	entries, err := ioutil.ReadDir(p.CgroupPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var hierarchies []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			hierarchies = append(hierarchies, filepath.Join(p.CgroupPath, entry.Name()))
+		}
+	}
+
+	return hierarchies, nil
+}
+
+func removeCgroup(cgroup string) error {
+	if err := os.Remove(cgroup); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+func cgroupBusy(err error) bool {
+	if pathErr, ok := err.(*os.PathError); ok {
+		return pathErr.Err == syscall.EBUSY || pathErr.Err == syscall.ENOTEMPTY
+	}
+
+	return false
+}
+
+func readCgroupValue(file string) (uint64, error) {
+	contents, err := ioutil.ReadFile(file)
+	if err != nil {
+		return 0, err
+	}
+
+	value := strings.TrimSpace(string(contents))
+	if value == "max" {
+		return 0, nil
+	}
+
+	return strconv.ParseUint(value, 10, 64)
+}
+
+// sharesToWeight converts cgroup v1 cpu.shares, from 2 to 262144, to a v2
+// cpu.weight, from 1 to 10000, the same way runc does
+func sharesToWeight(shares uint64) uint64 {
+	if shares < 2 {
+		shares = 2
+	}
+	if shares > 262144 {
+		shares = 262144
+	}
+
+	return 1 + ((shares-2)*9999)/262142
+}
+
+func weightToShares(weight uint64) uint64 {
+	if weight < 1 {
+		weight = 1
+	}
+
+	return 2 + ((weight-1)*262142)/9999
+}
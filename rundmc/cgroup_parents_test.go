@@ -0,0 +1,280 @@
+package rundmc_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path"
+
+	"github.com/cloudfoundry-incubator/goci"
+	"github.com/cloudfoundry-incubator/guardian/rundmc"
+	"github.com/cloudfoundry-incubator/guardian/rundmc/fakes"
+	runruncfakes "github.com/cloudfoundry-incubator/guardian/rundmc/runrunc/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/lager/lagertest"
+)
+
+var _ = Describe("CgroupParents", func() {
+	var (
+		tmpDir           string
+		cgroupPath       string
+		fakeBundleLoader *runruncfakes.FakeBundleLoader
+		fakeRetrier      *fakes.FakeRetrier
+		parents          *rundmc.CgroupParents
+	)
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "cgroup-parents")
+		Expect(err).NotTo(HaveOccurred())
+
+		cgroupPath = path.Join(tmpDir, "cgroup")
+		Expect(os.MkdirAll(path.Join(cgroupPath, "memory"), 0755)).To(Succeed())
+		Expect(os.MkdirAll(path.Join(cgroupPath, "cpu"), 0755)).To(Succeed())
+
+		fakeBundleLoader = new(runruncfakes.FakeBundleLoader)
+		fakeRetrier = new(fakes.FakeRetrier)
+		fakeRetrier.RunStub = func(fn func() error) error {
+			return fn()
+		}
+
+		parents = &rundmc.CgroupParents{
+			Root:         "garden-parents",
+			CgroupPath:   cgroupPath,
+			LimitsDir:    path.Join(tmpDir, "limits"),
+			BundleLoader: fakeBundleLoader,
+			Retrier:      fakeRetrier,
+			Logger:       lagertest.NewTestLogger("test"),
+		}
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tmpDir)
+	})
+
+	Describe("Path", func() {
+		It("returns the path of the parent without creating it", func() {
+			Expect(parents.Path("some-org")).To(Equal("/garden-parents/some-org"))
+			Expect(path.Join(cgroupPath, "memory", "garden-parents", "some-org")).NotTo(BeADirectory())
+		})
+
+		It("rejects names which are not a single path component", func() {
+			_, err := parents.Path("../escape")
+			Expect(err).To(MatchError(ContainSubstring(`invalid cgroup parent "../escape"`)))
+		})
+	})
+
+	Describe("Ensure", func() {
+		It("returns the path of the parent, relative to the root of the hierarchies", func() {
+			Expect(parents.Ensure("some-org")).To(Equal("/garden-parents/some-org"))
+		})
+
+		It("applies the limits last set for the parent when it is re-created", func() {
+			Expect(parents.SetLimits("some-org", rundmc.CgroupLimits{MemoryLimitInBytes: 1024, CPUShares: 512})).To(Succeed())
+			Expect(os.RemoveAll(path.Join(cgroupPath, "memory", "garden-parents"))).To(Succeed())
+			Expect(os.RemoveAll(path.Join(cgroupPath, "cpu", "garden-parents"))).To(Succeed())
+
+			_, err := parents.Ensure("some-org")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(ioutil.ReadFile(path.Join(cgroupPath, "memory", "garden-parents", "some-org", "memory.limit_in_bytes"))).To(Equal([]byte("1024")))
+			Expect(ioutil.ReadFile(path.Join(cgroupPath, "cpu", "garden-parents", "some-org", "cpu.shares"))).To(Equal([]byte("512")))
+		})
+
+		It("rejects names which are not a single path component", func() {
+			_, err := parents.Ensure("../escape")
+			Expect(err).To(MatchError(ContainSubstring(`invalid cgroup parent "../escape"`)))
+		})
+
+		Context("when the host uses the unified hierarchy", func() {
+			var unifiedRoot string
+
+			BeforeEach(func() {
+				unifiedRoot = path.Join(tmpDir, "unified")
+				for _, dir := range []string{unifiedRoot, path.Join(unifiedRoot, "garden-parents"), path.Join(unifiedRoot, "garden-parents", "some-org")} {
+					Expect(os.MkdirAll(dir, 0755)).To(Succeed())
+					Expect(ioutil.WriteFile(path.Join(dir, "cgroup.controllers"), []byte("cpu memory"), 0644)).To(Succeed())
+				}
+
+				parents.UnifiedRoot = unifiedRoot
+			})
+
+			It("delegates the controllers to the parent and to its containers", func() {
+				_, err := parents.Ensure("some-org")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(ioutil.ReadFile(path.Join(unifiedRoot, "garden-parents", "cgroup.subtree_control"))).To(Equal([]byte("+cpu +memory")))
+				Expect(ioutil.ReadFile(path.Join(unifiedRoot, "garden-parents", "some-org", "cgroup.subtree_control"))).To(Equal([]byte("+cpu +memory")))
+			})
+
+			It("sets the limits in the v2 files, converting the shares to a weight", func() {
+				Expect(parents.SetLimits("some-org", rundmc.CgroupLimits{MemoryLimitInBytes: 1024, CPUShares: 262144})).To(Succeed())
+
+				Expect(ioutil.ReadFile(path.Join(unifiedRoot, "garden-parents", "some-org", "memory.max"))).To(Equal([]byte("1024")))
+				Expect(ioutil.ReadFile(path.Join(unifiedRoot, "garden-parents", "some-org", "cpu.weight"))).To(Equal([]byte("10000")))
+
+				Expect(parents.Limits("some-org")).To(Equal(rundmc.CgroupLimits{MemoryLimitInBytes: 1024, CPUShares: 262144}))
+			})
+
+			It("reports no memory limit as zero", func() {
+				Expect(parents.SetLimits("some-org", rundmc.CgroupLimits{})).To(Succeed())
+				Expect(ioutil.ReadFile(path.Join(unifiedRoot, "garden-parents", "some-org", "memory.max"))).To(Equal([]byte("max")))
+
+				limits, err := parents.Limits("some-org")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(limits.MemoryLimitInBytes).To(BeZero())
+			})
+		})
+	})
+
+	Describe("SetLimits", func() {
+		It("writes the limits to the parent's v1 cgroups", func() {
+			Expect(parents.SetLimits("some-org", rundmc.CgroupLimits{MemoryLimitInBytes: 1024, CPUShares: 512})).To(Succeed())
+
+			Expect(ioutil.ReadFile(path.Join(cgroupPath, "memory", "garden-parents", "some-org", "memory.limit_in_bytes"))).To(Equal([]byte("1024")))
+			Expect(ioutil.ReadFile(path.Join(cgroupPath, "cpu", "garden-parents", "some-org", "cpu.shares"))).To(Equal([]byte("512")))
+		})
+
+		It("removes the limits when they are zero", func() {
+			Expect(parents.SetLimits("some-org", rundmc.CgroupLimits{})).To(Succeed())
+
+			Expect(ioutil.ReadFile(path.Join(cgroupPath, "memory", "garden-parents", "some-org", "memory.limit_in_bytes"))).To(Equal([]byte("-1")))
+			Expect(ioutil.ReadFile(path.Join(cgroupPath, "cpu", "garden-parents", "some-org", "cpu.shares"))).To(Equal([]byte("1024")))
+		})
+	})
+
+	Describe("Limits", func() {
+		It("reads the limits from the parent's v1 cgroups, treating the kernel's maximum as no limit", func() {
+			Expect(os.MkdirAll(path.Join(cgroupPath, "memory", "garden-parents", "some-org"), 0755)).To(Succeed())
+			Expect(os.MkdirAll(path.Join(cgroupPath, "cpu", "garden-parents", "some-org"), 0755)).To(Succeed())
+			Expect(ioutil.WriteFile(path.Join(cgroupPath, "memory", "garden-parents", "some-org", "memory.limit_in_bytes"), []byte("9223372036854771712\n"), 0644)).To(Succeed())
+			Expect(ioutil.WriteFile(path.Join(cgroupPath, "cpu", "garden-parents", "some-org", "cpu.shares"), []byte("512\n"), 0644)).To(Succeed())
+
+			Expect(parents.Limits("some-org")).To(Equal(rundmc.CgroupLimits{CPUShares: 512}))
+		})
+
+		It("returns an error when the parent does not exist", func() {
+			_, err := parents.Limits("missing-org")
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+
+		It("returns the limits a released parent will be re-created with", func() {
+			Expect(parents.SetLimits("some-org", rundmc.CgroupLimits{MemoryLimitInBytes: 1024, CPUShares: 512})).To(Succeed())
+			Expect(os.RemoveAll(path.Join(cgroupPath, "memory", "garden-parents"))).To(Succeed())
+
+			Expect(parents.Limits("some-org")).To(Equal(rundmc.CgroupLimits{MemoryLimitInBytes: 1024, CPUShares: 512}))
+		})
+	})
+
+	Describe("Prepare", func() {
+		bundleInCgroup := func(cgroupsPath string) {
+			bndl := goci.Bundle()
+			bndl.Spec.Linux.CgroupsPath = &cgroupsPath
+			fakeBundleLoader.LoadReturns(bndl, nil)
+		}
+
+		BeforeEach(func() {
+			Expect(parents.SetLimits("some-org", rundmc.CgroupLimits{MemoryLimitInBytes: 1024})).To(Succeed())
+			Expect(os.RemoveAll(path.Join(cgroupPath, "memory", "garden-parents"))).To(Succeed())
+		})
+
+		It("creates the container's parent with its limits", func() {
+			bundleInCgroup("/garden-parents/some-org/some-handle")
+
+			Expect(parents.Prepare(lagertest.NewTestLogger("test"), "/path/to/bundle")).To(Succeed())
+			Expect(fakeBundleLoader.LoadArgsForCall(0)).To(Equal("/path/to/bundle"))
+			Expect(ioutil.ReadFile(path.Join(cgroupPath, "memory", "garden-parents", "some-org", "memory.limit_in_bytes"))).To(Equal([]byte("1024")))
+		})
+
+		It("creates the container's cgroup in every hierarchy, so the parent is not removed while the container starts", func() {
+			bundleInCgroup("/garden-parents/some-org/some-handle")
+
+			Expect(parents.Prepare(lagertest.NewTestLogger("test"), "/path/to/bundle")).To(Succeed())
+			for _, hierarchy := range []string{"memory", "cpu"} {
+				Expect(path.Join(cgroupPath, hierarchy, "garden-parents", "some-org", "some-handle")).To(BeADirectory())
+			}
+
+			bundleInCgroup("/garden-parents/some-org/other-handle")
+			Expect(parents.Release(lagertest.NewTestLogger("test"), "/path/to/other-bundle")).To(Succeed())
+			Expect(ioutil.ReadFile(path.Join(cgroupPath, "memory", "garden-parents", "some-org", "memory.limit_in_bytes"))).To(Equal([]byte("1024")))
+		})
+
+		It("leaves containers which are not in a parent alone", func() {
+			bundleInCgroup("/some-handle")
+
+			Expect(parents.Prepare(lagertest.NewTestLogger("test"), "/path/to/bundle")).To(Succeed())
+			Expect(path.Join(cgroupPath, "memory", "garden-parents")).NotTo(BeADirectory())
+		})
+
+		It("returns an error when the bundle cannot be loaded", func() {
+			fakeBundleLoader.LoadReturns(nil, errors.New("no bundle"))
+			Expect(parents.Prepare(lagertest.NewTestLogger("test"), "/path/to/bundle")).To(MatchError("no bundle"))
+		})
+	})
+
+	Describe("Release", func() {
+		bundleInCgroup := func(cgroupsPath string) {
+			bndl := goci.Bundle()
+			bndl.Spec.Linux.CgroupsPath = &cgroupsPath
+			fakeBundleLoader.LoadReturns(bndl, nil)
+		}
+
+		BeforeEach(func() {
+			for _, hierarchy := range []string{"memory", "cpu"} {
+				Expect(os.MkdirAll(path.Join(cgroupPath, hierarchy, "garden-parents", "some-org", "some-handle"), 0755)).To(Succeed())
+			}
+
+			bundleInCgroup("/garden-parents/some-org/some-handle")
+		})
+
+		It("loads the container's bundle", func() {
+			Expect(parents.Release(lagertest.NewTestLogger("test"), "/path/to/bundle")).To(Succeed())
+			Expect(fakeBundleLoader.LoadArgsForCall(0)).To(Equal("/path/to/bundle"))
+		})
+
+		It("removes the container's cgroup and its empty parent from every hierarchy", func() {
+			Expect(parents.Release(lagertest.NewTestLogger("test"), "/path/to/bundle")).To(Succeed())
+
+			for _, hierarchy := range []string{"memory", "cpu"} {
+				Expect(path.Join(cgroupPath, hierarchy, "garden-parents", "some-org")).NotTo(BeADirectory())
+				Expect(path.Join(cgroupPath, hierarchy, "garden-parents")).To(BeADirectory())
+			}
+		})
+
+		It("keeps the parent while other containers are in it", func() {
+			Expect(os.MkdirAll(path.Join(cgroupPath, "memory", "garden-parents", "some-org", "other-handle"), 0755)).To(Succeed())
+
+			Expect(parents.Release(lagertest.NewTestLogger("test"), "/path/to/bundle")).To(Succeed())
+			Expect(path.Join(cgroupPath, "memory", "garden-parents", "some-org", "some-handle")).NotTo(BeADirectory())
+			Expect(path.Join(cgroupPath, "memory", "garden-parents", "some-org", "other-handle")).To(BeADirectory())
+		})
+
+		It("succeeds when the container's cgroup has already been removed", func() {
+			Expect(os.RemoveAll(path.Join(cgroupPath, "cpu", "garden-parents", "some-org", "some-handle"))).To(Succeed())
+			Expect(parents.Release(lagertest.NewTestLogger("test"), "/path/to/bundle")).To(Succeed())
+			Expect(path.Join(cgroupPath, "cpu", "garden-parents", "some-org")).NotTo(BeADirectory())
+		})
+
+		It("retries removing the container's cgroup until its processes have exited", func() {
+			Expect(parents.Release(lagertest.NewTestLogger("test"), "/path/to/bundle")).To(Succeed())
+			Expect(fakeRetrier.RunCallCount()).To(Equal(2))
+		})
+
+		It("returns an error when the container's cgroup cannot be removed", func() {
+			fakeRetrier.RunReturns(errors.New("device or resource busy"))
+			Expect(parents.Release(lagertest.NewTestLogger("test"), "/path/to/bundle")).To(MatchError("device or resource busy"))
+		})
+
+		It("leaves containers which are not in a parent alone", func() {
+			bundleInCgroup("/some-handle")
+			Expect(parents.Release(lagertest.NewTestLogger("test"), "/path/to/bundle")).To(Succeed())
+			Expect(path.Join(cgroupPath, "memory", "garden-parents", "some-org", "some-handle")).To(BeADirectory())
+		})
+
+		It("returns an error when the bundle cannot be loaded", func() {
+			fakeBundleLoader.LoadReturns(nil, errors.New("no bundle"))
+			Expect(parents.Release(lagertest.NewTestLogger("test"), "/path/to/bundle")).To(MatchError("no bundle"))
+		})
+	})
+})
@@ -18,9 +18,11 @@ import (
 //go:generate counterfeiter . Checker
 //go:generate counterfeiter . BundleRunner
 //go:generate counterfeiter . NstarRunner
+//go:generate counterfeiter . StreamInAdmitter
 //go:generate counterfeiter . ContainerStater
 //go:generate counterfeiter . EventStore
 //go:generate counterfeiter . Retrier
+//go:generate counterfeiter . CgroupManager
 
 type Depot interface {
 	Create(log lager.Logger, handle string, bundle depot.BundleSaver) error
@@ -30,11 +32,12 @@ type Depot interface {
 }
 
 type BundleGenerator interface {
-	Generate(spec gardener.DesiredContainerSpec) *goci.Bndl
+	Generate(spec gardener.DesiredContainerSpec) (*goci.Bndl, error)
+	Grants(spec gardener.DesiredContainerSpec) garden.Properties
 }
 
 type Checker interface {
-	Check(log lager.Logger, output io.Reader) error
+	Check(log lager.Logger, bundlePath string, start StartFunc) error
 }
 
 type ContainerStater interface {
@@ -45,6 +48,8 @@ type BundleRunner interface {
 	Start(log lager.Logger, bundlePath, id string, io garden.ProcessIO) (garden.Process, error)
 	Exec(log lager.Logger, id, bundlePath string, spec garden.ProcessSpec, io garden.ProcessIO) (garden.Process, error)
 	Kill(log lager.Logger, bundlePath string) error
+	Pause(log lager.Logger, handle string) error
+	Resume(log lager.Logger, handle string) error
 	Watch(log lager.Logger, id string, notifier runrunc.Notifier) error
 }
 
@@ -53,6 +58,11 @@ type NstarRunner interface {
 	StreamOut(log lager.Logger, pid int, path string, user string) (io.ReadCloser, error)
 }
 
+type StreamInAdmitter interface {
+	Admit(log lager.Logger, handle, bundlePath string, tarStream io.Reader) (io.Reader, func(extractErr error) error)
+	Forget(handle string)
+}
+
 type EventStore interface {
 	OnEvent(id string, event string)
 	Events(id string) []string
@@ -62,28 +72,72 @@ type Retrier interface {
 	Run(fn func() error) error
 }
 
+type CgroupManager interface {
+	Prepare(log lager.Logger, bundlePath string) error
+	Release(log lager.Logger, bundlePath string) error
+}
+
+// PausedPolicy says what happens when a process is run in, or files are
+// streamed in to or out of, a paused container
+type PausedPolicy int
+
+const (
+	// FailWhenPaused refuses to touch a paused container
+	FailWhenPaused PausedPolicy = iota
+
+	// ResumeWhenPaused resumes the container first
+	ResumeWhenPaused
+)
+
 // Containerizer knows how to manage a depot of container bundles
 type Containerizer struct {
 	depot        Depot
+	loader       depot.BundleLoader
 	bundler      BundleGenerator
 	runner       BundleRunner
 	startChecker Checker
 	stateChecker ContainerStater
 	nstar        NstarRunner
+	admitter     StreamInAdmitter
 	events       EventStore
 	retrier      Retrier
+	cgroups      CgroupManager
+	properties   Properties
+	pausedPolicy PausedPolicy
 }
 
-func New(depot Depot, bundler BundleGenerator, runner BundleRunner, startChecker Checker, stateChecker ContainerStater, nstarRunner NstarRunner, events EventStore, retrier Retrier) *Containerizer {
+// Config holds the collaborators of a Containerizer
+type Config struct {
+	Depot        Depot
+	Loader       depot.BundleLoader
+	Bundler      BundleGenerator
+	Runner       BundleRunner
+	StartChecker Checker
+	StateChecker ContainerStater
+	Nstar        NstarRunner
+	Admitter     StreamInAdmitter
+	Events       EventStore
+	Retrier      Retrier
+	Cgroups      CgroupManager
+	Properties   Properties
+	PausedPolicy PausedPolicy
+}
+
+func New(config Config) *Containerizer {
 	return &Containerizer{
-		depot:        depot,
-		bundler:      bundler,
-		runner:       runner,
-		startChecker: startChecker,
-		stateChecker: stateChecker,
-		nstar:        nstarRunner,
-		events:       events,
-		retrier:      retrier,
+		depot:        config.Depot,
+		loader:       config.Loader,
+		bundler:      config.Bundler,
+		runner:       config.Runner,
+		startChecker: config.StartChecker,
+		stateChecker: config.StateChecker,
+		nstar:        config.Nstar,
+		admitter:     config.Admitter,
+		events:       config.Events,
+		retrier:      config.Retrier,
+		cgroups:      config.Cgroups,
+		properties:   config.Properties,
+		pausedPolicy: config.PausedPolicy,
 	}
 }
 
@@ -94,7 +148,13 @@ func (c *Containerizer) Create(log lager.Logger, spec gardener.DesiredContainerS
 	log.Info("started")
 	defer log.Info("finished")
 
-	if err := c.depot.Create(log, spec.Handle, c.bundler.Generate(spec)); err != nil {
+	bndl, err := c.bundler.Generate(spec)
+	if err != nil {
+		log.Error("generate-bundle-failed", err)
+		return err
+	}
+
+	if err := c.depot.Create(log, spec.Handle, bndl); err != nil {
 		log.Error("create-failed", err)
 		return err
 	}
@@ -105,18 +165,19 @@ func (c *Containerizer) Create(log lager.Logger, spec gardener.DesiredContainerS
 		return err
 	}
 
-	stdoutR, stdoutW := io.Pipe()
-	_, err = c.runner.Start(log, path, spec.Handle, garden.ProcessIO{
-		Stdout: io.MultiWriter(logging.Writer(log), stdoutW),
-		Stderr: logging.Writer(log),
-	})
-	if err != nil {
-		log.Error("start", err)
+	if err := c.cgroups.Prepare(log, path); err != nil {
+		log.Error("prepare-cgroup-failed", err)
 		return err
 	}
 
-	if err := c.startChecker.Check(log, stdoutR); err != nil {
-		log.Error("check", err)
+	err = c.startChecker.Check(log, path, func(stdout, stderr io.Writer) (garden.Process, error) {
+		return c.runner.Start(log, path, spec.Handle, garden.ProcessIO{
+			Stdout: io.MultiWriter(logging.Writer(log), stdout),
+			Stderr: io.MultiWriter(logging.Writer(log), stderr),
+		})
+	})
+	if err != nil {
+		log.Error("start", err)
 		return err
 	}
 
@@ -125,6 +186,10 @@ func (c *Containerizer) Create(log lager.Logger, spec gardener.DesiredContainerS
 		return fmt.Errorf("create: state file not found for container: %s", err)
 	}
 
+	for name, value := range c.bundler.Grants(spec) {
+		c.properties.Set(spec.Handle, name, value)
+	}
+
 	go func() {
 		if err := c.runner.Watch(log, spec.Handle, c.events); err != nil {
 			log.Error("watch-failed", err)
@@ -147,6 +212,14 @@ func (c *Containerizer) Run(log lager.Logger, handle string, spec garden.Process
 		return nil, err
 	}
 
+	// a container without a state can't be paused, and 'runc exec' reports
+	// why it can't run the process better than we can
+	if state, err := c.stateChecker.State(log, handle); err == nil {
+		if err := c.whenPaused(log, handle, state); err != nil {
+			return nil, err
+		}
+	}
+
 	return c.runner.Exec(log, path, handle, spec, io)
 }
 
@@ -163,9 +236,28 @@ func (c *Containerizer) StreamIn(log lager.Logger, handle string, spec garden.St
 		return fmt.Errorf("stream-in: pid not found for container")
 	}
 
-	if err := c.nstar.StreamIn(log, state.Pid, spec.Path, spec.User, spec.TarStream); err != nil {
-		log.Error("nstar-failed", err)
-		return fmt.Errorf("stream-in: nstar: %s", err)
+	if err := c.whenPaused(log, handle, state); err != nil {
+		return fmt.Errorf("stream-in: %s", err)
+	}
+
+	bundlePath, err := c.depot.Lookup(log, handle)
+	if err != nil {
+		log.Error("lookup-failed", err)
+		return fmt.Errorf("stream-in: %s", err)
+	}
+
+	stream, admitted := c.admitter.Admit(log, handle, bundlePath, spec.TarStream)
+	nstarErr := c.nstar.StreamIn(log, state.Pid, spec.Path, spec.User, stream)
+
+	// a rejected archive also fails nstar, but the rejection is the real reason
+	if err := admitted(nstarErr); err != nil {
+		log.Error("admit-failed", err)
+		return fmt.Errorf("stream-in: %s", err)
+	}
+
+	if nstarErr != nil {
+		log.Error("nstar-failed", nstarErr)
+		return fmt.Errorf("stream-in: nstar: %s", nstarErr)
 	}
 
 	return nil
@@ -184,6 +276,10 @@ func (c *Containerizer) StreamOut(log lager.Logger, handle string, spec garden.S
 		return nil, fmt.Errorf("stream-out: pid not found for container")
 	}
 
+	if err := c.whenPaused(log, handle, state); err != nil {
+		return nil, fmt.Errorf("stream-out: %s", err)
+	}
+
 	stream, err := c.nstar.StreamOut(log, state.Pid, spec.Path, spec.User)
 	if err != nil {
 		log.Error("nstar-failed", err)
@@ -193,17 +289,20 @@ func (c *Containerizer) StreamOut(log lager.Logger, handle string, spec garden.S
 	return stream, nil
 }
 
-// Destroy kills any container processes and deletes the bundle directory
+// Destroy kills any container processes, releases the container's cgroup and
+// deletes the bundle directory
 func (c *Containerizer) Destroy(log lager.Logger, handle string) error {
 	log = log.Session("destroy", lager.Data{"handle": handle})
 
 	log.Info("started")
 	defer log.Info("finished")
 
-	_, err := c.stateChecker.State(log, handle)
+	defer c.admitter.Forget(handle)
+
+	state, err := c.stateChecker.State(log, handle)
 	if err != nil {
 		log.Error("pid-gone-skip-kill", err)
-		return c.depot.Destroy(log, handle)
+		return c.destroy(log, handle)
 	}
 
 	if err := c.runner.Kill(log, handle); err != nil {
@@ -211,26 +310,119 @@ func (c *Containerizer) Destroy(log lager.Logger, handle string) error {
 		return err
 	}
 
+	// frozen processes only die once they are thawed
+	if state.Paused {
+		if err := c.runner.Resume(log, handle); err != nil {
+			log.Error("resume-failed", err)
+			return err
+		}
+	}
+
+	return c.destroy(log, handle)
+}
+
+func (c *Containerizer) destroy(log lager.Logger, handle string) error {
+	// the bundle says which cgroup the container is in, so is needed until
+	// the cgroup is released; failing to release it only leaks the cgroup
+	if bundlePath, err := c.depot.Lookup(log, handle); err == nil {
+		if err := c.cgroups.Release(log, bundlePath); err != nil {
+			log.Error("release-cgroup-failed", err)
+		}
+	}
+
 	return c.depot.Destroy(log, handle)
 }
 
+// Pause freezes the container's processes until it is resumed
+func (c *Containerizer) Pause(log lager.Logger, handle string) error {
+	log = log.Session("pause", lager.Data{"handle": handle})
+
+	log.Info("started")
+	defer log.Info("finished")
+
+	if err := c.runner.Pause(log, handle); err != nil {
+		log.Error("pause-failed", err)
+		return err
+	}
+
+	return nil
+}
+
+// Resume thaws the processes of a paused container
+func (c *Containerizer) Resume(log lager.Logger, handle string) error {
+	log = log.Session("resume", lager.Data{"handle": handle})
+
+	log.Info("started")
+	defer log.Info("finished")
+
+	if err := c.runner.Resume(log, handle); err != nil {
+		log.Error("resume-failed", err)
+		return err
+	}
+
+	return nil
+}
+
 func (c *Containerizer) Info(log lager.Logger, handle string) (gardener.ActualContainerSpec, error) {
 	bundlePath, err := c.depot.Lookup(log, handle)
 	if err != nil {
 		return gardener.ActualContainerSpec{}, err
 	}
 
+	bndl, err := c.loader.Load(bundlePath)
+	if err != nil {
+		return gardener.ActualContainerSpec{}, err
+	}
+
+	// a container which isn't running can't be paused
+	state, err := c.stateChecker.State(log, handle)
+	paused := err == nil && state.Paused
+
 	return gardener.ActualContainerSpec{
 		BundlePath: bundlePath,
+		Paused:     paused,
 		Events:     c.events.Events(handle),
+		CPUSet:     cpuSet(bndl),
 	}, nil
 }
 
+func cpuSet(bndl *goci.Bndl) gardener.CPUSet {
+	var cpuset gardener.CPUSet
+	if bndl.Spec.Linux.Resources == nil || bndl.Spec.Linux.Resources.CPU == nil {
+		return cpuset
+	}
+
+	if cpus := bndl.Spec.Linux.Resources.CPU.Cpus; cpus != nil {
+		cpuset.CPUs = *cpus
+	}
+
+	if mems := bndl.Spec.Linux.Resources.CPU.Mems; mems != nil {
+		cpuset.Mems = *mems
+	}
+
+	return cpuset
+}
+
 // Handles returns a list of all container handles
 func (c *Containerizer) Handles() ([]string, error) {
 	return c.depot.Handles()
 }
 
+// whenPaused fails, or resumes the container, if it is paused, according to
+// the paused policy
+func (c *Containerizer) whenPaused(log lager.Logger, handle string, state State) error {
+	if !state.Paused {
+		return nil
+	}
+
+	if c.pausedPolicy != ResumeWhenPaused {
+		return fmt.Errorf("container %s is paused", handle)
+	}
+
+	log.Info("resuming-paused-container")
+	return c.Resume(log, handle)
+}
+
 func (c *Containerizer) waitForStateJSON(log lager.Logger, handle string) error {
 	return c.retrier.Run(func() error {
 		_, err := c.stateChecker.State(log, handle)
@@ -3,6 +3,7 @@ package rundmc_test
 import (
 	"errors"
 	"io"
+	"io/ioutil"
 	"os"
 	"time"
 
@@ -10,11 +11,13 @@ import (
 	"github.com/cloudfoundry-incubator/goci"
 	"github.com/cloudfoundry-incubator/guardian/gardener"
 	"github.com/cloudfoundry-incubator/guardian/rundmc"
+	depotfakes "github.com/cloudfoundry-incubator/guardian/rundmc/depot/fakes"
 	"github.com/cloudfoundry-incubator/guardian/rundmc/fakes"
 	"github.com/cloudfoundry-incubator/guardian/rundmc/runrunc"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/onsi/gomega/gbytes"
+	"github.com/opencontainers/specs"
 	"github.com/pivotal-golang/lager"
 	"github.com/pivotal-golang/lager/lagertest"
 )
@@ -22,24 +25,39 @@ import (
 var _ = Describe("Rundmc", func() {
 	var (
 		fakeDepot           *fakes.FakeDepot
+		fakeBundleLoader    *depotfakes.FakeBundleLoader
 		fakeBundler         *fakes.FakeBundleGenerator
 		fakeContainerRunner *fakes.FakeBundleRunner
 		fakeStartChecker    *fakes.FakeChecker
 		fakeNstarRunner     *fakes.FakeNstarRunner
+		fakeAdmitter        *fakes.FakeStreamInAdmitter
 		fakeStater          *fakes.FakeContainerStater
 		fakeEventStore      *fakes.FakeEventStore
 		fakeRetrier         *fakes.FakeRetrier
+		fakeCgroupManager   *fakes.FakeCgroupManager
+		fakeProperties      *fakes.FakeProperties
 
 		logger        lager.Logger
+		config        rundmc.Config
 		containerizer *rundmc.Containerizer
 	)
 
 	BeforeEach(func() {
 		fakeDepot = new(fakes.FakeDepot)
+		fakeBundleLoader = new(depotfakes.FakeBundleLoader)
+		fakeBundleLoader.LoadReturns(goci.Bundle(), nil)
 		fakeContainerRunner = new(fakes.FakeBundleRunner)
 		fakeStartChecker = new(fakes.FakeChecker)
+		fakeStartChecker.CheckStub = func(_ lager.Logger, _ string, start rundmc.StartFunc) error {
+			_, err := start(ioutil.Discard, ioutil.Discard)
+			return err
+		}
 		fakeBundler = new(fakes.FakeBundleGenerator)
 		fakeNstarRunner = new(fakes.FakeNstarRunner)
+		fakeAdmitter = new(fakes.FakeStreamInAdmitter)
+		fakeAdmitter.AdmitStub = func(_ lager.Logger, _, _ string, tarStream io.Reader) (io.Reader, func(error) error) {
+			return tarStream, func(error) error { return nil }
+		}
 		fakeStater = new(fakes.FakeContainerStater)
 		fakeEventStore = new(fakes.FakeEventStore)
 		logger = lagertest.NewTestLogger("test")
@@ -53,14 +71,33 @@ var _ = Describe("Rundmc", func() {
 			return fn()
 		}
 
-		containerizer = rundmc.New(fakeDepot, fakeBundler, fakeContainerRunner, fakeStartChecker, fakeStater, fakeNstarRunner, fakeEventStore, fakeRetrier)
+		fakeCgroupManager = new(fakes.FakeCgroupManager)
+		fakeProperties = new(fakes.FakeProperties)
+
+		config = rundmc.Config{
+			Depot:        fakeDepot,
+			Loader:       fakeBundleLoader,
+			Bundler:      fakeBundler,
+			Runner:       fakeContainerRunner,
+			StartChecker: fakeStartChecker,
+			StateChecker: fakeStater,
+			Nstar:        fakeNstarRunner,
+			Admitter:     fakeAdmitter,
+			Events:       fakeEventStore,
+			Retrier:      fakeRetrier,
+			Cgroups:      fakeCgroupManager,
+			Properties:   fakeProperties,
+			PausedPolicy: rundmc.FailWhenPaused,
+		}
+
+		containerizer = rundmc.New(config)
 	})
 
 	Describe("Create", func() {
 		It("should ask the depot to create a container", func() {
 			var returnedBundle *goci.Bndl
-			fakeBundler.GenerateStub = func(spec gardener.DesiredContainerSpec) *goci.Bndl {
-				return returnedBundle
+			fakeBundler.GenerateStub = func(spec gardener.DesiredContainerSpec) (*goci.Bndl, error) {
+				return returnedBundle, nil
 			}
 
 			containerizer.Create(logger, gardener.DesiredContainerSpec{
@@ -74,6 +111,23 @@ var _ = Describe("Rundmc", func() {
 			Expect(bundle).To(Equal(returnedBundle))
 		})
 
+		Context("when generating the bundle fails", func() {
+			BeforeEach(func() {
+				fakeBundler.GenerateReturns(nil, errors.New("capability CAP_SYS_ADMIN is not allowed"))
+			})
+
+			It("returns the error", func() {
+				Expect(containerizer.Create(logger, gardener.DesiredContainerSpec{
+					Handle: "exuberant!",
+				})).To(MatchError("capability CAP_SYS_ADMIN is not allowed"))
+			})
+
+			It("does not create the container in the depot", func() {
+				containerizer.Create(logger, gardener.DesiredContainerSpec{Handle: "exuberant!"})
+				Expect(fakeDepot.CreateCallCount()).To(Equal(0))
+			})
+		})
+
 		Context("when creating the depot directory fails", func() {
 			It("returns an error", func() {
 				fakeDepot.CreateReturns(errors.New("blam"))
@@ -111,9 +165,9 @@ var _ = Describe("Rundmc", func() {
 				Expect(containerizer.Create(logger, gardener.DesiredContainerSpec{})).NotTo(Succeed())
 			})
 
-			It("should not check if the container is started", func() {
+			It("should not wait for the state file", func() {
 				Expect(containerizer.Create(logger, gardener.DesiredContainerSpec{})).NotTo(Succeed())
-				Expect(fakeStartChecker.CheckCallCount()).To(Equal(0))
+				Expect(fakeStater.StateCallCount()).To(Equal(0))
 			})
 		})
 
@@ -144,18 +198,79 @@ var _ = Describe("Rundmc", func() {
 		})
 
 		It("should check if the container is started", func() {
-			Expect(containerizer.Create(logger, gardener.DesiredContainerSpec{})).To(Succeed())
+			Expect(containerizer.Create(logger, gardener.DesiredContainerSpec{Handle: "exuberant!"})).To(Succeed())
 			Expect(fakeStartChecker.CheckCallCount()).To(Equal(1))
+
+			_, bundlePath, _ := fakeStartChecker.CheckArgsForCall(0)
+			Expect(bundlePath).To(Equal("/path/to/exuberant!"))
+		})
+
+		It("passes the init process's output to the start checker", func() {
+			fakeContainerRunner.StartStub = func(_ lager.Logger, _, _ string, pio garden.ProcessIO) (garden.Process, error) {
+				pio.Stdout.Write([]byte("some-stdout"))
+				pio.Stderr.Write([]byte("some-stderr"))
+				return nil, nil
+			}
+
+			stdout, stderr := gbytes.NewBuffer(), gbytes.NewBuffer()
+			fakeStartChecker.CheckStub = func(_ lager.Logger, _ string, start rundmc.StartFunc) error {
+				_, err := start(stdout, stderr)
+				return err
+			}
+
+			Expect(containerizer.Create(logger, gardener.DesiredContainerSpec{})).To(Succeed())
+			Expect(stdout).To(gbytes.Say("some-stdout"))
+			Expect(stderr).To(gbytes.Say("some-stderr"))
+		})
+
+		It("records what the rules granted the container once it has started", func() {
+			fakeBundler.GrantsReturns(garden.Properties{"garden.granted-capabilities": "CAP_SYS_PTRACE"})
+
+			spec := gardener.DesiredContainerSpec{Handle: "the-handle"}
+			Expect(containerizer.Create(logger, spec)).To(Succeed())
+
+			Expect(fakeBundler.GrantsArgsForCall(0)).To(Equal(spec))
+			Expect(fakeProperties.SetCallCount()).To(Equal(1))
+			handle, name, value := fakeProperties.SetArgsForCall(0)
+			Expect(handle).To(Equal("the-handle"))
+			Expect(name).To(Equal("garden.granted-capabilities"))
+			Expect(value).To(Equal("CAP_SYS_PTRACE"))
+		})
+
+		It("prepares the container's cgroup before starting it", func() {
+			fakeCgroupManager.PrepareStub = func(_ lager.Logger, bundlePath string) error {
+				Expect(bundlePath).To(Equal("/path/to/the-handle"))
+				Expect(fakeContainerRunner.StartCallCount()).To(Equal(0))
+				return nil
+			}
+
+			Expect(containerizer.Create(logger, gardener.DesiredContainerSpec{Handle: "the-handle"})).To(Succeed())
+			Expect(fakeCgroupManager.PrepareCallCount()).To(Equal(1))
+		})
+
+		Context("when the cgroup can't be prepared", func() {
+			It("does not start the container", func() {
+				fakeCgroupManager.PrepareReturns(errors.New("no-cgroup-for-you"))
+
+				Expect(containerizer.Create(logger, gardener.DesiredContainerSpec{Handle: "the-handle"})).To(MatchError("no-cgroup-for-you"))
+				Expect(fakeContainerRunner.StartCallCount()).To(Equal(0))
+			})
 		})
 
 		Context("when the start check fails", func() {
 			It("returns the underlying error", func() {
-				fakeStartChecker.CheckStub = func(_ lager.Logger, stdout io.Reader) error {
-					return errors.New("I died")
-				}
+				fakeStartChecker.CheckReturns(errors.New("I died"))
 
 				Expect(containerizer.Create(logger, gardener.DesiredContainerSpec{Handle: "the-handle"})).To(MatchError("I died"))
 			})
+
+			It("does not record any grants", func() {
+				fakeBundler.GrantsReturns(garden.Properties{"garden.granted-capabilities": "CAP_SYS_PTRACE"})
+				fakeStartChecker.CheckReturns(errors.New("I died"))
+
+				containerizer.Create(logger, gardener.DesiredContainerSpec{Handle: "the-handle"})
+				Expect(fakeProperties.SetCallCount()).To(Equal(0))
+			})
 		})
 
 		Context("when the state file was not written even after PID 1 has started", func() {
@@ -215,6 +330,100 @@ var _ = Describe("Rundmc", func() {
 		})
 	})
 
+	Describe("paused containers", func() {
+		BeforeEach(func() {
+			fakeStater.StateReturns(rundmc.State{Pid: 12, Paused: true}, nil)
+		})
+
+		Context("when the paused policy is to fail", func() {
+			It("does not run processes in the container", func() {
+				_, err := containerizer.Run(logger, "some-handle", garden.ProcessSpec{}, garden.ProcessIO{})
+				Expect(err).To(MatchError("container some-handle is paused"))
+				Expect(fakeContainerRunner.ExecCallCount()).To(Equal(0))
+			})
+
+			It("does not stream files in to the container", func() {
+				Expect(containerizer.StreamIn(logger, "some-handle", garden.StreamInSpec{})).To(MatchError("stream-in: container some-handle is paused"))
+				Expect(fakeNstarRunner.StreamInCallCount()).To(Equal(0))
+			})
+
+			It("does not stream files out of the container", func() {
+				_, err := containerizer.StreamOut(logger, "some-handle", garden.StreamOutSpec{})
+				Expect(err).To(MatchError("stream-out: container some-handle is paused"))
+				Expect(fakeNstarRunner.StreamOutCallCount()).To(Equal(0))
+			})
+
+			It("does not resume the container", func() {
+				containerizer.Run(logger, "some-handle", garden.ProcessSpec{}, garden.ProcessIO{})
+				Expect(fakeContainerRunner.ResumeCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when the paused policy is to resume", func() {
+			BeforeEach(func() {
+				config.PausedPolicy = rundmc.ResumeWhenPaused
+				containerizer = rundmc.New(config)
+			})
+
+			It("resumes the container before running a process in it", func() {
+				_, err := containerizer.Run(logger, "some-handle", garden.ProcessSpec{}, garden.ProcessIO{})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeContainerRunner.ResumeCallCount()).To(Equal(1))
+				_, handle := fakeContainerRunner.ResumeArgsForCall(0)
+				Expect(handle).To(Equal("some-handle"))
+				Expect(fakeContainerRunner.ExecCallCount()).To(Equal(1))
+			})
+
+			It("resumes the container before streaming files in to it", func() {
+				Expect(containerizer.StreamIn(logger, "some-handle", garden.StreamInSpec{})).To(Succeed())
+				Expect(fakeContainerRunner.ResumeCallCount()).To(Equal(1))
+			})
+
+			It("resumes the container before streaming files out of it", func() {
+				_, err := containerizer.StreamOut(logger, "some-handle", garden.StreamOutSpec{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(fakeContainerRunner.ResumeCallCount()).To(Equal(1))
+			})
+
+			It("does not run the process when the container can't be resumed", func() {
+				fakeContainerRunner.ResumeReturns(errors.New("runc resume: exit status 1"))
+
+				_, err := containerizer.Run(logger, "some-handle", garden.ProcessSpec{}, garden.ProcessIO{})
+				Expect(err).To(MatchError("runc resume: exit status 1"))
+				Expect(fakeContainerRunner.ExecCallCount()).To(Equal(0))
+			})
+		})
+	})
+
+	Describe("Pause", func() {
+		It("asks the runner to pause the container", func() {
+			Expect(containerizer.Pause(logger, "some-handle")).To(Succeed())
+			Expect(fakeContainerRunner.PauseCallCount()).To(Equal(1))
+			_, handle := fakeContainerRunner.PauseArgsForCall(0)
+			Expect(handle).To(Equal("some-handle"))
+		})
+
+		It("returns the runner's error", func() {
+			fakeContainerRunner.PauseReturns(errors.New("runc pause: exit status 1"))
+			Expect(containerizer.Pause(logger, "some-handle")).To(MatchError("runc pause: exit status 1"))
+		})
+	})
+
+	Describe("Resume", func() {
+		It("asks the runner to resume the container", func() {
+			Expect(containerizer.Resume(logger, "some-handle")).To(Succeed())
+			Expect(fakeContainerRunner.ResumeCallCount()).To(Equal(1))
+			_, handle := fakeContainerRunner.ResumeArgsForCall(0)
+			Expect(handle).To(Equal("some-handle"))
+		})
+
+		It("returns the runner's error", func() {
+			fakeContainerRunner.ResumeReturns(errors.New("runc resume: exit status 1"))
+			Expect(containerizer.Resume(logger, "some-handle")).To(MatchError("runc resume: exit status 1"))
+		})
+	})
+
 	Describe("StreamIn", func() {
 		It("should execute the NSTar command with the container PID", func() {
 			fakeStater.StateReturns(rundmc.State{
@@ -244,6 +453,54 @@ var _ = Describe("Rundmc", func() {
 			fakeNstarRunner.StreamInReturns(errors.New("failed"))
 			Expect(containerizer.StreamIn(logger, "some-handle", garden.StreamInSpec{})).To(MatchError("stream-in: nstar: failed"))
 		})
+
+		It("passes the stream through the admitter with the container's bundle path", func() {
+			admittedStream := gbytes.NewBuffer()
+			fakeAdmitter.AdmitReturns(admittedStream, func(error) error { return nil })
+
+			someStream := gbytes.NewBuffer()
+			Expect(containerizer.StreamIn(logger, "some-handle", garden.StreamInSpec{
+				TarStream: someStream,
+			})).To(Succeed())
+
+			Expect(fakeAdmitter.AdmitCallCount()).To(Equal(1))
+			_, handle, bundlePath, stream := fakeAdmitter.AdmitArgsForCall(0)
+			Expect(handle).To(Equal("some-handle"))
+			Expect(bundlePath).To(Equal("/path/to/some-handle"))
+			Expect(stream).To(Equal(someStream))
+
+			_, _, _, _, nstarStream := fakeNstarRunner.StreamInArgsForCall(0)
+			Expect(nstarStream).To(Equal(admittedStream))
+		})
+
+		It("tells the admitter whether the stream was extracted, so that a failure doesn't count against the container", func() {
+			var extractErr error
+			fakeAdmitter.AdmitReturns(gbytes.NewBuffer(), func(err error) error {
+				extractErr = err
+				return nil
+			})
+			fakeNstarRunner.StreamInReturns(errors.New("disk full"))
+
+			containerizer.StreamIn(logger, "some-handle", garden.StreamInSpec{})
+			Expect(extractErr).To(MatchError("disk full"))
+		})
+
+		It("returns an error if the bundle path cannot be found", func() {
+			fakeDepot.LookupReturns("", errors.New("no bundle"))
+			Expect(containerizer.StreamIn(logger, "some-handle", garden.StreamInSpec{})).To(MatchError("stream-in: no bundle"))
+			Expect(fakeNstarRunner.StreamInCallCount()).To(Equal(0))
+		})
+
+		Context("when the admitter rejects the archive", func() {
+			BeforeEach(func() {
+				fakeAdmitter.AdmitReturns(gbytes.NewBuffer(), func(error) error { return errors.New("too big") })
+				fakeNstarRunner.StreamInReturns(errors.New("unexpected EOF"))
+			})
+
+			It("returns the rejection rather than the nstar error", func() {
+				Expect(containerizer.StreamIn(logger, "some-handle", garden.StreamInSpec{})).To(MatchError("stream-in: too big"))
+			})
+		})
 	})
 
 	Describe("StreamOut", func() {
@@ -286,6 +543,12 @@ var _ = Describe("Rundmc", func() {
 	})
 
 	Describe("destroy", func() {
+		It("forgets the container's stream-in usage", func() {
+			Expect(containerizer.Destroy(logger, "some-handle")).To(Succeed())
+			Expect(fakeAdmitter.ForgetCallCount()).To(Equal(1))
+			Expect(fakeAdmitter.ForgetArgsForCall(0)).To(Equal("some-handle"))
+		})
+
 		Context("when the state.json is already gone", func() {
 			BeforeEach(func() {
 				fakeStater.StateReturns(rundmc.State{}, errors.New("pid not found"))
@@ -301,6 +564,11 @@ var _ = Describe("Rundmc", func() {
 				Expect(fakeDepot.DestroyCallCount()).To(Equal(1))
 				Expect(arg2(fakeDepot.DestroyArgsForCall(0))).To(Equal("some-handle"))
 			})
+
+			It("should release the container's cgroup", func() {
+				Expect(containerizer.Destroy(logger, "some-handle")).To(Succeed())
+				Expect(fakeCgroupManager.ReleaseCallCount()).To(Equal(1))
+			})
 		})
 
 		Context("when state.json exists", func() {
@@ -328,6 +596,51 @@ var _ = Describe("Rundmc", func() {
 					containerizer.Destroy(logger, "some-handle")
 					Expect(fakeDepot.DestroyCallCount()).To(Equal(0))
 				})
+
+				It("does not release the container's cgroup", func() {
+					fakeContainerRunner.KillReturns(errors.New("killing is wrong"))
+					containerizer.Destroy(logger, "some-handle")
+					Expect(fakeCgroupManager.ReleaseCallCount()).To(Equal(0))
+				})
+			})
+
+			It("does not resume a container which is not paused", func() {
+				Expect(containerizer.Destroy(logger, "some-handle")).To(Succeed())
+				Expect(fakeContainerRunner.ResumeCallCount()).To(Equal(0))
+			})
+
+			Context("when the container is paused", func() {
+				BeforeEach(func() {
+					fakeStater.StateReturns(rundmc.State{Paused: true}, nil)
+				})
+
+				It("resumes it after killing it, so that its processes can die", func() {
+					Expect(containerizer.Destroy(logger, "some-handle")).To(Succeed())
+					Expect(fakeContainerRunner.KillCallCount()).To(Equal(1))
+					Expect(fakeContainerRunner.ResumeCallCount()).To(Equal(1))
+				})
+
+				It("does not destroy the depot directory when it can't be resumed", func() {
+					fakeContainerRunner.ResumeReturns(errors.New("runc resume: exit status 1"))
+					Expect(containerizer.Destroy(logger, "some-handle")).To(MatchError("runc resume: exit status 1"))
+					Expect(fakeDepot.DestroyCallCount()).To(Equal(0))
+				})
+			})
+
+			It("releases the container's cgroup using its bundle", func() {
+				Expect(containerizer.Destroy(logger, "some-handle")).To(Succeed())
+				Expect(fakeCgroupManager.ReleaseCallCount()).To(Equal(1))
+
+				_, bundlePath := fakeCgroupManager.ReleaseArgsForCall(0)
+				Expect(bundlePath).To(Equal("/path/to/some-handle"))
+			})
+
+			Context("when releasing the cgroup fails", func() {
+				It("still destroys the depot directory", func() {
+					fakeCgroupManager.ReleaseReturns(errors.New("busy"))
+					Expect(containerizer.Destroy(logger, "some-handle")).To(Succeed())
+					Expect(fakeDepot.DestroyCallCount()).To(Equal(1))
+				})
 			})
 		})
 	})
@@ -347,6 +660,42 @@ var _ = Describe("Rundmc", func() {
 			})
 		})
 
+		It("reports the CPUs and memory nodes the container is pinned to", func() {
+			cpus, mems := "4-5", "1"
+			fakeBundleLoader.LoadReturns(goci.Bundle().WithResources(&specs.Resources{
+				CPU: &specs.CPU{Cpus: &cpus, Mems: &mems},
+			}), nil)
+
+			actualSpec, err := containerizer.Info(logger, "some-handle")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeBundleLoader.LoadArgsForCall(0)).To(Equal("/path/to/some-handle"))
+			Expect(actualSpec.CPUSet).To(Equal(gardener.CPUSet{CPUs: "4-5", Mems: "1"}))
+		})
+
+		Context("when the bundle can't be loaded", func() {
+			It("should return the error", func() {
+				fakeBundleLoader.LoadReturns(nil, errors.New("no-config-json"))
+				_, err := containerizer.Info(logger, "some-handle")
+				Expect(err).To(MatchError("no-config-json"))
+			})
+		})
+
+		It("reports whether the container is paused", func() {
+			fakeStater.StateReturns(rundmc.State{Paused: true}, nil)
+
+			actualSpec, err := containerizer.Info(logger, "some-handle")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(actualSpec.Paused).To(BeTrue())
+		})
+
+		It("reports a container without a state as not paused", func() {
+			fakeStater.StateReturns(rundmc.State{Paused: true}, errors.New("state.json not found"))
+
+			actualSpec, err := containerizer.Info(logger, "some-handle")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(actualSpec.Paused).To(BeFalse())
+		})
+
 		It("should return any events from the event store", func() {
 			fakeEventStore.EventsReturns([]string{
 				"potato",
@@ -6,8 +6,10 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/cloudfoundry-incubator/goci"
+	"github.com/opencontainers/specs"
 	"github.com/pivotal-golang/lager"
 )
 
@@ -16,6 +18,7 @@ var ErrDoesNotExist = errors.New("does not exist")
 //go:generate counterfeiter . BundleSaver
 type BundleSaver interface {
 	Save(path string) error
+	Mounts() []specs.Mount
 }
 
 // a depot which stores containers as subdirs of a depot directory
@@ -47,6 +50,36 @@ func (d *DirectoryDepot) Create(log lager.Logger, handle string, bundle BundleSa
 		return err
 	}
 
+	if err := createMountSources(path, bundle.Mounts()); err != nil {
+		removeOrLog(log, path)
+		log.Error("create-mount-sources", err, lager.Data{"path": path})
+		return err
+	}
+
+	return nil
+}
+
+// createMountSources creates empty files for the bind mounts whose sources
+// are in the bundle directory (e.g. the DNS files of a container with a
+// read-only rootfs). Bundle rules only describe these mounts, so that nothing
+// is written to the depot for a container whose bundle is invalid.
+func createMountSources(bundlePath string, mounts []specs.Mount) error {
+	for _, m := range mounts {
+		if m.Type != "bind" || !strings.HasPrefix(m.Source, bundlePath+"/") {
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(m.Source), 0755); err != nil {
+			return err
+		}
+
+		f, err := os.OpenFile(m.Source, os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return err
+		}
+		f.Close()
+	}
+
 	return nil
 }
 
@@ -11,6 +11,7 @@ import (
 	"github.com/cloudfoundry-incubator/guardian/rundmc/depot/fakes"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"github.com/opencontainers/specs"
 	"github.com/pivotal-golang/lager"
 	"github.com/pivotal-golang/lager/lagertest"
 )
@@ -72,6 +73,43 @@ var _ = Describe("Depot", func() {
 			Expect(dirdepot.Create(logger, "aardvaark", fakeBundle)).NotTo(Succeed())
 			Expect(filepath.Join(depotDir, "aardvaark")).NotTo(BeADirectory())
 		})
+
+		It("creates the sources of bind mounts which are in the container directory", func() {
+			fakeBundle.MountsReturns([]specs.Mount{
+				{Type: "bind", Source: filepath.Join(depotDir, "aardvaark", "etc", "hosts"), Destination: "/etc/hosts"},
+				{Type: "bind", Source: "/some/host/path", Destination: "/data"},
+				{Type: "tmpfs", Source: filepath.Join(depotDir, "aardvaark", "tmpfs"), Destination: "/tmp"},
+			})
+
+			Expect(dirdepot.Create(logger, "aardvaark", fakeBundle)).To(Succeed())
+			Expect(filepath.Join(depotDir, "aardvaark", "etc", "hosts")).To(BeARegularFile())
+			Expect(filepath.Join(depotDir, "aardvaark", "tmpfs")).NotTo(BeAnExistingFile())
+			Expect("/some/host/path").NotTo(BeAnExistingFile())
+		})
+
+		It("leaves existing bind mount sources alone", func() {
+			source := filepath.Join(depotDir, "aardvaark", "etc", "hosts")
+			fakeBundle.SaveStub = func(path string) error {
+				Expect(os.MkdirAll(filepath.Dir(source), 0755)).To(Succeed())
+				return ioutil.WriteFile(source, []byte("127.0.0.1 localhost"), 0644)
+			}
+			fakeBundle.MountsReturns([]specs.Mount{{Type: "bind", Source: source, Destination: "/etc/hosts"}})
+
+			Expect(dirdepot.Create(logger, "aardvaark", fakeBundle)).To(Succeed())
+			Expect(ioutil.ReadFile(source)).To(Equal([]byte("127.0.0.1 localhost")))
+		})
+
+		It("destroys the container directory if a bind mount source can't be created", func() {
+			fakeBundle.MountsReturns([]specs.Mount{
+				{Type: "bind", Source: filepath.Join(depotDir, "aardvaark", "config.json", "hosts"), Destination: "/etc/hosts"},
+			})
+			fakeBundle.SaveStub = func(path string) error {
+				return ioutil.WriteFile(filepath.Join(path, "config.json"), []byte("{}"), 0644)
+			}
+
+			Expect(dirdepot.Create(logger, "aardvaark", fakeBundle)).NotTo(Succeed())
+			Expect(filepath.Join(depotDir, "aardvaark")).NotTo(BeADirectory())
+		})
 	})
 
 	Describe("destroy", func() {
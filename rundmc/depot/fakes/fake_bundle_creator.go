@@ -5,6 +5,7 @@ import (
 	"sync"
 
 	"github.com/cloudfoundry-incubator/guardian/rundmc/depot"
+	"github.com/opencontainers/specs"
 )
 
 type FakeBundleCreator struct {
@@ -16,6 +17,12 @@ type FakeBundleCreator struct {
 	saveReturns struct {
 		result1 error
 	}
+	MountsStub        func() []specs.Mount
+	mountsMutex       sync.RWMutex
+	mountsArgsForCall []struct{}
+	mountsReturns     struct {
+		result1 []specs.Mount
+	}
 }
 
 func (fake *FakeBundleCreator) Save(path string) error {
@@ -50,4 +57,28 @@ func (fake *FakeBundleCreator) SaveReturns(result1 error) {
 	}{result1}
 }
 
+func (fake *FakeBundleCreator) Mounts() []specs.Mount {
+	fake.mountsMutex.Lock()
+	fake.mountsArgsForCall = append(fake.mountsArgsForCall, struct{}{})
+	fake.mountsMutex.Unlock()
+	if fake.MountsStub != nil {
+		return fake.MountsStub()
+	} else {
+		return fake.mountsReturns.result1
+	}
+}
+
+func (fake *FakeBundleCreator) MountsCallCount() int {
+	fake.mountsMutex.RLock()
+	defer fake.mountsMutex.RUnlock()
+	return len(fake.mountsArgsForCall)
+}
+
+func (fake *FakeBundleCreator) MountsReturns(result1 []specs.Mount) {
+	fake.MountsStub = nil
+	fake.mountsReturns = struct {
+		result1 []specs.Mount
+	}{result1}
+}
+
 var _ depot.BundleSaver = new(FakeBundleCreator)
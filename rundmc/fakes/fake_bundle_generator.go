@@ -4,23 +4,33 @@ package fakes
 import (
 	"sync"
 
+	"github.com/cloudfoundry-incubator/garden"
 	"github.com/cloudfoundry-incubator/goci"
 	"github.com/cloudfoundry-incubator/guardian/gardener"
 	"github.com/cloudfoundry-incubator/guardian/rundmc"
 )
 
 type FakeBundleGenerator struct {
-	GenerateStub        func(spec gardener.DesiredContainerSpec) *goci.Bndl
+	GenerateStub        func(spec gardener.DesiredContainerSpec) (*goci.Bndl, error)
 	generateMutex       sync.RWMutex
 	generateArgsForCall []struct {
 		spec gardener.DesiredContainerSpec
 	}
 	generateReturns struct {
 		result1 *goci.Bndl
+		result2 error
+	}
+	GrantsStub        func(spec gardener.DesiredContainerSpec) garden.Properties
+	grantsMutex       sync.RWMutex
+	grantsArgsForCall []struct {
+		spec gardener.DesiredContainerSpec
+	}
+	grantsReturns struct {
+		result1 garden.Properties
 	}
 }
 
-func (fake *FakeBundleGenerator) Generate(spec gardener.DesiredContainerSpec) *goci.Bndl {
+func (fake *FakeBundleGenerator) Generate(spec gardener.DesiredContainerSpec) (*goci.Bndl, error) {
 	fake.generateMutex.Lock()
 	fake.generateArgsForCall = append(fake.generateArgsForCall, struct {
 		spec gardener.DesiredContainerSpec
@@ -29,7 +39,7 @@ func (fake *FakeBundleGenerator) Generate(spec gardener.DesiredContainerSpec) *g
 	if fake.GenerateStub != nil {
 		return fake.GenerateStub(spec)
 	} else {
-		return fake.generateReturns.result1
+		return fake.generateReturns.result1, fake.generateReturns.result2
 	}
 }
 
@@ -45,10 +55,43 @@ func (fake *FakeBundleGenerator) GenerateArgsForCall(i int) gardener.DesiredCont
 	return fake.generateArgsForCall[i].spec
 }
 
-func (fake *FakeBundleGenerator) GenerateReturns(result1 *goci.Bndl) {
+func (fake *FakeBundleGenerator) GenerateReturns(result1 *goci.Bndl, result2 error) {
 	fake.GenerateStub = nil
 	fake.generateReturns = struct {
 		result1 *goci.Bndl
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeBundleGenerator) Grants(spec gardener.DesiredContainerSpec) garden.Properties {
+	fake.grantsMutex.Lock()
+	fake.grantsArgsForCall = append(fake.grantsArgsForCall, struct {
+		spec gardener.DesiredContainerSpec
+	}{spec})
+	fake.grantsMutex.Unlock()
+	if fake.GrantsStub != nil {
+		return fake.GrantsStub(spec)
+	} else {
+		return fake.grantsReturns.result1
+	}
+}
+
+func (fake *FakeBundleGenerator) GrantsCallCount() int {
+	fake.grantsMutex.RLock()
+	defer fake.grantsMutex.RUnlock()
+	return len(fake.grantsArgsForCall)
+}
+
+func (fake *FakeBundleGenerator) GrantsArgsForCall(i int) gardener.DesiredContainerSpec {
+	fake.grantsMutex.RLock()
+	defer fake.grantsMutex.RUnlock()
+	return fake.grantsArgsForCall[i].spec
+}
+
+func (fake *FakeBundleGenerator) GrantsReturns(result1 garden.Properties) {
+	fake.GrantsStub = nil
+	fake.grantsReturns = struct {
+		result1 garden.Properties
 	}{result1}
 }
 
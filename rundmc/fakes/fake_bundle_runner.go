@@ -45,6 +45,24 @@ type FakeBundleRunner struct {
 	killReturns struct {
 		result1 error
 	}
+	PauseStub        func(log lager.Logger, handle string) error
+	pauseMutex       sync.RWMutex
+	pauseArgsForCall []struct {
+		log    lager.Logger
+		handle string
+	}
+	pauseReturns struct {
+		result1 error
+	}
+	ResumeStub        func(log lager.Logger, handle string) error
+	resumeMutex       sync.RWMutex
+	resumeArgsForCall []struct {
+		log    lager.Logger
+		handle string
+	}
+	resumeReturns struct {
+		result1 error
+	}
 	WatchStub        func(log lager.Logger, id string, notifier runrunc.Notifier) error
 	watchMutex       sync.RWMutex
 	watchArgsForCall []struct {
@@ -163,6 +181,72 @@ func (fake *FakeBundleRunner) KillReturns(result1 error) {
 	}{result1}
 }
 
+func (fake *FakeBundleRunner) Pause(log lager.Logger, handle string) error {
+	fake.pauseMutex.Lock()
+	fake.pauseArgsForCall = append(fake.pauseArgsForCall, struct {
+		log    lager.Logger
+		handle string
+	}{log, handle})
+	fake.pauseMutex.Unlock()
+	if fake.PauseStub != nil {
+		return fake.PauseStub(log, handle)
+	} else {
+		return fake.pauseReturns.result1
+	}
+}
+
+func (fake *FakeBundleRunner) PauseCallCount() int {
+	fake.pauseMutex.RLock()
+	defer fake.pauseMutex.RUnlock()
+	return len(fake.pauseArgsForCall)
+}
+
+func (fake *FakeBundleRunner) PauseArgsForCall(i int) (lager.Logger, string) {
+	fake.pauseMutex.RLock()
+	defer fake.pauseMutex.RUnlock()
+	return fake.pauseArgsForCall[i].log, fake.pauseArgsForCall[i].handle
+}
+
+func (fake *FakeBundleRunner) PauseReturns(result1 error) {
+	fake.PauseStub = nil
+	fake.pauseReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeBundleRunner) Resume(log lager.Logger, handle string) error {
+	fake.resumeMutex.Lock()
+	fake.resumeArgsForCall = append(fake.resumeArgsForCall, struct {
+		log    lager.Logger
+		handle string
+	}{log, handle})
+	fake.resumeMutex.Unlock()
+	if fake.ResumeStub != nil {
+		return fake.ResumeStub(log, handle)
+	} else {
+		return fake.resumeReturns.result1
+	}
+}
+
+func (fake *FakeBundleRunner) ResumeCallCount() int {
+	fake.resumeMutex.RLock()
+	defer fake.resumeMutex.RUnlock()
+	return len(fake.resumeArgsForCall)
+}
+
+func (fake *FakeBundleRunner) ResumeArgsForCall(i int) (lager.Logger, string) {
+	fake.resumeMutex.RLock()
+	defer fake.resumeMutex.RUnlock()
+	return fake.resumeArgsForCall[i].log, fake.resumeArgsForCall[i].handle
+}
+
+func (fake *FakeBundleRunner) ResumeReturns(result1 error) {
+	fake.ResumeStub = nil
+	fake.resumeReturns = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeBundleRunner) Watch(log lager.Logger, id string, notifier runrunc.Notifier) error {
 	fake.watchMutex.Lock()
 	fake.watchArgsForCall = append(fake.watchArgsForCall, struct {
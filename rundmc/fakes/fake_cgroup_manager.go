@@ -0,0 +1,98 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/guardian/rundmc"
+	"github.com/pivotal-golang/lager"
+)
+
+type FakeCgroupManager struct {
+	PrepareStub        func(log lager.Logger, bundlePath string) error
+	prepareMutex       sync.RWMutex
+	prepareArgsForCall []struct {
+		log        lager.Logger
+		bundlePath string
+	}
+	prepareReturns struct {
+		result1 error
+	}
+	ReleaseStub        func(log lager.Logger, bundlePath string) error
+	releaseMutex       sync.RWMutex
+	releaseArgsForCall []struct {
+		log        lager.Logger
+		bundlePath string
+	}
+	releaseReturns struct {
+		result1 error
+	}
+}
+
+func (fake *FakeCgroupManager) Prepare(log lager.Logger, bundlePath string) error {
+	fake.prepareMutex.Lock()
+	fake.prepareArgsForCall = append(fake.prepareArgsForCall, struct {
+		log        lager.Logger
+		bundlePath string
+	}{log, bundlePath})
+	fake.prepareMutex.Unlock()
+	if fake.PrepareStub != nil {
+		return fake.PrepareStub(log, bundlePath)
+	} else {
+		return fake.prepareReturns.result1
+	}
+}
+
+func (fake *FakeCgroupManager) PrepareCallCount() int {
+	fake.prepareMutex.RLock()
+	defer fake.prepareMutex.RUnlock()
+	return len(fake.prepareArgsForCall)
+}
+
+func (fake *FakeCgroupManager) PrepareArgsForCall(i int) (lager.Logger, string) {
+	fake.prepareMutex.RLock()
+	defer fake.prepareMutex.RUnlock()
+	return fake.prepareArgsForCall[i].log, fake.prepareArgsForCall[i].bundlePath
+}
+
+func (fake *FakeCgroupManager) PrepareReturns(result1 error) {
+	fake.PrepareStub = nil
+	fake.prepareReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeCgroupManager) Release(log lager.Logger, bundlePath string) error {
+	fake.releaseMutex.Lock()
+	fake.releaseArgsForCall = append(fake.releaseArgsForCall, struct {
+		log        lager.Logger
+		bundlePath string
+	}{log, bundlePath})
+	fake.releaseMutex.Unlock()
+	if fake.ReleaseStub != nil {
+		return fake.ReleaseStub(log, bundlePath)
+	} else {
+		return fake.releaseReturns.result1
+	}
+}
+
+func (fake *FakeCgroupManager) ReleaseCallCount() int {
+	fake.releaseMutex.RLock()
+	defer fake.releaseMutex.RUnlock()
+	return len(fake.releaseArgsForCall)
+}
+
+func (fake *FakeCgroupManager) ReleaseArgsForCall(i int) (lager.Logger, string) {
+	fake.releaseMutex.RLock()
+	defer fake.releaseMutex.RUnlock()
+	return fake.releaseArgsForCall[i].log, fake.releaseArgsForCall[i].bundlePath
+}
+
+func (fake *FakeCgroupManager) ReleaseReturns(result1 error) {
+	fake.ReleaseStub = nil
+	fake.releaseReturns = struct {
+		result1 error
+	}{result1}
+}
+
+var _ rundmc.CgroupManager = new(FakeCgroupManager)
@@ -2,7 +2,6 @@
 package fakes
 
 import (
-	"io"
 	"sync"
 
 	"github.com/cloudfoundry-incubator/guardian/rundmc"
@@ -10,26 +9,28 @@ import (
 )
 
 type FakeChecker struct {
-	CheckStub        func(log lager.Logger, output io.Reader) error
+	CheckStub        func(log lager.Logger, bundlePath string, start rundmc.StartFunc) error
 	checkMutex       sync.RWMutex
 	checkArgsForCall []struct {
-		log    lager.Logger
-		output io.Reader
+		log        lager.Logger
+		bundlePath string
+		start      rundmc.StartFunc
 	}
 	checkReturns struct {
 		result1 error
 	}
 }
 
-func (fake *FakeChecker) Check(log lager.Logger, output io.Reader) error {
+func (fake *FakeChecker) Check(log lager.Logger, bundlePath string, start rundmc.StartFunc) error {
 	fake.checkMutex.Lock()
 	fake.checkArgsForCall = append(fake.checkArgsForCall, struct {
-		log    lager.Logger
-		output io.Reader
-	}{log, output})
+		log        lager.Logger
+		bundlePath string
+		start      rundmc.StartFunc
+	}{log, bundlePath, start})
 	fake.checkMutex.Unlock()
 	if fake.CheckStub != nil {
-		return fake.CheckStub(log, output)
+		return fake.CheckStub(log, bundlePath, start)
 	} else {
 		return fake.checkReturns.result1
 	}
@@ -41,10 +42,10 @@ func (fake *FakeChecker) CheckCallCount() int {
 	return len(fake.checkArgsForCall)
 }
 
-func (fake *FakeChecker) CheckArgsForCall(i int) (lager.Logger, io.Reader) {
+func (fake *FakeChecker) CheckArgsForCall(i int) (lager.Logger, string, rundmc.StartFunc) {
 	fake.checkMutex.RLock()
 	defer fake.checkMutex.RUnlock()
-	return fake.checkArgsForCall[i].log, fake.checkArgsForCall[i].output
+	return fake.checkArgsForCall[i].log, fake.checkArgsForCall[i].bundlePath, fake.checkArgsForCall[i].start
 }
 
 func (fake *FakeChecker) CheckReturns(result1 error) {
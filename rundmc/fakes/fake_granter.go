@@ -0,0 +1,55 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/rundmc"
+)
+
+type FakeGranter struct {
+	GrantsStub        func(spec gardener.DesiredContainerSpec) garden.Properties
+	grantsMutex       sync.RWMutex
+	grantsArgsForCall []struct {
+		spec gardener.DesiredContainerSpec
+	}
+	grantsReturns struct {
+		result1 garden.Properties
+	}
+}
+
+func (fake *FakeGranter) Grants(spec gardener.DesiredContainerSpec) garden.Properties {
+	fake.grantsMutex.Lock()
+	fake.grantsArgsForCall = append(fake.grantsArgsForCall, struct {
+		spec gardener.DesiredContainerSpec
+	}{spec})
+	fake.grantsMutex.Unlock()
+	if fake.GrantsStub != nil {
+		return fake.GrantsStub(spec)
+	} else {
+		return fake.grantsReturns.result1
+	}
+}
+
+func (fake *FakeGranter) GrantsCallCount() int {
+	fake.grantsMutex.RLock()
+	defer fake.grantsMutex.RUnlock()
+	return len(fake.grantsArgsForCall)
+}
+
+func (fake *FakeGranter) GrantsArgsForCall(i int) gardener.DesiredContainerSpec {
+	fake.grantsMutex.RLock()
+	defer fake.grantsMutex.RUnlock()
+	return fake.grantsArgsForCall[i].spec
+}
+
+func (fake *FakeGranter) GrantsReturns(result1 garden.Properties) {
+	fake.GrantsStub = nil
+	fake.grantsReturns = struct {
+		result1 garden.Properties
+	}{result1}
+}
+
+var _ rundmc.Granter = new(FakeGranter)
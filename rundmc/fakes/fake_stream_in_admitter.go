@@ -0,0 +1,91 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"io"
+	"sync"
+
+	"github.com/cloudfoundry-incubator/guardian/rundmc"
+	"github.com/pivotal-golang/lager"
+)
+
+type FakeStreamInAdmitter struct {
+	AdmitStub        func(log lager.Logger, handle string, bundlePath string, tarStream io.Reader) (io.Reader, func(extractErr error) error)
+	admitMutex       sync.RWMutex
+	admitArgsForCall []struct {
+		log        lager.Logger
+		handle     string
+		bundlePath string
+		tarStream  io.Reader
+	}
+	admitReturns struct {
+		result1 io.Reader
+		result2 func(extractErr error) error
+	}
+	ForgetStub        func(handle string)
+	forgetMutex       sync.RWMutex
+	forgetArgsForCall []struct {
+		handle string
+	}
+}
+
+func (fake *FakeStreamInAdmitter) Admit(log lager.Logger, handle string, bundlePath string, tarStream io.Reader) (io.Reader, func(extractErr error) error) {
+	fake.admitMutex.Lock()
+	fake.admitArgsForCall = append(fake.admitArgsForCall, struct {
+		log        lager.Logger
+		handle     string
+		bundlePath string
+		tarStream  io.Reader
+	}{log, handle, bundlePath, tarStream})
+	fake.admitMutex.Unlock()
+	if fake.AdmitStub != nil {
+		return fake.AdmitStub(log, handle, bundlePath, tarStream)
+	} else {
+		return fake.admitReturns.result1, fake.admitReturns.result2
+	}
+}
+
+func (fake *FakeStreamInAdmitter) AdmitCallCount() int {
+	fake.admitMutex.RLock()
+	defer fake.admitMutex.RUnlock()
+	return len(fake.admitArgsForCall)
+}
+
+func (fake *FakeStreamInAdmitter) AdmitArgsForCall(i int) (lager.Logger, string, string, io.Reader) {
+	fake.admitMutex.RLock()
+	defer fake.admitMutex.RUnlock()
+	return fake.admitArgsForCall[i].log, fake.admitArgsForCall[i].handle, fake.admitArgsForCall[i].bundlePath, fake.admitArgsForCall[i].tarStream
+}
+
+func (fake *FakeStreamInAdmitter) AdmitReturns(result1 io.Reader, result2 func(extractErr error) error) {
+	fake.AdmitStub = nil
+	fake.admitReturns = struct {
+		result1 io.Reader
+		result2 func(extractErr error) error
+	}{result1, result2}
+}
+
+func (fake *FakeStreamInAdmitter) Forget(handle string) {
+	fake.forgetMutex.Lock()
+	fake.forgetArgsForCall = append(fake.forgetArgsForCall, struct {
+		handle string
+	}{handle})
+	fake.forgetMutex.Unlock()
+	if fake.ForgetStub != nil {
+		fake.ForgetStub(handle)
+	}
+}
+
+func (fake *FakeStreamInAdmitter) ForgetCallCount() int {
+	fake.forgetMutex.RLock()
+	defer fake.forgetMutex.RUnlock()
+	return len(fake.forgetArgsForCall)
+}
+
+func (fake *FakeStreamInAdmitter) ForgetArgsForCall(i int) string {
+	fake.forgetMutex.RLock()
+	defer fake.forgetMutex.RUnlock()
+	return fake.forgetArgsForCall[i].handle
+}
+
+var _ rundmc.StreamInAdmitter = new(FakeStreamInAdmitter)
@@ -0,0 +1,53 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/guardian/rundmc/initd"
+)
+
+type FakeReporter struct {
+	ReportStub        func(exit initd.Exit) error
+	reportMutex       sync.RWMutex
+	reportArgsForCall []struct {
+		exit initd.Exit
+	}
+	reportReturns struct {
+		result1 error
+	}
+}
+
+func (fake *FakeReporter) Report(exit initd.Exit) error {
+	fake.reportMutex.Lock()
+	fake.reportArgsForCall = append(fake.reportArgsForCall, struct {
+		exit initd.Exit
+	}{exit})
+	fake.reportMutex.Unlock()
+	if fake.ReportStub != nil {
+		return fake.ReportStub(exit)
+	} else {
+		return fake.reportReturns.result1
+	}
+}
+
+func (fake *FakeReporter) ReportCallCount() int {
+	fake.reportMutex.RLock()
+	defer fake.reportMutex.RUnlock()
+	return len(fake.reportArgsForCall)
+}
+
+func (fake *FakeReporter) ReportArgsForCall(i int) initd.Exit {
+	fake.reportMutex.RLock()
+	defer fake.reportMutex.RUnlock()
+	return fake.reportArgsForCall[i].exit
+}
+
+func (fake *FakeReporter) ReportReturns(result1 error) {
+	fake.ReportStub = nil
+	fake.reportReturns = struct {
+		result1 error
+	}{result1}
+}
+
+var _ initd.Reporter = new(FakeReporter)
@@ -0,0 +1,89 @@
+package initd
+
+import (
+	"errors"
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/pivotal-golang/clock"
+)
+
+// KillFunc sends a signal, in the manner of kill(2)
+type KillFunc func(pid int, sig syscall.Signal) error
+
+// Init reaps children until it is asked to shut down, at which point it
+// forwards the shutdown signal to every other process in the container and
+// gives them ShutdownTimeout to exit before killing them.
+//
+// Processes started with `runc exec` are not children of init, so their exit
+// does not raise SIGCHLD; during shutdown Init polls for them every
+// PollInterval.
+type Init struct {
+	Reaper          *Reaper
+	Kill            KillFunc
+	ShutdownTimeout time.Duration
+	PollInterval    time.Duration
+	Clock           clock.Clock
+}
+
+// ErrShutdownTimedOut is returned by Run when processes had to be killed
+var ErrShutdownTimedOut = errors.New("timed out waiting for processes to exit, killed them")
+
+// Run handles signals (which should include SIGCHLD, SIGTERM and SIGINT)
+// until a shutdown has completed.
+func (i *Init) Run(signals <-chan os.Signal) error {
+	for sig := range signals {
+		switch sig {
+		case syscall.SIGTERM, syscall.SIGINT:
+			return i.shutdown(sig.(syscall.Signal), signals)
+		default:
+			if _, err := i.Reaper.Reap(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (i *Init) shutdown(sig syscall.Signal, signals <-chan os.Signal) error {
+	// -1 from pid 1 is every other process in the pid namespace
+	if err := i.Kill(-1, sig); err != nil && err != syscall.ESRCH {
+		return err
+	}
+
+	timer := i.Clock.NewTimer(i.ShutdownTimeout)
+	defer timer.Stop()
+
+	ticker := i.Clock.NewTicker(i.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		children, err := i.Reaper.Reap()
+		if err != nil {
+			return err
+		}
+
+		if !children && !i.othersAlive() {
+			return nil
+		}
+
+		select {
+		case <-signals:
+		case <-ticker.C():
+		case <-timer.C():
+			if err := i.Kill(-1, syscall.SIGKILL); err != nil && err != syscall.ESRCH {
+				return err
+			}
+
+			i.Reaper.Reap()
+			return ErrShutdownTimedOut
+		}
+	}
+}
+
+func (i *Init) othersAlive() bool {
+	// signal 0 only checks whether there is anything to signal
+	return i.Kill(-1, 0) != syscall.ESRCH
+}
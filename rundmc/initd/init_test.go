@@ -0,0 +1,165 @@
+package initd_test
+
+import (
+	"os"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/cloudfoundry-incubator/guardian/rundmc/initd"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/clock/fakeclock"
+)
+
+type kill struct {
+	pid int
+	sig syscall.Signal
+}
+
+var _ = Describe("Init", func() {
+	var (
+		clk     *fakeclock.FakeClock
+		signals chan os.Signal
+
+		mu       sync.Mutex
+		kills    []kill
+		children int
+		others   bool
+
+		initProcess *initd.Init
+	)
+
+	BeforeEach(func() {
+		clk = fakeclock.NewFakeClock(time.Now())
+		signals = make(chan os.Signal, 1)
+		kills = nil
+		children = 0
+		others = false
+
+		initProcess = &initd.Init{
+			Reaper: &initd.Reaper{
+				Wait: func(status *syscall.WaitStatus) (int, error) {
+					mu.Lock()
+					defer mu.Unlock()
+
+					if children == 0 {
+						return 0, syscall.ECHILD
+					}
+
+					return 0, nil
+				},
+			},
+			Kill: func(pid int, sig syscall.Signal) error {
+				mu.Lock()
+				defer mu.Unlock()
+
+				if sig == 0 {
+					if others {
+						return nil
+					}
+
+					return syscall.ESRCH
+				}
+
+				kills = append(kills, kill{pid, sig})
+				return nil
+			},
+			ShutdownTimeout: 10 * time.Second,
+			PollInterval:    100 * time.Millisecond,
+			Clock:           clk,
+		}
+	})
+
+	run := func() <-chan error {
+		done := make(chan error, 1)
+		go func() {
+			done <- initProcess.Run(signals)
+		}()
+
+		return done
+	}
+
+	sentKills := func() []kill {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return append([]kill{}, kills...)
+	}
+
+	It("reaps children on SIGCHLD", func() {
+		reaped := make(chan struct{}, 1)
+		initProcess.Reaper.Wait = func(status *syscall.WaitStatus) (int, error) {
+			reaped <- struct{}{}
+			return 0, syscall.ECHILD
+		}
+
+		done := run()
+		signals <- syscall.SIGCHLD
+		Eventually(reaped).Should(Receive())
+
+		close(signals)
+		Eventually(done).Should(Receive(BeNil()))
+	})
+
+	itForwards := func(sig syscall.Signal) {
+		It("forwards "+sig.String()+" to every other process and exits", func() {
+			done := run()
+			signals <- sig
+
+			Eventually(done).Should(Receive(BeNil()))
+			Expect(sentKills()).To(Equal([]kill{{-1, sig}}))
+		})
+	}
+
+	itForwards(syscall.SIGTERM)
+	itForwards(syscall.SIGINT)
+
+	Context("when children take a while to exit", func() {
+		BeforeEach(func() {
+			children = 1
+		})
+
+		It("waits for them to be reaped", func() {
+			done := run()
+			signals <- syscall.SIGTERM
+			Consistently(done).ShouldNot(Receive())
+
+			mu.Lock()
+			children = 0
+			mu.Unlock()
+
+			signals <- syscall.SIGCHLD
+			Eventually(done).Should(Receive(BeNil()))
+		})
+
+		It("kills them once the timeout has passed", func() {
+			done := run()
+			signals <- syscall.SIGTERM
+			Consistently(done).ShouldNot(Receive())
+
+			clk.Increment(10 * time.Second)
+			Eventually(done).Should(Receive(Equal(initd.ErrShutdownTimedOut)))
+			Expect(sentKills()).To(Equal([]kill{{-1, syscall.SIGTERM}, {-1, syscall.SIGKILL}}))
+		})
+	})
+
+	Context("when processes which are not children take a while to exit", func() {
+		BeforeEach(func() {
+			others = true
+		})
+
+		It("polls until they have gone", func() {
+			done := run()
+			signals <- syscall.SIGTERM
+			Consistently(done).ShouldNot(Receive())
+
+			mu.Lock()
+			others = false
+			mu.Unlock()
+
+			clk.Increment(100 * time.Millisecond)
+			Eventually(done).Should(Receive(BeNil()))
+		})
+	})
+})
@@ -0,0 +1,13 @@
+package initd_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestInitd(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Initd Suite")
+}
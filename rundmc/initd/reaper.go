@@ -0,0 +1,91 @@
+// Package initd is the guts of the init process which runs as pid 1 in every
+// container. As pid 1 it inherits every orphaned process in the container, so
+// it has to reap them or they pile up as zombies.
+package initd
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"syscall"
+)
+
+// Exit describes a reaped child
+type Exit struct {
+	Pid        int    `json:"pid"`
+	ExitStatus int    `json:"exit_status"`
+	Signal     string `json:"signal,omitempty"`
+}
+
+// WaitFunc waits for any child without blocking, in the manner of wait4(2)
+// with WNOHANG: it returns 0 if children remain but none have exited, and
+// ECHILD if there are no children at all.
+type WaitFunc func(status *syscall.WaitStatus) (pid int, err error)
+
+// Wait4 is a WaitFunc which calls wait4(2)
+func Wait4(status *syscall.WaitStatus) (int, error) {
+	return syscall.Wait4(-1, status, syscall.WNOHANG, nil)
+}
+
+//go:generate counterfeiter . Reporter
+type Reporter interface {
+	Report(exit Exit) error
+}
+
+type Reaper struct {
+	Wait WaitFunc
+
+	// Reporter is told about every reaped child. It may be nil.
+	Reporter Reporter
+}
+
+// Reap collects every child which has already exited. It returns false once
+// there are no children left at all.
+func (r *Reaper) Reap() (bool, error) {
+	for {
+		var status syscall.WaitStatus
+		pid, err := r.Wait(&status)
+		if err == syscall.EINTR {
+			continue
+		}
+
+		if err == syscall.ECHILD {
+			return false, nil
+		}
+
+		if err != nil {
+			return true, err
+		}
+
+		if pid <= 0 {
+			return true, nil
+		}
+
+		if r.Reporter != nil {
+			r.Reporter.Report(exitOf(pid, status))
+		}
+	}
+}
+
+func exitOf(pid int, status syscall.WaitStatus) Exit {
+	if status.Signaled() {
+		// follow the shell's convention for processes killed by a signal
+		return Exit{Pid: pid, ExitStatus: 128 + int(status.Signal()), Signal: status.Signal().String()}
+	}
+
+	return Exit{Pid: pid, ExitStatus: status.ExitStatus()}
+}
+
+// JSONReporter writes each Exit to W as a line of JSON
+type JSONReporter struct {
+	W io.Writer
+
+	mu sync.Mutex
+}
+
+func (j *JSONReporter) Report(exit Exit) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return json.NewEncoder(j.W).Encode(exit)
+}
@@ -0,0 +1,156 @@
+package initd_test
+
+import (
+	"bytes"
+	"errors"
+	"os/exec"
+	"syscall"
+
+	"github.com/cloudfoundry-incubator/guardian/rundmc/initd"
+	"github.com/cloudfoundry-incubator/guardian/rundmc/initd/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type waitResult struct {
+	pid    int
+	status syscall.WaitStatus
+	err    error
+}
+
+// waitSequence returns a WaitFunc which returns each result in turn, then
+// ECHILD forever
+func waitSequence(results ...waitResult) (initd.WaitFunc, *int) {
+	calls := 0
+	return func(status *syscall.WaitStatus) (int, error) {
+		calls++
+		if len(results) == 0 {
+			return 0, syscall.ECHILD
+		}
+
+		result := results[0]
+		results = results[1:]
+		*status = result.status
+		return result.pid, result.err
+	}, &calls
+}
+
+// exited encodes an exit status the same way the kernel does
+func exited(code int) syscall.WaitStatus {
+	return syscall.WaitStatus(code << 8)
+}
+
+func killed(sig syscall.Signal) syscall.WaitStatus {
+	return syscall.WaitStatus(sig)
+}
+
+var _ = Describe("Reaper", func() {
+	var (
+		reporter *fakes.FakeReporter
+		reaper   *initd.Reaper
+	)
+
+	BeforeEach(func() {
+		reporter = new(fakes.FakeReporter)
+		reaper = &initd.Reaper{Reporter: reporter}
+	})
+
+	It("reaps every exited child and reports its exit status", func() {
+		reaper.Wait, _ = waitSequence(
+			waitResult{pid: 12, status: exited(0)},
+			waitResult{pid: 13, status: exited(3)},
+		)
+
+		Expect(reaper.Reap()).To(BeFalse())
+
+		Expect(reporter.ReportCallCount()).To(Equal(2))
+		Expect(reporter.ReportArgsForCall(0)).To(Equal(initd.Exit{Pid: 12, ExitStatus: 0}))
+		Expect(reporter.ReportArgsForCall(1)).To(Equal(initd.Exit{Pid: 13, ExitStatus: 3}))
+	})
+
+	It("reports children killed by a signal", func() {
+		reaper.Wait, _ = waitSequence(waitResult{pid: 12, status: killed(syscall.SIGKILL)})
+
+		Expect(reaper.Reap()).To(BeFalse())
+		Expect(reporter.ReportArgsForCall(0)).To(Equal(initd.Exit{Pid: 12, ExitStatus: 137, Signal: "killed"}))
+	})
+
+	Context("when children remain which have not exited", func() {
+		It("stops and says that children remain", func() {
+			var calls *int
+			reaper.Wait, calls = waitSequence(
+				waitResult{pid: 12, status: exited(0)},
+				waitResult{pid: 0},
+				waitResult{pid: 13, status: exited(0)},
+			)
+
+			Expect(reaper.Reap()).To(BeTrue())
+			Expect(*calls).To(Equal(2))
+			Expect(reporter.ReportCallCount()).To(Equal(1))
+		})
+	})
+
+	Context("when waiting is interrupted", func() {
+		It("retries", func() {
+			reaper.Wait, _ = waitSequence(
+				waitResult{err: syscall.EINTR},
+				waitResult{pid: 12, status: exited(0)},
+			)
+
+			Expect(reaper.Reap()).To(BeFalse())
+			Expect(reporter.ReportCallCount()).To(Equal(1))
+		})
+	})
+
+	Context("when waiting fails", func() {
+		It("returns the error", func() {
+			reaper.Wait, _ = waitSequence(waitResult{err: errors.New("boom")})
+
+			_, err := reaper.Reap()
+			Expect(err).To(MatchError("boom"))
+		})
+	})
+
+	Context("without a reporter", func() {
+		It("still reaps", func() {
+			var calls *int
+			reaper.Reporter = nil
+			reaper.Wait, calls = waitSequence(waitResult{pid: 12, status: exited(0)})
+
+			Expect(reaper.Reap()).To(BeFalse())
+			Expect(*calls).To(Equal(2))
+		})
+	})
+
+	Context("with real children", func() {
+		It("reaps them", func() {
+			reaper.Wait = initd.Wait4
+
+			cmd := exec.Command("sh", "-c", "exit 3")
+			Expect(cmd.Start()).To(Succeed())
+
+			Eventually(func() bool {
+				remaining, err := reaper.Reap()
+				Expect(err).NotTo(HaveOccurred())
+				return remaining
+			}).Should(BeFalse())
+
+			Expect(reporter.ReportArgsForCall(0)).To(Equal(initd.Exit{Pid: cmd.Process.Pid, ExitStatus: 3}))
+		})
+	})
+})
+
+var _ = Describe("JSONReporter", func() {
+	It("writes each exit as a line of JSON", func() {
+		out := new(bytes.Buffer)
+		reporter := &initd.JSONReporter{W: out}
+
+		Expect(reporter.Report(initd.Exit{Pid: 12, ExitStatus: 3})).To(Succeed())
+		Expect(reporter.Report(initd.Exit{Pid: 13, ExitStatus: 143, Signal: "terminated"})).To(Succeed())
+
+		Expect(out.String()).To(Equal(
+			`{"pid":12,"exit_status":3}` + "\n" +
+				`{"pid":13,"exit_status":143,"signal":"terminated"}` + "\n",
+		))
+	})
+})
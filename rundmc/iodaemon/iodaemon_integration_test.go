@@ -2,11 +2,10 @@ package iodaemon_test
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 
-	"io"
-
 	"github.com/cloudfoundry-incubator/garden"
 	linkpkg "github.com/cloudfoundry-incubator/guardian/rundmc/iodaemon/link"
 	. "github.com/onsi/ginkgo"
@@ -16,248 +15,153 @@ import (
 )
 
 var _ = Describe("Iodaemon integration tests", func() {
-	It("can read stdin", func() {
-		spawnS, err := gexec.Start(exec.Command(
+	var serveS *gexec.Session
+
+	BeforeEach(func() {
+		var err error
+		serveS, err = gexec.Start(exec.Command(
 			iodaemonBinPath,
-			"spawn",
+			"serve",
 			socketPath,
-			"bash", "-c", "cat <&0; exit 42",
 		), GinkgoWriter, GinkgoWriter)
 		Expect(err).ToNot(HaveOccurred())
 
-		defer spawnS.Kill()
+		Eventually(serveS).Should(gbytes.Say("ready\n"))
+	})
 
-		Eventually(spawnS).Should(gbytes.Say("ready\n"))
-		Consistently(spawnS).ShouldNot(gbytes.Say("active\n"))
+	AfterEach(func() {
+		serveS.Kill()
+		Eventually(serveS).Should(gexec.Exit())
+	})
 
-		linkStdout := gbytes.NewBuffer()
-		link, err := linkpkg.Create(socketPath, linkStdout, os.Stderr)
+	spawn := func(id string, stdout io.Writer, argv ...string) *linkpkg.Link {
+		l, err := linkpkg.Spawn(socketPath, id, linkpkg.SpawnSpec{Path: argv[0], Args: argv}, stdout, GinkgoWriter)
 		Expect(err).ToNot(HaveOccurred())
+		return l
+	}
+
+	It("can read stdin", func() {
+		linkStdout := gbytes.NewBuffer()
+		link := spawn("some-id", linkStdout, "bash", "-c", "cat <&0; exit 42")
 
 		link.Write([]byte("hello\ngoodbye"))
 		link.Close()
 
-		Eventually(spawnS).Should(gbytes.Say("active\n"))
 		Eventually(linkStdout).Should(gbytes.Say("hello\ngoodbye"))
 
 		Expect(link.Wait()).To(Equal(42))
 	})
 
 	It("can read stdin in tty mode", func() {
-		spawnS, err := gexec.Start(exec.Command(
-			iodaemonBinPath,
-			"-tty",
-			"spawn",
-			socketPath,
-			"bash", "-c", "cat <&0; exit 42",
-		), GinkgoWriter, GinkgoWriter)
-		Expect(err).ToNot(HaveOccurred())
-
-		defer spawnS.Kill()
-
-		Eventually(spawnS).Should(gbytes.Say("ready\n"))
-		Consistently(spawnS).ShouldNot(gbytes.Say("active\n"))
-
 		linkStdout := gbytes.NewBuffer()
-		link, err := linkpkg.Create(socketPath, linkStdout, os.Stderr)
+		link, err := linkpkg.Spawn(socketPath, "some-id", linkpkg.SpawnSpec{
+			Path: "bash",
+			Args: []string{"bash", "-c", "cat <&0; exit 42"},
+			TTY:  &linkpkg.WindowSize{Columns: 80, Rows: 24},
+		}, linkStdout, os.Stderr)
 		Expect(err).ToNot(HaveOccurred())
 
 		link.Write([]byte("hello\ngoodbye"))
-		link.Close()
-
-		Eventually(spawnS).Should(gbytes.Say("active\n"))
 		Eventually(linkStdout).Should(gbytes.Say("hello\r\ngoodbye"))
 
+		link.Close()
 		Expect(link.Wait()).To(Equal(255)) // 255 indicates unhandled SIGHUP
 	})
 
 	It("consistently executes a quickly-printing-and-exiting command", func() {
 		for i := 0; i < 10; i++ {
-			spawnS, err := gexec.Start(exec.Command(
-				iodaemonBinPath,
-				"spawn",
-				socketPath,
-				"echo", "hi",
-			), GinkgoWriter, GinkgoWriter)
-			Expect(err).ToNot(HaveOccurred())
+			linkStdout := gbytes.NewBuffer()
+			lk := spawn(fmt.Sprintf("process-%d", i), linkStdout, "echo", "hi")
 
-			Eventually(spawnS).Should(gbytes.Say("ready\n"))
-
-			lk, err := linkpkg.Create(socketPath, GinkgoWriter, GinkgoWriter)
-			Expect(err).ToNot(HaveOccurred())
-			lk.Close()
-
-			Eventually(spawnS).Should(gbytes.Say("active\n"))
-			Eventually(spawnS, 2).Should(gexec.Exit(0))
+			Expect(lk.Wait()).To(Equal(0))
+			Expect(linkStdout).To(gbytes.Say("hi\n"))
 		}
 	})
 
-	It("times out while spawning when no listeners connect", func() {
-		process, err := gexec.Start(exec.Command(
-			iodaemonBinPath,
-			"-timeout 5s",
-			"spawn",
-			socketPath,
-			"bash", "-c", "cat <&0",
-		), GinkgoWriter, GinkgoWriter)
-		Expect(err).ToNot(HaveOccurred())
+	It("keeps running processes when the client goes away, so that they can be attached to again", func() {
+		lk := spawn("some-id", GinkgoWriter, "bash", "-c", "read; echo hello; exit 12")
+		Expect(lk.TerminateConnection()).To(Succeed())
 
-		Eventually(process, "6s").Should(gexec.Exit(2))
-	})
-
-	It("returns the exit code of the process", func(done Done) {
-		spawnS, err := gexec.Start(exec.Command(
-			iodaemonBinPath,
-			"spawn",
-			socketPath,
-			"echo", "hello",
-		), GinkgoWriter, GinkgoWriter)
-		Expect(err).ToNot(HaveOccurred())
-
-		Eventually(spawnS).Should(gbytes.Say("ready\n"))
+		Expect(linkpkg.List(socketPath)).To(ConsistOf("some-id"))
 
 		linkStdout := gbytes.NewBuffer()
-		link, err := linkpkg.Create(socketPath, linkStdout, os.Stderr)
+		lk, err := linkpkg.Attach(socketPath, "some-id", linkStdout, GinkgoWriter)
 		Expect(err).ToNot(HaveOccurred())
 
-		Eventually(linkStdout).Should(gbytes.Say("hello"))
+		lk.Write([]byte("\n"))
+		Eventually(linkStdout).Should(gbytes.Say("hello\n"))
+		Expect(lk.Wait()).To(Equal(12))
+	})
 
-		status, err := link.Wait()
-		Expect(err).ToNot(HaveOccurred())
-		Expect(status).To(Equal(0))
+	It("breaks its links when it dies", func() {
+		lk := spawn("some-id", GinkgoWriter, "sleep", "100")
 
-		Eventually(spawnS).Should(gbytes.Say("active\n"))
-		Eventually(spawnS).Should(gexec.Exit(0))
+		serveS.Kill()
 
-		close(done)
-	}, 2.0)
+		_, err := lk.Wait()
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("exits with usage when the command is unknown", func() {
+		session, err := gexec.Start(exec.Command(iodaemonBinPath, "spawn", socketPath), GinkgoWriter, GinkgoWriter)
+		Expect(err).ToNot(HaveOccurred())
+		Eventually(session).Should(gexec.Exit(1))
+	})
 
 	Describe("Signalling", func() {
 		It("should forward SIGTERM", func(done Done) {
-			spawnS, err := gexec.Start(exec.Command(
-				iodaemonBinPath,
-				"spawn",
-				socketPath,
-				"sh", "-c", `
+			buffer := gbytes.NewBuffer()
+			link := spawn("some-id", io.MultiWriter(buffer, GinkgoWriter), "sh", "-c", `
 					trap 'exit 42' TERM
 					echo 'trapping'
 
 					sleep 100 &
 					wait
-				`,
-			), GinkgoWriter, GinkgoWriter)
-			Expect(err).ToNot(HaveOccurred())
-
-			Eventually(spawnS).Should(gbytes.Say("ready\n"))
-
-			buffer := gbytes.NewBuffer()
-			link, err := linkpkg.Create(socketPath, io.MultiWriter(buffer, GinkgoWriter), GinkgoWriter)
-			Expect(err).ToNot(HaveOccurred())
+				`)
 
 			Eventually(buffer).Should(gbytes.Say("trapping"))
 
-			err = link.Signal(garden.SignalTerminate)
+			err := link.Signal(garden.SignalTerminate)
 			Expect(err).ToNot(HaveOccurred())
 
 			status, err := link.Wait()
 			Expect(err).ToNot(HaveOccurred())
 			Expect(status).To(Equal(42))
 
-			Eventually(spawnS).Should(gexec.Exit(0))
-
 			close(done)
 		}, 5.0)
 
 		It("should forward SIGKILL", func(done Done) {
-			spawnS, err := gexec.Start(exec.Command(
-				iodaemonBinPath,
-				"spawn",
-				socketPath,
-				// signals other than kill will be delived to sh after sleep has finished
-				"sh", "-c", "sleep 100",
-			), GinkgoWriter, GinkgoWriter)
-			Expect(err).ToNot(HaveOccurred())
-
-			Eventually(spawnS).Should(gbytes.Say("ready\n"))
+			// signals other than kill will be delived to sh after sleep has finished
+			link := spawn("some-id", GinkgoWriter, "sh", "-c", "sleep 100")
 
-			buffer := gbytes.NewBuffer()
-			link, err := linkpkg.Create(socketPath, io.MultiWriter(buffer, GinkgoWriter), GinkgoWriter)
-			Expect(err).ToNot(HaveOccurred())
-
-			Eventually(spawnS).Should(gbytes.Say("active\n"))
-
-			err = link.Signal(garden.SignalKill)
+			err := link.Signal(garden.SignalKill)
 			Expect(err).ToNot(HaveOccurred())
 
 			status, err := link.Wait()
 			Expect(err).ToNot(HaveOccurred())
 			Expect(status).To(Equal(255))
 
-			Eventually(spawnS).Should(gexec.Exit(0))
-
 			close(done)
 		}, 5.0)
 	})
 
 	Context("when the process is exiting with a non-zero exit code", func() {
-		var (
-			sentExitCode int
-			spawnS       *gexec.Session
-		)
-
-		JustBeforeEach(func() {
-			var err error
-
-			spawnS, err = gexec.Start(exec.Command(
-				iodaemonBinPath,
-				"spawn",
-				socketPath,
-				"bash", "-c", fmt.Sprintf("exit %d", sentExitCode),
-			), GinkgoWriter, GinkgoWriter)
-			Expect(err).ToNot(HaveOccurred())
-
-			Eventually(spawnS).Should(gbytes.Say("ready\n"))
-		})
-
-		AfterEach(func() {
-			Eventually(spawnS).Should(gbytes.Say("active\n"))
-			Eventually(spawnS).Should(gexec.Exit(0))
-		})
+		for _, code := range []int{1, 255} {
+			sentExitCode := code
 
-		Context("when the process is exiting with 1", func() {
-			BeforeEach(func() {
-				sentExitCode = 1
-			})
-
-			It("returns the exit code of the process", func(done Done) {
-				link, err := linkpkg.Create(socketPath, GinkgoWriter, GinkgoWriter)
-				Expect(err).ToNot(HaveOccurred())
+			Context(fmt.Sprintf("when the process is exiting with %d", sentExitCode), func() {
+				It("returns the exit code of the process", func(done Done) {
+					link := spawn("some-id", GinkgoWriter, "bash", "-c", fmt.Sprintf("exit %d", sentExitCode))
 
-				status, err := link.Wait()
+					status, err := link.Wait()
 
-				Expect(err).ToNot(HaveOccurred())
-				Expect(status).To(Equal(1))
+					Expect(err).ToNot(HaveOccurred())
+					Expect(status).To(Equal(sentExitCode))
 
-				close(done)
-			}, 2.0)
-		})
-
-		Context("when the process is exiting with 255", func() {
-			BeforeEach(func() {
-				sentExitCode = 255
+					close(done)
+				}, 2.0)
 			})
-
-			It("returns the exit code of the process", func(done Done) {
-				link, err := linkpkg.Create(socketPath, GinkgoWriter, GinkgoWriter)
-				Expect(err).ToNot(HaveOccurred())
-
-				status, err := link.Wait()
-
-				Expect(err).ToNot(HaveOccurred())
-				Expect(status).To(Equal(255))
-
-				close(done)
-			}, 2.0)
-		})
+		}
 	})
 })
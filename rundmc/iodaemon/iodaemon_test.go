@@ -1,13 +1,10 @@
 package iodaemon_test
 
 import (
-	"time"
-
-	"io/ioutil"
+	"fmt"
+	"net"
 	"os"
-	"path/filepath"
-
-	"bytes"
+	"sync"
 
 	"github.com/cloudfoundry-incubator/guardian/rundmc/iodaemon"
 	linkpkg "github.com/cloudfoundry-incubator/guardian/rundmc/iodaemon/link"
@@ -16,264 +13,253 @@ import (
 	"github.com/onsi/gomega/gbytes"
 )
 
-type wc struct {
-	*bytes.Buffer
-}
-
-func (b wc) Close() error {
-	return nil
-}
-
 var _ = Describe("Iodaemon", func() {
 	var (
-		socketPath       string
-		tmpdir           string
-		fakeOut          wc
-		fakeErr          wc
-		expectedExitCode int
-
-		wirer  *iodaemon.Wirer
-		daemon *iodaemon.Daemon
-
-		exited chan struct{}
+		listener net.Listener
+		served   chan struct{}
 	)
 
 	BeforeEach(func() {
 		var err error
-		expectedExitCode = 0
-		tmpdir, err = ioutil.TempDir("", "socket-dir")
+		listener, err = iodaemon.Listen(socketPath)
 		Expect(err).ToNot(HaveOccurred())
 
-		socketPath = filepath.Join(tmpdir, "iodaemon.sock")
-
-		exited = make(chan struct{})
-
-		fakeOut = wc{
-			bytes.NewBuffer([]byte{}),
-		}
-		fakeErr = wc{
-			bytes.NewBuffer([]byte{}),
-		}
-
-		wirer = &iodaemon.Wirer{}
-		daemon = &iodaemon.Daemon{}
+		served = make(chan struct{})
+		go func() {
+			iodaemon.NewServer().Serve(listener)
+			close(served)
+		}()
 	})
 
 	AfterEach(func() {
-		defer os.RemoveAll(tmpdir)
-
-		Eventually(exited).Should(BeClosed())
-
-		By("tidying up the socket file")
-		if _, err := os.Stat(socketPath); !os.IsNotExist(err) {
-			Fail("socket file not cleaned up")
-		}
+		listener.Close()
+		Eventually(served).Should(BeClosed())
 	})
 
-	Context("spawning a process: when no listeners connect", func() {
-		spawnProcess := func(socketPath string, args ...string) {
-			go func() {
-				iodaemon.Spawn(socketPath, args, time.Second, fakeOut, wirer, daemon)
-				close(exited)
-			}()
-		}
+	command := func(argv ...string) linkpkg.SpawnSpec {
+		return linkpkg.SpawnSpec{Path: argv[0], Args: argv}
+	}
 
-		It("times out when no listeners connect", func() {
-			spawnProcess(socketPath, "echo", "hello")
-			Eventually(exited).Should(BeClosed())
-		})
-	})
+	spawn := func(id string, spec linkpkg.SpawnSpec) (*linkpkg.Link, *gbytes.Buffer, *gbytes.Buffer) {
+		stdout := gbytes.NewBuffer()
+		stderr := gbytes.NewBuffer()
 
-	Context("spawning a process: when listeners connect", func() {
-		spawnProcess := func(socketPath string, args ...string) {
-			go func() {
-				defer GinkgoRecover()
-				Expect(iodaemon.Spawn(socketPath, args, time.Second, fakeOut, wirer, daemon)).To(Succeed())
-				close(exited)
-			}()
-		}
+		l, err := linkpkg.Spawn(socketPath, id, spec, stdout, stderr)
+		Expect(err).ToNot(HaveOccurred())
 
-		It("reports back stdout", func() {
-			spawnProcess(socketPath, "echo", "hello")
+		return l, stdout, stderr
+	}
 
-			_, linkStdout, _, err := createLink(socketPath)
-			Expect(err).ToNot(HaveOccurred())
-			Eventually(linkStdout).Should(gbytes.Say("hello\n"))
+	Context("spawning a process", func() {
+		It("reports back stdout", func() {
+			l, stdout, _ := spawn("some-id", command("echo", "hello"))
+			Eventually(stdout).Should(gbytes.Say("hello\n"))
+			Expect(l.Wait()).To(Equal(0))
 		})
 
-		It("supports re-linking to an iodaemon instance", func() {
-			spawnProcess(socketPath, "bash")
+		It("reports back stderr", func() {
+			l, _, stderr := spawn("some-id", command("bash", "-c", "echo error 1>&2"))
+			Eventually(stderr).Should(gbytes.Say("error\n"))
+			Expect(l.Wait()).To(Equal(0))
+		})
 
-			l, _, _, err := createLink(socketPath)
-			Expect(err).ToNot(HaveOccurred())
-			err = l.Writer.TerminateConnection()
-			Expect(err).ToNot(HaveOccurred())
+		It("sends stdin to child", func() {
+			l, stdout, _ := spawn("some-id", command("env", "-i", "bash", "--noprofile", "--norc"))
 
-			m, _, _, err := createLink(socketPath)
+			_, err := l.Write([]byte("echo hello\n"))
 			Expect(err).ToNot(HaveOccurred())
+			Eventually(stdout).Should(gbytes.Say(".*hello.*"))
 
-			_, err = m.Write([]byte("exit\n"))
+			_, err = l.Write([]byte("exit 3\n"))
 			Expect(err).ToNot(HaveOccurred())
+			Expect(l.Wait()).To(Equal(3))
 		})
 
-		It("reports back stderr", func() {
-			spawnProcess(socketPath, "bash", "-c", "echo error 1>&2")
+		It("closes stdin when the link is closed", func() {
+			l, _, _ := spawn("some-id", command("bash"))
 
-			_, _, linkStderr, err := createLink(socketPath)
-			Expect(err).ToNot(HaveOccurred())
-			Eventually(linkStderr).Should(gbytes.Say("error\n"))
+			Expect(l.Close()).To(Succeed()) //bash will normally terminate when it receives EOF on stdin
+			Expect(l.Wait()).To(Equal(0))
 		})
 
-		It("sends stdin to child", func() {
-			spawnProcess(socketPath, "env", "-i", "bash", "--noprofile", "--norc")
-
-			l, linkStdout, _, err := createLink(socketPath)
-			Expect(err).ToNot(HaveOccurred())
+		It("runs the process with the given environment, in the given directory", func() {
+			spec := command("bash", "-c", "echo $FOO; pwd")
+			spec.Env = []string{"FOO=bar"}
+			spec.Dir = os.TempDir()
 
-			_, err = l.Write([]byte("echo hello\n"))
-			Expect(err).ToNot(HaveOccurred())
-			Eventually(linkStdout).Should(gbytes.Say(".*hello.*"))
+			l, stdout, _ := spawn("some-id", spec)
+			Eventually(stdout).Should(gbytes.Say("bar\n" + os.TempDir()))
+			Expect(l.Wait()).To(Equal(0))
+		})
 
-			_, err = l.Write([]byte("exit\n"))
-			Expect(err).ToNot(HaveOccurred())
+		It("returns an error when the executable can't be found", func() {
+			_, err := linkpkg.Spawn(socketPath, "some-id", command("/bin/does-not-exist"), gbytes.NewBuffer(), gbytes.NewBuffer())
+			Expect(err).To(MatchError(ContainSubstring("/bin/does-not-exist")))
 		})
 
-		It("exits when the child exits", func() {
-			spawnProcess(socketPath, "bash")
+		It("returns an error when the ID is already in use", func() {
+			l, _, _ := spawn("some-id", command("cat"))
 
-			l, _, _, err := createLink(socketPath)
-			Expect(err).ToNot(HaveOccurred())
+			_, err := linkpkg.Spawn(socketPath, "some-id", command("echo"), gbytes.NewBuffer(), gbytes.NewBuffer())
+			Expect(err).To(MatchError("process already exists: some-id"))
 
-			_, err = l.Write([]byte("exit\n"))
-			Expect(err).ToNot(HaveOccurred())
+			Expect(l.Close()).To(Succeed())
+			Expect(l.Wait()).To(Equal(0))
 		})
 
-		It("closes stdin when the link is closed", func() {
-			spawnProcess(socketPath, "bash")
-
-			l, _, _, err := createLink(socketPath)
-			Expect(err).ToNot(HaveOccurred())
-
-			Expect(l.Close()).To(Succeed()) //bash will normally terminate when it receives EOF on stdin
+		It("runs many processes at once over the one socket", func() {
+			var wg sync.WaitGroup
+			for i := 0; i < 20; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer GinkgoRecover()
+					defer wg.Done()
+
+					l, stdout, _ := spawn(fmt.Sprintf("process-%d", i), command("bash", "-c", fmt.Sprintf("echo hello %d; exit %d", i, i)))
+					Expect(l.Wait()).To(Equal(i))
+					Expect(stdout).To(gbytes.Say(fmt.Sprintf("hello %d\n", i)))
+				}(i)
+			}
+
+			wg.Wait()
 		})
 
 		Context("when there is an existing socket file", func() {
 			BeforeEach(func() {
+				listener.Close()
+				Eventually(served).Should(BeClosed())
+
 				file, err := os.Create(socketPath)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(file.Close()).To(Succeed())
+
+				listener, err = iodaemon.Listen(socketPath)
+				Expect(err).ToNot(HaveOccurred())
+
+				served = make(chan struct{})
+				go func() {
+					iodaemon.NewServer().Serve(listener)
+					close(served)
+				}()
 			})
 
 			It("still creates the process", func() {
-				spawnProcess(socketPath, "echo", "hello")
-
-				_, linkStdout, _, err := createLink(socketPath)
-				Expect(err).ToNot(HaveOccurred())
-				Eventually(linkStdout).Should(gbytes.Say("hello\n"))
+				l, stdout, _ := spawn("some-id", command("echo", "hello"))
+				Eventually(stdout).Should(gbytes.Say("hello\n"))
+				Expect(l.Wait()).To(Equal(0))
 			})
 		})
 	})
 
-	Context("spawning a tty", func() {
-		spawnTty := func(socketPath string, args ...string) {
-			go func() {
-				defer GinkgoRecover()
-				Expect(iodaemon.Spawn(socketPath, args, time.Second, fakeOut, wirer, daemon)).To(Succeed())
-				close(exited)
-			}()
-		}
+	Context("re-linking to a process", func() {
+		It("keeps the process's output until a link is attached", func() {
+			l, _, _ := spawn("some-id", command("bash", "-c", "read; echo hello; echo error >&2"))
+			Expect(l.TerminateConnection()).To(Succeed())
 
-		BeforeEach(func() {
-			wirer.WithTty = true
-			wirer.WindowColumns = 200
-			wirer.WindowRows = 80
-			daemon.WithTty = true
-		})
-
-		It("reports back stdout", func() {
-			spawnTty(socketPath, "echo", "hello")
+			stdout := gbytes.NewBuffer()
+			stderr := gbytes.NewBuffer()
+			m, err := linkpkg.Attach(socketPath, "some-id", stdout, stderr)
+			Expect(err).ToNot(HaveOccurred())
 
-			_, linkStdout, _, err := createLink(socketPath)
+			_, err = m.Write([]byte("\n"))
 			Expect(err).ToNot(HaveOccurred())
-			Eventually(linkStdout).Should(gbytes.Say("hello"))
+
+			Eventually(stdout).Should(gbytes.Say("hello\n"))
+			Eventually(stderr).Should(gbytes.Say("error\n"))
+			Expect(m.Wait()).To(Equal(0))
 		})
 
-		It("reports back stderr to stdout", func() {
-			spawnTty(socketPath, "bash", "-c", "echo error 1>&2")
+		It("closes the previous link", func() {
+			l, _, _ := spawn("some-id", command("cat"))
 
-			_, linkStdout, _, err := createLink(socketPath)
+			m, err := linkpkg.Attach(socketPath, "some-id", gbytes.NewBuffer(), gbytes.NewBuffer())
 			Expect(err).ToNot(HaveOccurred())
-			Eventually(linkStdout).Should(gbytes.Say("error"))
+
+			_, err = l.Wait()
+			Expect(err).To(MatchError(ContainSubstring("lost link to process some-id")))
+
+			Expect(m.Close()).To(Succeed())
+			Expect(m.Wait()).To(Equal(0))
 		})
 
-		It("exits when the child exits", func() {
-			spawnTty(socketPath, "bash")
+		It("returns an error when the process is unknown", func() {
+			_, err := linkpkg.Attach(socketPath, "some-id", gbytes.NewBuffer(), gbytes.NewBuffer())
+			Expect(err).To(MatchError("unknown process: some-id"))
+		})
+	})
 
-			l, _, _, err := createLink(socketPath)
-			Expect(err).ToNot(HaveOccurred())
+	Context("listing processes", func() {
+		It("lists the processes until their exit status has been sent", func() {
+			l, _, _ := spawn("some-id", command("cat"))
+			m, _, _ := spawn("other-id", command("cat"))
 
-			_, err = l.Write([]byte("exit\n"))
-			Expect(err).ToNot(HaveOccurred())
+			Expect(linkpkg.List(socketPath)).To(Equal([]string{"other-id", "some-id"}))
+
+			Expect(l.Close()).To(Succeed())
+			Expect(l.Wait()).To(Equal(0))
+			Eventually(func() ([]string, error) { return linkpkg.List(socketPath) }).Should(Equal([]string{"other-id"}))
+
+			Expect(m.Close()).To(Succeed())
+			Expect(m.Wait()).To(Equal(0))
 		})
+	})
 
-		It("closes stdin when the link is closed", func() {
-			spawnTty(socketPath, "bash")
+	Context("spawning a tty", func() {
+		tty := func(argv ...string) linkpkg.SpawnSpec {
+			spec := command(argv...)
+			spec.TTY = &linkpkg.WindowSize{Columns: 200, Rows: 80}
+			return spec
+		}
 
-			l, _, _, err := createLink(socketPath)
-			Expect(err).ToNot(HaveOccurred())
+		It("reports back stdout", func() {
+			l, stdout, _ := spawn("some-id", tty("echo", "hello"))
+			Eventually(stdout).Should(gbytes.Say("hello"))
+			Expect(l.Wait()).To(Equal(0))
+		})
 
-			Expect(l.Close()).To(Succeed()) //bash will normally terminate when it receives EOF on stdin
+		It("reports back stderr to stdout", func() {
+			l, stdout, _ := spawn("some-id", tty("bash", "-c", "echo error 1>&2"))
+			Eventually(stdout).Should(gbytes.Say("error"))
+			Expect(l.Wait()).To(Equal(0))
 		})
 
 		It("sends stdin to child", func() {
-			spawnTty(socketPath, "env", "-i", "bash", "--noprofile", "--norc")
-
-			l, linkStdout, _, err := createLink(socketPath)
-			Expect(err).ToNot(HaveOccurred())
+			l, stdout, _ := spawn("some-id", tty("env", "-i", "bash", "--noprofile", "--norc"))
 
-			_, err = l.Write([]byte("echo hello\n"))
+			_, err := l.Write([]byte("echo hello\n"))
 			Expect(err).ToNot(HaveOccurred())
-			Eventually(linkStdout).Should(gbytes.Say(".*hello.*"))
+			Eventually(stdout).Should(gbytes.Say(".*hello.*"))
 
 			_, err = l.Write([]byte("exit\n"))
 			Expect(err).ToNot(HaveOccurred())
+			Expect(l.Wait()).To(Equal(0))
 		})
 
-		It("correctly sets the window size", func() {
-			spawnTty(socketPath, "env", "-i", "bash", "--noprofile", "--norc")
+		It("hangs up the process when the link is closed", func() {
+			l, _, _ := spawn("some-id", tty("bash", "-c", "cat; exit 42"))
 
-			l, linkStdout, _, err := createLink(socketPath)
-			Expect(err).ToNot(HaveOccurred())
+			Expect(l.Close()).To(Succeed())
+			Expect(l.Wait()).To(Equal(255)) // 255 indicates unhandled SIGHUP
+		})
 
-			_, err = l.Write([]byte("TERM=xterm tput cols && TERM=xterm tput lines\n"))
+		It("correctly sets the window size", func() {
+			l, stdout, _ := spawn("some-id", tty("env", "-i", "bash", "--noprofile", "--norc"))
+
+			_, err := l.Write([]byte("TERM=xterm tput cols && TERM=xterm tput lines\n"))
 			Expect(err).ToNot(HaveOccurred())
-			Eventually(linkStdout).Should(gbytes.Say(`200\s*80`))
+			Eventually(stdout).Should(gbytes.Say(`200\s*80`))
 
 			Expect(l.SetWindowSize(100, 40)).To(Succeed())
 
 			Eventually(func() *gbytes.Buffer {
 				_, err = l.Write([]byte("TERM=xterm tput cols && TERM=xterm tput lines\n"))
 				Expect(err).ToNot(HaveOccurred())
-				return linkStdout
+				return stdout
 			}).Should(gbytes.Say((`100\s*40`)))
 
 			_, err = l.Write([]byte("exit\n"))
 			Expect(err).ToNot(HaveOccurred())
+			Expect(l.Wait()).To(Equal(0))
 		})
 	})
 })
-
-func createLink(socketPath string) (*linkpkg.Link, *gbytes.Buffer, *gbytes.Buffer, error) {
-	linkStdout := gbytes.NewBuffer()
-	linkStderr := gbytes.NewBuffer()
-	var l *linkpkg.Link
-	var err error
-	for i := 0; i < 100; i++ {
-		time.Sleep(10 * time.Millisecond)
-		l, err = linkpkg.Create(socketPath, linkStdout, linkStderr)
-		if err == nil {
-			break
-		}
-	}
-	return l, linkStdout, linkStderr, err
-}
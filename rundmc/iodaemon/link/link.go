@@ -1,170 +1,122 @@
 package link
 
 import (
-	"bytes"
+	"encoding/gob"
+	"errors"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"net"
-	"sync"
 	"syscall"
-	"time"
-
-	"github.com/npat-efault/poller"
 )
 
 type SignalMsg struct {
 	Signal syscall.Signal `json:"signal"`
 }
 
+// Link is a connection to a process which the IO daemon is running. Input
+// written to it is sent to the process, and the process's output is written
+// to the link's stdout and stderr until the process exits.
 type Link struct {
 	*Writer
 
-	exitStatus <-chan int
+	done       chan struct{}
+	exitStatus int
+	exitErr    error
 }
 
-func Create(socketPath string, stdout io.Writer, stderr io.Writer) (*Link, error) {
-	conn, err := net.Dial("unix", socketPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to connect to i/o daemon: %s", err)
-	}
-
-	var b [2048]byte
-	var oob [2048]byte
-
-	n, oobn, _, _, err := conn.(*net.UnixConn).ReadMsgUnix(b[:], oob[:])
-	if err != nil {
-		return nil, fmt.Errorf("failed to read unix msg: %s (read: %d, %d)", err, n, oobn)
-	}
-
-	scms, err := syscall.ParseSocketControlMessage(oob[:oobn])
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse socket control message: %s", err)
-	}
-
-	if len(scms) < 1 {
-		return nil, fmt.Errorf("no socket control messages sent")
-	}
-
-	scm := scms[0]
-
-	fds, err := syscall.ParseUnixRights(&scm)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse unix rights: %s", err)
-	}
-
-	if len(fds) != 3 {
-		return nil, fmt.Errorf("invalid number of fds; need 3, got %d", len(fds))
-	}
+// Spawn asks the IO daemon listening on socketPath to start a process with
+// the given ID, and links to it
+func Spawn(socketPath, id string, spec SpawnSpec, stdout io.Writer, stderr io.Writer) (*Link, error) {
+	return create(socketPath, Request{ID: id, Spawn: &spec}, stdout, stderr)
+}
 
-	for _, fd := range fds {
-		syscall.CloseOnExec(fd)
-	}
+// Attach links to a process which the IO daemon is already running, e.g.
+// after guardian has restarted. The process's previous link is closed.
+func Attach(socketPath, id string, stdout io.Writer, stderr io.Writer) (*Link, error) {
+	return create(socketPath, Request{ID: id}, stdout, stderr)
+}
 
-	lstdout, err := poller.NewFD(fds[0])
+// List returns the IDs of the processes which the IO daemon is running
+func List(socketPath string) ([]string, error) {
+	writer, _, response, err := request(socketPath, Request{List: true})
 	if err != nil {
 		return nil, err
 	}
 
-	var devNullS syscall.Stat_t
-	if err := syscall.Stat("/dev/null", &devNullS); err != nil {
-		return nil, err
-	}
+	writer.TerminateConnection()
+	return response.Processes, nil
+}
 
-	var s syscall.Stat_t
-	if err := syscall.Fstat(fds[1], &s); err != nil {
-		return nil, err
-	}
+func (link *Link) Wait() (int, error) {
+	<-link.done
+	return link.exitStatus, link.exitErr
+}
 
-	lstatus, err := poller.NewFD(fds[2])
+func create(socketPath string, req Request, stdout io.Writer, stderr io.Writer) (*Link, error) {
+	writer, decoder, _, err := request(socketPath, req)
 	if err != nil {
 		return nil, err
 	}
 
-	linkWriter := NewWriter(conn)
-	done := make(chan struct{})
-
-	wg := sync.WaitGroup{}
-	wg.Add(1)
+	link := &Link{
+		Writer: writer,
+		done:   make(chan struct{}),
+	}
 
-	go func(fd io.Reader) {
-		defer wg.Done()
+	go func() {
+		defer close(link.done)
+		defer writer.TerminateConnection()
 
 		for {
-			buff := make([]byte, 32*1024)
-			n, err := fd.Read(buff)
+			var output Output
+			if err := decoder.Decode(&output); err != nil {
+				link.exitStatus = -1
+				link.exitErr = fmt.Errorf("lost link to process %s: %s", req.ID, err)
+				return
+			}
 
-			if n > 0 {
-				stdout.Write(buff[0:n])
+			if len(output.Stdout) > 0 {
+				stdout.Write(output.Stdout)
 			}
 
-			if err != nil {
+			if len(output.Stderr) > 0 {
+				stderr.Write(output.Stderr)
+			}
+
+			if output.Exited {
+				link.exitStatus = output.ExitStatus
 				return
 			}
 		}
-	}(lstdout)
-
-	// if using a tty, stderr will be /dev/null remotely, which we can't poll
-	var lstderr io.ReadCloser = ioutil.NopCloser(&bytes.Buffer{})
-	if s.Rdev != devNullS.Rdev {
-		lstderr, err = poller.NewFD(fds[1])
-		if err != nil {
-			return nil, err
-		}
-
-		wg.Add(1)
-		go func(fd io.Reader) {
-			defer wg.Done()
+	}()
 
-			for {
-				buff := make([]byte, 32*1024)
-				n, err := fd.Read(buff)
+	return link, nil
+}
 
-				if n > 0 {
-					stderr.Write(buff[0:n])
-				}
+func request(socketPath string, req Request) (*Writer, *gob.Decoder, Response, error) {
+	var response Response
 
-				if err != nil {
-					return
-				}
-			}
-		}(lstderr)
+	conn, err := net.Dial("unix", socketPath)
+	if err != nil {
+		return nil, nil, response, fmt.Errorf("failed to connect to i/o daemon: %s", err)
 	}
 
-	go func() {
-		wg.Wait()
-		close(done)
-	}()
-
-	exitStatus := make(chan int)
-	go func() {
-		var s int
-		_, err := fmt.Fscanf(lstatus, "%d\n", &s)
-		if err != nil {
-			s = 255
-		}
-
-		select {
-		case <-done:
-			// quit immediately if stdout and error have closed
-		case <-time.After(200 * time.Millisecond):
-			// need a timeout here in case streams never close
-		}
-
+	writer := NewWriter(conn)
+	if err := writer.enc.Encode(req); err != nil {
 		conn.Close()
-		lstatus.Close()
-		lstdout.Close()
-		lstderr.Close()
+		return nil, nil, response, fmt.Errorf("failed to send request to i/o daemon: %s", err)
+	}
 
-		exitStatus <- s
-	}()
+	decoder := gob.NewDecoder(conn)
+	if err := decoder.Decode(&response); err != nil {
+		conn.Close()
+		return nil, nil, response, fmt.Errorf("failed to read response from i/o daemon: %s", err)
+	}
 
-	return &Link{
-		Writer:     linkWriter,
-		exitStatus: exitStatus,
-	}, nil
-}
+	if response.Error != "" {
+		conn.Close()
+		return nil, nil, response, errors.New(response.Error)
+	}
 
-func (link *Link) Wait() (int, error) {
-	return <-link.exitStatus, nil
+	return writer, decoder, response, nil
 }
@@ -0,0 +1,48 @@
+package link
+
+// The IO daemon serves each connection as follows. The client sends a
+// Request, and the daemon replies with a Response. A connection which lists
+// the processes ends there. A connection which spawns or attaches to a
+// process becomes the process's link: the client sends Inputs, and the
+// daemon sends Outputs, the last of which carries the exit status.
+//
+// Every message is gob encoded.
+
+type Request struct {
+	// ID names the process to spawn or attach to
+	ID string
+
+	// Spawn starts the process, rather than attaching to a running one
+	Spawn *SpawnSpec
+
+	// List lists the running processes, rather than linking to one
+	List bool
+}
+
+type SpawnSpec struct {
+	Path string
+	Args []string
+	Env  []string
+	Dir  string
+
+	// TTY, when set, runs the process in a terminal of the given size
+	TTY *WindowSize
+}
+
+type Response struct {
+	Error string
+
+	// Processes are the IDs of the running processes, in reply to List
+	Processes []string
+}
+
+type Output struct {
+	Stdout []byte
+	Stderr []byte
+
+	// Exited is set on the last message, whose ExitStatus is the process's
+	// (255 if it was killed by a signal); gob does not send zero values, so
+	// a pointer would not survive an exit status of 0
+	Exited     bool
+	ExitStatus int
+}
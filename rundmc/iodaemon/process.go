@@ -0,0 +1,302 @@
+package iodaemon
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/guardian/rundmc/iodaemon/link"
+	"github.com/npat-efault/poller"
+)
+
+// streamGrace is how long output is still read for once a process has
+// exited, as children it leaves behind may hold its streams open
+const streamGrace = 200 * time.Millisecond
+
+type process struct {
+	cmd     *exec.Cmd
+	withTty bool
+
+	stdin  io.WriteCloser
+	stdout *poller.FD
+	stderr *poller.FD // nil with a tty, whose output is all on stdout
+	ttyFd  int        // for setting the window size
+
+	exited  chan struct{}
+	streams sync.WaitGroup
+
+	// sendMu makes the stdout, stderr and exit goroutines take turns to send
+	sendMu sync.Mutex
+
+	// mu guards conn, the client linked to the process, if any, and
+	// finished, which is set once the exit status has been sent
+	mu       sync.Mutex
+	attached *sync.Cond
+	conn     *connection
+	finished bool
+}
+
+func start(spec link.SpawnSpec) (*process, error) {
+	executablePath, err := exec.LookPath(spec.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := child(executablePath, spec.Args)
+	cmd.Env = spec.Env
+	cmd.Dir = spec.Dir
+
+	wirer := &Wirer{WithTty: spec.TTY != nil}
+	if spec.TTY != nil {
+		wirer.WindowColumns = spec.TTY.Columns
+		wirer.WindowRows = spec.TTY.Rows
+	}
+
+	stdinW, stdoutR, stderrR, err := wirer.Wire(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	err = cmd.Start()
+	closeChildEnds(cmd)
+	if err != nil {
+		stdinW.Close()
+		stdoutR.Close()
+		stderrR.Close()
+		return nil, err
+	}
+
+	p := &process{
+		cmd:     cmd,
+		withTty: wirer.WithTty,
+		ttyFd:   -1,
+		exited:  make(chan struct{}),
+	}
+	p.attached = sync.NewCond(&p.mu)
+
+	if p.withTty {
+		// stdin and stdout are both the pty's master, and stderrR is /dev/null
+		stderrR.Close()
+
+		if p.ttyFd, err = dup(stdoutR); err == nil {
+			p.stdout, err = pollable(stdoutR)
+		}
+
+		p.stdin = p.stdout
+	} else {
+		p.stdin = stdinW
+		if p.stdout, err = pollable(stdoutR); err == nil {
+			p.stderr, err = pollable(stderrR)
+		}
+	}
+
+	if err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return nil, err
+	}
+
+	p.streams.Add(1)
+	go p.stream(p.stdout, func(data []byte) link.Output { return link.Output{Stdout: data} })
+
+	if p.stderr != nil {
+		p.streams.Add(1)
+		go p.stream(p.stderr, func(data []byte) link.Output { return link.Output{Stderr: data} })
+	}
+
+	return p, nil
+}
+
+// attach links the process to c, closing any previous link
+func (p *process) attach(c *connection) {
+	p.mu.Lock()
+	if p.finished {
+		p.mu.Unlock()
+		c.close()
+		return
+	}
+
+	previous := p.conn
+	p.conn = c
+	p.attached.Broadcast()
+	p.mu.Unlock()
+
+	if previous != nil {
+		previous.close()
+	}
+}
+
+func (p *process) detach(c *connection) {
+	p.mu.Lock()
+	if p.conn == c {
+		p.conn = nil
+	}
+	p.mu.Unlock()
+
+	c.close()
+}
+
+// send sends output to the linked client, waiting for one to attach if
+// there is none, or if sending fails
+func (p *process) send(output link.Output) {
+	p.sendMu.Lock()
+	defer p.sendMu.Unlock()
+
+	for {
+		p.mu.Lock()
+		for p.conn == nil {
+			p.attached.Wait()
+		}
+		c := p.conn
+		p.mu.Unlock()
+
+		if err := c.send(output); err == nil {
+			return
+		}
+
+		p.detach(c)
+	}
+}
+
+func (p *process) stream(r *poller.FD, output func([]byte) link.Output) {
+	defer p.streams.Done()
+
+	buf := make([]byte, 32*1024)
+	for {
+		select {
+		case <-p.exited:
+			// keep draining, but only for as long as the stream is not idle
+			r.SetReadDeadline(time.Now().Add(streamGrace))
+		default:
+		}
+
+		n, err := r.Read(buf)
+		if n > 0 {
+			p.send(output(buf[:n]))
+		}
+
+		if err != nil {
+			return
+		}
+	}
+}
+
+// wait waits for the process to exit, and sends its exit status once its
+// output has been sent
+func (p *process) wait() {
+	err := p.cmd.Wait()
+
+	close(p.exited)
+	p.stdout.SetReadDeadline(time.Now().Add(streamGrace))
+	if p.stderr != nil {
+		p.stderr.SetReadDeadline(time.Now().Add(streamGrace))
+	}
+
+	p.streams.Wait()
+	p.send(link.Output{Exited: true, ExitStatus: exitStatus(err)})
+
+	p.mu.Lock()
+	c := p.conn
+	p.conn = nil
+	p.finished = true
+	p.mu.Unlock()
+
+	// the client may already have hung up, having got the exit status
+	if c != nil {
+		c.close()
+	}
+
+	p.stdout.Close()
+	if p.stderr != nil {
+		p.stderr.Close()
+	} else {
+		syscall.Close(p.ttyFd)
+	}
+
+	if !p.withTty {
+		p.stdin.Close()
+	}
+}
+
+func (p *process) handle(input link.Input) error {
+	if input.WindowSize != nil {
+		setWinSize(uintptr(p.ttyFd), input.WindowSize.Columns, input.WindowSize.Rows)
+		p.cmd.Process.Signal(syscall.SIGWINCH)
+	} else if input.EOF {
+		if p.withTty {
+			// the pty's master is also its output, so it stays open
+			p.cmd.Process.Signal(syscall.SIGHUP)
+			return nil
+		}
+
+		return p.stdin.Close()
+	} else if input.Signal != nil {
+		if input.Signal.Signal == garden.SignalTerminate {
+			p.cmd.Process.Signal(syscall.SIGTERM)
+		} else if input.Signal.Signal == garden.SignalKill {
+			p.cmd.Process.Signal(syscall.SIGKILL)
+		}
+	} else {
+		_, err := p.stdin.Write(input.StdinData)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func exitStatus(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		if ws, ok := exitErr.Sys().(syscall.WaitStatus); ok && ws.Exited() {
+			return ws.ExitStatus()
+		}
+	}
+
+	// e.g. killed by a signal
+	return 255
+}
+
+// closeChildEnds closes the daemon's copies of the ends of the process's
+// streams which the process was given, so that its output reaches EOF
+func closeChildEnds(cmd *exec.Cmd) {
+	for _, stream := range []interface{}{cmd.Stdin, cmd.Stdout, cmd.Stderr} {
+		if f, ok := stream.(*os.File); ok {
+			f.Close() // the tty is all three; closing it again is harmless
+		}
+	}
+}
+
+// dup duplicates f's descriptor, without leaking it to processes spawned
+// concurrently
+func dup(f *os.File) (int, error) {
+	syscall.ForkLock.RLock()
+	defer syscall.ForkLock.RUnlock()
+
+	fd, err := syscall.Dup(int(f.Fd()))
+	if err != nil {
+		return -1, err
+	}
+
+	syscall.CloseOnExec(fd)
+	return fd, nil
+}
+
+// pollable replaces f with a poller, so that reads on it can time out
+func pollable(f *os.File) (*poller.FD, error) {
+	fd, err := dup(f)
+	f.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return poller.NewFD(fd)
+}
@@ -0,0 +1,192 @@
+package iodaemon
+
+import (
+	"encoding/gob"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/cloudfoundry-incubator/guardian/rundmc/iodaemon/link"
+)
+
+// Server runs processes on behalf of the clients which connect to its
+// socket, speaking the protocol described in the link package. It keeps
+// each process's output, and its exit status, until a client is linked to
+// receive them, so that processes survive their client, e.g. guardian,
+// restarting.
+type Server struct {
+	processes map[string]*process
+	mu        sync.Mutex
+}
+
+func NewServer() *Server {
+	return &Server{
+		processes: make(map[string]*process),
+	}
+}
+
+// Listen listens on a unix socket at socketPath, replacing any stale socket
+// file which a previous daemon left behind
+func Listen(socketPath string) (net.Listener, error) {
+	// Delete socketPath if it exists to avoid bind failures.
+	err := os.Remove(socketPath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	err = os.MkdirAll(filepath.Dir(socketPath), 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	return net.Listen("unix", socketPath)
+}
+
+// Serve handles connections until the listener is closed
+func (s *Server) Serve(listener net.Listener) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+
+		go s.handle(conn)
+	}
+}
+
+func (s *Server) handle(conn net.Conn) {
+	c := newConnection(conn)
+	decoder := gob.NewDecoder(conn)
+
+	var request link.Request
+	if err := decoder.Decode(&request); err != nil {
+		c.close()
+		return
+	}
+
+	if request.List {
+		c.send(link.Response{Processes: s.ids()})
+		c.close()
+		return
+	}
+
+	p, err := s.process(request)
+	if err != nil {
+		c.send(link.Response{Error: err.Error()})
+		c.close()
+		return
+	}
+
+	if err := c.send(link.Response{}); err != nil {
+		// a spawned process carries on, and can be attached to later
+		c.close()
+		return
+	}
+
+	p.attach(c)
+	defer p.detach(c)
+
+	for {
+		var input link.Input
+		if err := decoder.Decode(&input); err != nil {
+			return
+		}
+
+		if err := p.handle(input); err != nil {
+			return
+		}
+	}
+}
+
+func (s *Server) process(request link.Request) (*process, error) {
+	s.mu.Lock()
+	p, found := s.processes[request.ID]
+	if request.Spawn == nil {
+		s.mu.Unlock()
+
+		if p == nil {
+			return nil, fmt.Errorf("unknown process: %s", request.ID)
+		}
+
+		return p, nil
+	}
+
+	if found {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("process already exists: %s", request.ID)
+	}
+
+	// reserve the ID while the process starts, so that forking does not
+	// hold up other clients
+	s.processes[request.ID] = nil
+	s.mu.Unlock()
+
+	p, err := start(*request.Spawn)
+	if err != nil {
+		s.forget(request.ID)
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.processes[request.ID] = p
+	s.mu.Unlock()
+
+	go func() {
+		p.wait()
+		s.forget(request.ID)
+	}()
+
+	return p, nil
+}
+
+func (s *Server) ids() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids := make([]string, 0, len(s.processes))
+	for id, p := range s.processes {
+		if p != nil {
+			ids = append(ids, id)
+		}
+	}
+
+	sort.Strings(ids)
+	return ids
+}
+
+func (s *Server) forget(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.processes, id)
+}
+
+// connection is a client's connection, which a process's stdout, stderr and
+// exit goroutines all send on
+type connection struct {
+	conn net.Conn
+
+	enc       *gob.Encoder
+	sendMu    sync.Mutex
+	closeOnce sync.Once
+}
+
+func newConnection(conn net.Conn) *connection {
+	return &connection{conn: conn, enc: gob.NewEncoder(conn)}
+}
+
+func (c *connection) send(v interface{}) error {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+
+	return c.enc.Encode(v)
+}
+
+func (c *connection) close() {
+	c.closeOnce.Do(func() {
+		c.conn.Close()
+	})
+}
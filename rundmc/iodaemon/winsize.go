@@ -1,7 +1,6 @@
 package iodaemon
 
 import (
-	"os"
 	"syscall"
 	"unsafe"
 )
@@ -13,10 +12,10 @@ type ttySize struct {
 	Ypixel uint16
 }
 
-func setWinSize(f *os.File, cols int, rows int) error {
+func setWinSize(fd uintptr, cols int, rows int) error {
 	_, _, e := syscall.Syscall6(
 		syscall.SYS_IOCTL,
-		uintptr(f.Fd()),
+		fd,
 		uintptr(syscall.TIOCSWINSZ),
 		uintptr(unsafe.Pointer(&ttySize{uint16(rows), uint16(cols), 0, 0})),
 		0, 0, 0,
@@ -77,7 +77,7 @@ func createTtyPty(windowColumns int, windowRows int) (stdinR, stdinW, stdoutR, s
 	stdoutW = tty
 	stderrW = tty
 
-	setWinSize(stdinW, windowColumns, windowRows)
+	setWinSize(stdinW.Fd(), windowColumns, windowRows)
 
 	return
 }
@@ -2,47 +2,45 @@ package rundmc
 
 import (
 	"bytes"
-	"fmt"
 	"io"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 
+	"github.com/cloudfoundry-incubator/guardian/rundmc/nstar"
 	"github.com/cloudfoundry/gunk/command_runner"
+	"github.com/docker/docker/pkg/reexec"
 	"github.com/pivotal-golang/lager"
 )
 
-type nstar struct {
-	NstarBinPath string
-	TarBinPath   string
-
+type nstarRunner struct {
 	CommandRunner command_runner.CommandRunner
 }
 
-func NewNstarRunner(nstarPath, tarPath string, runner command_runner.CommandRunner) NstarRunner {
-	return &nstar{
-		NstarBinPath:  nstarPath,
-		TarBinPath:    tarPath,
+// NewNstarRunner returns an NstarRunner which re-executes the current binary
+// as an nstar helper (see the nstar package). The binary must call
+// reexec.Init() on start up for this to work.
+func NewNstarRunner(runner command_runner.CommandRunner) NstarRunner {
+	return &nstarRunner{
 		CommandRunner: runner,
 	}
 }
 
-func (n *nstar) StreamIn(logger lager.Logger, pid int, path, user string, tarStream io.Reader) error {
-	buff := new(bytes.Buffer)
-	cmd := exec.Command(n.NstarBinPath, n.TarBinPath, fmt.Sprintf("%d", pid), n.streamUser(user), path)
-	cmd.Stdout = buff
-	cmd.Stderr = buff
+func (n *nstarRunner) StreamIn(logger lager.Logger, pid int, path, user string, tarStream io.Reader) error {
+	errOut := new(bytes.Buffer)
+	cmd := reexec.Command(nstar.StreamInCommand, strconv.Itoa(pid), n.streamUser(user), path)
+	cmd.Stderr = errOut
 	cmd.Stdin = tarStream
 
 	if err := n.CommandRunner.Run(cmd); err != nil {
-		return fmt.Errorf("error streaming in: %v. Output: %s", err, buff.String())
+		return nstar.ParseError(errOut.Bytes(), err)
 	}
 
 	return nil
 }
 
-func (n *nstar) StreamOut(log lager.Logger, pid int, path, user string) (io.ReadCloser, error) {
+func (n *nstarRunner) StreamOut(log lager.Logger, pid int, path, user string) (io.ReadCloser, error) {
 	sourcePath := filepath.Dir(path)
 	compressPath := filepath.Base(path)
 	if strings.HasSuffix(path, "/") {
@@ -56,30 +54,30 @@ func (n *nstar) StreamOut(log lager.Logger, pid int, path, user string) (io.Read
 		return nil, err
 	}
 
-	cmd := exec.Command(n.NstarBinPath, n.TarBinPath, fmt.Sprintf("%d", pid), n.streamUser(user), sourcePath, compressPath)
+	cmd := reexec.Command(nstar.StreamOutCommand, strconv.Itoa(pid), n.streamUser(user), sourcePath, compressPath)
 	cmd.Stdout = writer
 	cmd.Stderr = errOut
 
 	if err := n.CommandRunner.Background(cmd); err != nil {
-		return nil, fmt.Errorf("error streaming out: %v. Output: %s", err, errOut.String())
+		return nil, nstar.ParseError(errOut.Bytes(), err)
 	}
 
 	writer.Close()
 
 	go func() {
 		if err := n.CommandRunner.Wait(cmd); err != nil {
-			log.Error("wait", err, lager.Data{
-				"pid":    pid,
-				"path":   path,
-				"user":   user,
-				"stdout": errOut.String()})
+			log.Error("wait", nstar.ParseError(errOut.Bytes(), err), lager.Data{
+				"pid":  pid,
+				"path": path,
+				"user": user,
+			})
 		}
 	}()
 
 	return reader, nil
 }
 
-func (n *nstar) streamUser(usr string) string {
+func (n *nstarRunner) streamUser(usr string) string {
 	if usr == "" {
 		usr = "root"
 	}
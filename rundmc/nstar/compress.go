@@ -0,0 +1,91 @@
+package nstar
+
+import (
+	"archive/tar"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// Compress writes a tar archive of path, which is relative to dir, to w.
+// Entry names are relative to dir, as with `tar -C dir -cf - path`.
+func Compress(w io.Writer, dir, path string) error {
+	tw := tar.NewWriter(w)
+
+	root := filepath.Join(dir, path)
+	err := filepath.Walk(root, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return &Error{Op: "stat", Path: file, Message: err.Error()}
+		}
+
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			return &Error{Op: "compress", Path: file, Message: err.Error()}
+		}
+
+		if path == "." && rel != "." {
+			rel = "./" + rel
+		}
+
+		return writeEntry(tw, file, rel, info)
+	})
+	if err != nil {
+		return asError("compress", err)
+	}
+
+	if err := tw.Close(); err != nil {
+		return &Error{Op: "write-archive", Message: err.Error()}
+	}
+
+	return nil
+}
+
+func writeEntry(tw *tar.Writer, file, name string, info os.FileInfo) error {
+	var link string
+	if info.Mode()&os.ModeSymlink != 0 {
+		var err error
+		if link, err = os.Readlink(file); err != nil {
+			return &Error{Op: "readlink", Path: file, Message: err.Error()}
+		}
+	}
+
+	hdr, err := tar.FileInfoHeader(info, link)
+	if err != nil {
+		return &Error{Op: "compress", Path: file, Message: err.Error()}
+	}
+
+	hdr.Name = name
+	if info.IsDir() {
+		hdr.Name += "/"
+	}
+
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		hdr.Uid = int(stat.Uid)
+		hdr.Gid = int(stat.Gid)
+	}
+
+	// names are meaningless outside of the container's /etc/passwd
+	hdr.Uname = ""
+	hdr.Gname = ""
+
+	if err := tw.WriteHeader(hdr); err != nil {
+		return &Error{Op: "write-archive", Path: file, Message: err.Error()}
+	}
+
+	if !info.Mode().IsRegular() {
+		return nil
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return &Error{Op: "open", Path: file, Message: err.Error()}
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(tw, f); err != nil {
+		return &Error{Op: "write-archive", Path: file, Message: err.Error()}
+	}
+
+	return nil
+}
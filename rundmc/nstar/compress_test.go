@@ -0,0 +1,85 @@
+package nstar_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry-incubator/guardian/rundmc/nstar"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Compress", func() {
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "nstar-compress")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(os.MkdirAll(filepath.Join(dir, "some-dir", "nested"), 0755)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(dir, "some-dir", "nested", "file"), []byte("hello"), 0644)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	It("archives the path relative to the directory", func() {
+		out := new(bytes.Buffer)
+		Expect(nstar.Compress(out, dir, "some-dir")).To(Succeed())
+
+		Expect(entries(out)).To(Equal(map[string]string{
+			"some-dir/":            "",
+			"some-dir/nested/":     "",
+			"some-dir/nested/file": "hello",
+		}))
+	})
+
+	It("archives the contents of the directory when the path is '.'", func() {
+		out := new(bytes.Buffer)
+		Expect(nstar.Compress(out, filepath.Join(dir, "some-dir"), ".")).To(Succeed())
+
+		Expect(entries(out)).To(HaveKey("./nested/file"))
+	})
+
+	It("records the ownership the files have", func() {
+		out := new(bytes.Buffer)
+		Expect(nstar.Compress(out, dir, "some-dir")).To(Succeed())
+
+		tr := tar.NewReader(out)
+		hdr, err := tr.Next()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(hdr.Uid).To(Equal(os.Getuid()))
+		Expect(hdr.Gid).To(Equal(os.Getgid()))
+	})
+
+	Context("when the path does not exist", func() {
+		It("returns a structured error", func() {
+			err := nstar.Compress(new(bytes.Buffer), dir, "potato")
+			Expect(err).To(BeAssignableToTypeOf(&nstar.Error{}))
+			Expect(err.(*nstar.Error).Op).To(Equal("stat"))
+		})
+	})
+})
+
+func entries(r io.Reader) map[string]string {
+	result := make(map[string]string)
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return result
+		}
+		Expect(err).NotTo(HaveOccurred())
+
+		contents, err := ioutil.ReadAll(tr)
+		Expect(err).NotTo(HaveOccurred())
+		result[hdr.Name] = string(contents)
+	}
+}
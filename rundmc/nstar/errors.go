@@ -0,0 +1,47 @@
+package nstar
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Error describes a failed stream operation. Helpers write it to stderr as
+// JSON, so it survives the process boundary intact.
+type Error struct {
+	Op      string `json:"op"`
+	Path    string `json:"path,omitempty"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("%s: %s", e.Op, e.Message)
+	}
+
+	return fmt.Sprintf("%s %s: %s", e.Op, e.Path, e.Message)
+}
+
+// ParseError recovers the Error written by a failed helper. If the output is
+// not a structured error (for example because the helper could not be
+// started) the raw output and the exit error are reported instead.
+func ParseError(stderr []byte, exitErr error) error {
+	var nstarErr Error
+	if err := json.NewDecoder(bytes.NewReader(stderr)).Decode(&nstarErr); err == nil && nstarErr.Op != "" {
+		return &nstarErr
+	}
+
+	return &Error{
+		Op:      "exec",
+		Message: fmt.Sprintf("%s: %s", exitErr, strings.TrimSpace(string(stderr))),
+	}
+}
+
+func asError(op string, err error) *Error {
+	if nstarErr, ok := err.(*Error); ok {
+		return nstarErr
+	}
+
+	return &Error{Op: op, Message: err.Error()}
+}
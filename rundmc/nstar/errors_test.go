@@ -0,0 +1,24 @@
+package nstar_test
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry-incubator/guardian/rundmc/nstar"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ParseError", func() {
+	It("decodes the structured error written by the helper", func() {
+		err := nstar.ParseError([]byte(`{"op":"mkdir","path":"/foo","message":"permission denied"}`+"\n"), errors.New("exit status 1"))
+		Expect(err).To(Equal(&nstar.Error{Op: "mkdir", Path: "/foo", Message: "permission denied"}))
+		Expect(err).To(MatchError("mkdir /foo: permission denied"))
+	})
+
+	Context("when the output is not a structured error", func() {
+		It("includes the exit error and the raw output", func() {
+			err := nstar.ParseError([]byte("fork/exec: no such file\n"), errors.New("exit status 2"))
+			Expect(err).To(MatchError("exec: exit status 2: fork/exec: no such file"))
+		})
+	})
+})
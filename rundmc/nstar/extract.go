@@ -0,0 +1,261 @@
+package nstar
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// Owner is the uid and gid which extracted files are owned by
+type Owner struct {
+	UID int
+	GID int
+}
+
+// Extract unpacks a (optionally gzipped) tar stream in to dest. Every entry
+// is owned by owner, regardless of the ownership recorded in the archive.
+// Entries may not escape dest, either directly or via a symlink extracted
+// earlier in the same stream.
+func Extract(r io.Reader, dest string, owner Owner) error {
+	stream, err := Decompress(r)
+	if err != nil {
+		return &Error{Op: "decompress", Message: err.Error()}
+	}
+
+	tr := tar.NewReader(stream)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return &Error{Op: "read-archive", Message: err.Error()}
+		}
+
+		if err := extractEntry(tr, hdr, dest, owner); err != nil {
+			return err
+		}
+	}
+}
+
+// Decompress transparently gunzips r if it starts with the gzip magic number
+func Decompress(r io.Reader) (io.Reader, error) {
+	buffered := bufio.NewReader(r)
+
+	magic, err := buffered.Peek(2)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	if len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		return gzip.NewReader(buffered)
+	}
+
+	return buffered, nil
+}
+
+func extractEntry(tr io.Reader, hdr *tar.Header, dest string, owner Owner) error {
+	target, err := securePath(dest, hdr.Name)
+	if err != nil {
+		return err
+	}
+
+	if target == dest && hdr.Typeflag != tar.TypeDir {
+		return &Error{Op: "extract", Path: hdr.Name, Message: "entry would replace the destination directory"}
+	}
+
+	if err := MkdirAs(filepath.Dir(target), 0755, owner); err != nil {
+		return err
+	}
+
+	mode := entryMode(hdr)
+
+	switch hdr.Typeflag {
+	case tar.TypeDir:
+		if info, err := os.Lstat(target); err == nil && !info.IsDir() {
+			return &Error{Op: "extract", Path: hdr.Name, Message: "a non-directory already exists at this path"}
+		}
+
+		if err := os.MkdirAll(target, mode); err != nil {
+			return &Error{Op: "mkdir", Path: hdr.Name, Message: err.Error()}
+		}
+
+	case tar.TypeReg, tar.TypeRegA:
+		if err := removeIfNotDir(target); err != nil {
+			return err
+		}
+
+		f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+		if err != nil {
+			return &Error{Op: "create", Path: hdr.Name, Message: err.Error()}
+		}
+
+		_, err = io.Copy(f, tr)
+		f.Close()
+		if err != nil {
+			return &Error{Op: "write", Path: hdr.Name, Message: err.Error()}
+		}
+
+	case tar.TypeSymlink:
+		if err := removeIfNotDir(target); err != nil {
+			return err
+		}
+
+		if err := os.Symlink(hdr.Linkname, target); err != nil {
+			return &Error{Op: "symlink", Path: hdr.Name, Message: err.Error()}
+		}
+
+	case tar.TypeLink:
+		source, err := securePath(dest, hdr.Linkname)
+		if err != nil {
+			return err
+		}
+
+		if err := removeIfNotDir(target); err != nil {
+			return err
+		}
+
+		if err := os.Link(source, target); err != nil {
+			return &Error{Op: "link", Path: hdr.Name, Message: err.Error()}
+		}
+
+	case tar.TypeChar, tar.TypeBlock, tar.TypeFifo:
+		if err := removeIfNotDir(target); err != nil {
+			return err
+		}
+
+		if err := syscall.Mknod(target, deviceMode(hdr), int(deviceNumber(hdr))); err != nil {
+			return &Error{Op: "mknod", Path: hdr.Name, Message: err.Error()}
+		}
+
+	default:
+		// pax headers and the like are consumed by archive/tar; anything else
+		// is ignored, as tar itself would do
+		return nil
+	}
+
+	if err := os.Lchown(target, owner.UID, owner.GID); err != nil {
+		return &Error{Op: "chown", Path: hdr.Name, Message: err.Error()}
+	}
+
+	if hdr.Typeflag != tar.TypeSymlink {
+		// chown clears the setuid and setgid bits, and the umask applies on
+		// create, so the mode is only final now
+		if err := os.Chmod(target, mode); err != nil {
+			return &Error{Op: "chmod", Path: hdr.Name, Message: err.Error()}
+		}
+
+		if err := os.Chtimes(target, hdr.ModTime, hdr.ModTime); err != nil {
+			return &Error{Op: "chtimes", Path: hdr.Name, Message: err.Error()}
+		}
+	}
+
+	return nil
+}
+
+// securePath returns the location of name beneath dest. It refuses paths
+// which traverse an existing symlink beneath dest, so that an archive cannot
+// plant a link and then write through it.
+func securePath(dest, name string) (string, error) {
+	rel := filepath.Clean(string(filepath.Separator) + name)
+	target := filepath.Join(dest, rel)
+
+	parent := dest
+	for _, component := range strings.Split(filepath.Dir(rel), string(filepath.Separator)) {
+		if component == "" {
+			continue
+		}
+
+		parent = filepath.Join(parent, component)
+		info, err := os.Lstat(parent)
+		if os.IsNotExist(err) {
+			break
+		}
+
+		if err != nil {
+			return "", &Error{Op: "stat", Path: name, Message: err.Error()}
+		}
+
+		if info.Mode()&os.ModeSymlink != 0 {
+			return "", &Error{Op: "extract", Path: name, Message: "refusing to extract through a symlink"}
+		}
+	}
+
+	return target, nil
+}
+
+// entryMode returns the entry's permissions, along with its setuid, setgid
+// and sticky bits
+func entryMode(hdr *tar.Header) os.FileMode {
+	return hdr.FileInfo().Mode() & (os.ModePerm | os.ModeSetuid | os.ModeSetgid | os.ModeSticky)
+}
+
+func removeIfNotDir(path string) error {
+	info, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	if err != nil {
+		return &Error{Op: "stat", Path: path, Message: err.Error()}
+	}
+
+	if info.IsDir() {
+		return &Error{Op: "extract", Path: path, Message: "a directory already exists at this path"}
+	}
+
+	if err := os.Remove(path); err != nil {
+		return &Error{Op: "remove", Path: path, Message: err.Error()}
+	}
+
+	return nil
+}
+
+func deviceMode(hdr *tar.Header) uint32 {
+	mode := uint32(os.FileMode(hdr.Mode).Perm())
+	switch hdr.Typeflag {
+	case tar.TypeChar:
+		return mode | syscall.S_IFCHR
+	case tar.TypeBlock:
+		return mode | syscall.S_IFBLK
+	default:
+		return mode | syscall.S_IFIFO
+	}
+}
+
+// deviceNumber encodes major and minor numbers the same way as glibc's
+// makedev
+func deviceNumber(hdr *tar.Header) uint64 {
+	major, minor := uint64(hdr.Devmajor), uint64(hdr.Devminor)
+	return (minor & 0xff) | ((major & 0xfff) << 8) | ((minor &^ 0xff) << 12) | ((major &^ 0xfff) << 32)
+}
+
+// MkdirAs creates path and any missing parents. Only newly created
+// directories are chowned; existing ones keep their ownership.
+func MkdirAs(path string, mode os.FileMode, owner Owner) error {
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+
+	if parent := filepath.Dir(path); parent != path {
+		if err := MkdirAs(parent, mode, owner); err != nil {
+			return err
+		}
+	}
+
+	if err := os.Mkdir(path, mode); err != nil && !os.IsExist(err) {
+		return &Error{Op: "mkdir", Path: path, Message: err.Error()}
+	}
+
+	if err := os.Chown(path, owner.UID, owner.GID); err != nil {
+		return &Error{Op: "chown", Path: path, Message: err.Error()}
+	}
+
+	return nil
+}
@@ -0,0 +1,162 @@
+package nstar_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/cloudfoundry-incubator/guardian/rundmc/nstar"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Extract", func() {
+	var (
+		dest  string
+		owner nstar.Owner
+	)
+
+	BeforeEach(func() {
+		var err error
+		dest, err = ioutil.TempDir("", "nstar-extract")
+		Expect(err).NotTo(HaveOccurred())
+
+		owner = nstar.Owner{UID: os.Getuid(), GID: os.Getgid()}
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(dest)).To(Succeed())
+	})
+
+	It("extracts files and directories in to the destination", func() {
+		Expect(nstar.Extract(archive(
+			entry{name: "some-dir/", mode: 0700},
+			entry{name: "some-dir/some-file", contents: "hello", mode: 0644},
+			entry{name: "some-link", link: "some-dir/some-file"},
+		), dest, owner)).To(Succeed())
+
+		info, err := os.Stat(filepath.Join(dest, "some-dir"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Mode().Perm()).To(Equal(os.FileMode(0700)))
+
+		Expect(ioutil.ReadFile(filepath.Join(dest, "some-dir", "some-file"))).To(Equal([]byte("hello")))
+		Expect(os.Readlink(filepath.Join(dest, "some-link"))).To(Equal("some-dir/some-file"))
+	})
+
+	It("creates missing parent directories", func() {
+		Expect(nstar.Extract(archive(
+			entry{name: "a/b/c", contents: "deep", mode: 0644},
+		), dest, owner)).To(Succeed())
+
+		Expect(ioutil.ReadFile(filepath.Join(dest, "a", "b", "c"))).To(Equal([]byte("deep")))
+	})
+
+	It("chowns extracted entries to the owner", func() {
+		Expect(nstar.Extract(archive(
+			entry{name: "some-file", contents: "hello", mode: 0644, uid: 12345},
+		), dest, owner)).To(Succeed())
+
+		info, err := os.Stat(filepath.Join(dest, "some-file"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Sys().(*syscall.Stat_t).Uid).To(BeEquivalentTo(owner.UID))
+		Expect(info.Sys().(*syscall.Stat_t).Gid).To(BeEquivalentTo(owner.GID))
+	})
+
+	It("keeps the setuid, setgid and sticky bits", func() {
+		Expect(nstar.Extract(archive(
+			entry{name: "sticky/", mode: 01777},
+			entry{name: "setuid", contents: "#!/bin/sh", mode: 04755},
+			entry{name: "setgid", contents: "#!/bin/sh", mode: 02755},
+		), dest, owner)).To(Succeed())
+
+		info, err := os.Stat(filepath.Join(dest, "sticky"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Mode() & (os.ModePerm | os.ModeSticky)).To(Equal(os.ModeSticky | 0777))
+
+		info, err = os.Stat(filepath.Join(dest, "setuid"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Mode() & (os.ModePerm | os.ModeSetuid)).To(Equal(os.ModeSetuid | 0755))
+
+		info, err = os.Stat(filepath.Join(dest, "setgid"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Mode() & (os.ModePerm | os.ModeSetgid)).To(Equal(os.ModeSetgid | 0755))
+	})
+
+	It("accepts gzipped archives", func() {
+		gzipped := new(bytes.Buffer)
+		gz := gzip.NewWriter(gzipped)
+		_, err := gz.Write(archive(entry{name: "some-file", contents: "zipped", mode: 0644}).Bytes())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(gz.Close()).To(Succeed())
+
+		Expect(nstar.Extract(gzipped, dest, owner)).To(Succeed())
+		Expect(ioutil.ReadFile(filepath.Join(dest, "some-file"))).To(Equal([]byte("zipped")))
+	})
+
+	It("keeps entries with '..' in their path inside the destination", func() {
+		Expect(nstar.Extract(archive(
+			entry{name: "../../escaped", contents: "nope", mode: 0644},
+		), dest, owner)).To(Succeed())
+
+		Expect(filepath.Join(dest, "escaped")).To(BeAnExistingFile())
+		Expect(filepath.Join(filepath.Dir(dest), "escaped")).NotTo(BeAnExistingFile())
+	})
+
+	It("refuses to extract through a symlink", func() {
+		outside, err := ioutil.TempDir("", "nstar-outside")
+		Expect(err).NotTo(HaveOccurred())
+		defer os.RemoveAll(outside)
+
+		err = nstar.Extract(archive(
+			entry{name: "sneaky", link: outside},
+			entry{name: "sneaky/file", contents: "nope", mode: 0644},
+		), dest, owner)
+		Expect(err).To(MatchError(ContainSubstring("refusing to extract through a symlink")))
+		Expect(filepath.Join(outside, "file")).NotTo(BeAnExistingFile())
+	})
+
+	Context("when the archive is corrupt", func() {
+		It("returns a structured error", func() {
+			err := nstar.Extract(bytes.NewBufferString("this is not a tarball, not even slightly"), dest, owner)
+			Expect(err).To(BeAssignableToTypeOf(&nstar.Error{}))
+			Expect(err.(*nstar.Error).Op).To(Equal("read-archive"))
+		})
+	})
+})
+
+type entry struct {
+	name     string
+	contents string
+	link     string
+	mode     int64
+	uid      int
+}
+
+func archive(entries ...entry) *bytes.Buffer {
+	buffer := new(bytes.Buffer)
+	tw := tar.NewWriter(buffer)
+
+	for _, e := range entries {
+		hdr := &tar.Header{Name: e.name, Mode: e.mode, Uid: e.uid, Size: int64(len(e.contents))}
+		switch {
+		case e.link != "":
+			hdr.Typeflag = tar.TypeSymlink
+			hdr.Linkname = e.link
+		case e.name[len(e.name)-1] == '/':
+			hdr.Typeflag = tar.TypeDir
+		default:
+			hdr.Typeflag = tar.TypeReg
+		}
+
+		Expect(tw.WriteHeader(hdr)).To(Succeed())
+		_, err := tw.Write([]byte(e.contents))
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	Expect(tw.Close()).To(Succeed())
+	return buffer
+}
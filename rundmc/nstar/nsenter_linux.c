@@ -0,0 +1,88 @@
+#define _GNU_SOURCE
+#include <errno.h>
+#include <fcntl.h>
+#include <sched.h>
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+#include <unistd.h>
+
+/* keep in sync with StreamInCommand and StreamOutCommand */
+static const char *helpers[] = {"nstar-stream-in", "nstar-stream-out", NULL};
+
+/* fail reports an error as the JSON that ParseError expects */
+static void fail(const char *op, const char *path) {
+  fprintf(stderr, "{\"op\":\"%s\",\"path\":\"%s\",\"message\":\"%s\"}\n", op, path, strerror(errno));
+  exit(1);
+}
+
+/*
+ * nstar_enter moves a stream helper, whose argv is <helper> <pid> ..., in to
+ * the mount and user namespaces of the process with the given pid. setns(2)
+ * refuses both to multi-threaded processes, so this has to happen before the
+ * Go runtime starts its threads, i.e. in a constructor.
+ */
+__attribute__((constructor)) static void nstar_enter(int argc, char **argv) {
+  const char **helper;
+  char mntns[64], userns[64];
+  char *end;
+  long pid;
+  int mntfd, userfd;
+
+  if(argc < 2) {
+    return;
+  }
+
+  for(helper = helpers; *helper != NULL; helper++) {
+    if(strcmp(argv[0], *helper) == 0) {
+      break;
+    }
+  }
+
+  if(*helper == NULL) {
+    return;
+  }
+
+  pid = strtol(argv[1], &end, 10);
+  if(*argv[1] == '\0' || *end != '\0' || pid <= 0) {
+    return; /* the helper reports the invalid pid */
+  }
+
+  snprintf(mntns, sizeof(mntns), "/proc/%ld/ns/mnt", pid);
+  snprintf(userns, sizeof(userns), "/proc/%ld/ns/user", pid);
+
+  /* open both first, as /proc is the container's once in its mount namespace */
+  mntfd = open(mntns, O_RDONLY | O_CLOEXEC);
+  if(mntfd == -1) {
+    fail("open", mntns);
+  }
+
+  userfd = open(userns, O_RDONLY | O_CLOEXEC);
+  if(userfd == -1) {
+    fail("open", userns);
+  }
+
+  if(setns(mntfd, CLONE_NEWNS) == -1) {
+    fail("setns", mntns);
+  }
+  close(mntfd);
+
+  /* setns(2) gives EINVAL when the container shares guardian's user
+   * namespace, which is fine: ids need no translating then. Anything else
+   * would leave the helper as the host's root, so give up. */
+  if(setns(userfd, CLONE_NEWUSER) == -1) {
+    if(errno != EINVAL) {
+      fail("setns", userns);
+    }
+  } else {
+    /* guardian's root is not mapped in the container, so become its root */
+    if(setgid(0) == -1) {
+      fail("setgid", userns);
+    }
+
+    if(setuid(0) == -1) {
+      fail("setuid", userns);
+    }
+  }
+  close(userfd);
+}
@@ -0,0 +1,39 @@
+package nstar
+
+// nsenter_linux.c enters the container's namespaces before the Go runtime
+// starts.
+
+/*
+#include <grp.h>
+#include <unistd.h>
+*/
+import "C"
+
+// become switches the helper to the given user. libc changes the
+// credentials of every thread in the process, which the syscall package
+// does not.
+func become(uid, gid int, groups []int) error {
+	gids := make([]C.gid_t, len(groups))
+	for i, group := range groups {
+		gids[i] = C.gid_t(group)
+	}
+
+	var list *C.gid_t
+	if len(gids) > 0 {
+		list = &gids[0]
+	}
+
+	if rv, err := C.setgroups(C.size_t(len(gids)), list); rv != 0 {
+		return &Error{Op: "setgroups", Message: err.Error()}
+	}
+
+	if rv, err := C.setgid(C.gid_t(gid)); rv != 0 {
+		return &Error{Op: "setgid", Message: err.Error()}
+	}
+
+	if rv, err := C.setuid(C.uid_t(uid)); rv != 0 {
+		return &Error{Op: "setuid", Message: err.Error()}
+	}
+
+	return nil
+}
@@ -0,0 +1,148 @@
+// Package nstar streams tar archives in to and out of a running container.
+//
+// The guardian binary re-executes itself (see the docker reexec package) as
+// a short-lived helper which enters the mount and user namespaces of the
+// container's init process (see nsenter_linux.c), looks the requested user up
+// in the container's /etc/passwd, and then reads or writes the archive as
+// that user, so a stream can only touch what the user could touch from inside
+// the container. The kernel maps ownership to and from host ids.
+package nstar
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/docker/docker/pkg/reexec"
+	"github.com/opencontainers/runc/libcontainer/user"
+)
+
+const (
+	StreamInCommand  = "nstar-stream-in"
+	StreamOutCommand = "nstar-stream-out"
+)
+
+func init() {
+	reexec.Register(StreamInCommand, helper(streamIn))
+	reexec.Register(StreamOutCommand, helper(streamOut))
+}
+
+// helper adapts a stream function to a reexec entrypoint. Any error is
+// written to stderr as JSON so that it can be recovered with ParseError.
+func helper(fn func(args []string) error) func() {
+	return func() {
+		if err := fn(os.Args[1:]); err != nil {
+			json.NewEncoder(os.Stderr).Encode(asError("nstar", err))
+			os.Exit(1)
+		}
+
+		os.Exit(0)
+	}
+}
+
+// streamIn expects <pid> <user> <destination> and extracts stdin in to the
+// destination directory, creating it if needed.
+func streamIn(args []string) error {
+	username, destination, err := parseArgs(StreamInCommand, args, 3)
+	if err != nil {
+		return err
+	}
+
+	usr, err := lookup(username)
+	if err != nil {
+		return err
+	}
+
+	// the destination is created before becoming the user, so it can be made
+	// where the user could not make it, but it is owned by the user, as is
+	// everything extracted in to it
+	destination = usr.resolve(destination)
+	if err := MkdirAs(destination, 0755, usr.owner); err != nil {
+		return err
+	}
+
+	if err := usr.become(); err != nil {
+		return err
+	}
+
+	return Extract(os.Stdin, destination, usr.owner)
+}
+
+// streamOut expects <pid> <user> <source directory> <path> and writes an
+// archive of path, relative to the source directory, to stdout.
+func streamOut(args []string) error {
+	username, source, err := parseArgs(StreamOutCommand, args, 4)
+	if err != nil {
+		return err
+	}
+
+	usr, err := lookup(username)
+	if err != nil {
+		return err
+	}
+
+	if err := usr.become(); err != nil {
+		return err
+	}
+
+	return Compress(os.Stdout, usr.resolve(source), args[3])
+}
+
+// parseArgs returns the user and path arguments. The pid is only used by
+// nsenter_linux.c, which has entered its namespaces by now; it is checked
+// here because the constructor leaves an invalid pid for the helper to
+// report.
+func parseArgs(name string, args []string, n int) (username, path string, err error) {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	if err := flags.Parse(args); err != nil {
+		return "", "", &Error{Op: "parse-args", Message: err.Error()}
+	}
+
+	if flags.NArg() != n {
+		return "", "", &Error{Op: "parse-args", Message: fmt.Sprintf("expected %d arguments, got %d", n, flags.NArg())}
+	}
+
+	if pid, err := strconv.Atoi(flags.Arg(0)); err != nil || pid <= 0 {
+		return "", "", &Error{Op: "parse-args", Message: fmt.Sprintf("invalid pid: %s", flags.Arg(0))}
+	}
+
+	return flags.Arg(1), flags.Arg(2), nil
+}
+
+type containerUser struct {
+	home   string
+	owner  Owner
+	groups []int
+}
+
+// lookup finds the user in the container's /etc/passwd; the helper is in the
+// container's mount namespace by now
+func lookup(username string) (*containerUser, error) {
+	u, err := user.GetExecUserPath(username, &user.ExecUser{Home: "/"}, "/etc/passwd", "/etc/group")
+	if err != nil {
+		return nil, &Error{Op: "lookup-user", Path: username, Message: err.Error()}
+	}
+
+	return &containerUser{
+		home:   u.Home,
+		owner:  Owner{UID: u.Uid, GID: u.Gid},
+		groups: u.Sgids,
+	}, nil
+}
+
+func (u *containerUser) become() error {
+	return become(u.owner.UID, u.owner.GID, u.groups)
+}
+
+// resolve makes relative paths relative to the user's home directory, which
+// is where the original tar-based implementation ran from.
+func (u *containerUser) resolve(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+
+	return filepath.Join(u.home, path)
+}
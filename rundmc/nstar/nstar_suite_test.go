@@ -0,0 +1,19 @@
+package nstar_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+
+	"github.com/docker/docker/pkg/reexec"
+)
+
+func TestNstar(t *testing.T) {
+	if reexec.Init() {
+		return
+	}
+
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Nstar Suite")
+}
@@ -0,0 +1,173 @@
+package nstar_test
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"syscall"
+
+	"github.com/cloudfoundry-incubator/guardian/rundmc/nstar"
+	"github.com/docker/docker/pkg/reexec"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Stream helpers", func() {
+	var (
+		dir    string
+		nobody *user.User
+	)
+
+	// the helpers enter the namespaces of the given process, so streaming
+	// to and from the test's own process works on the host's filesystem
+	helper := func(command string, stdin io.Reader, args ...string) (*bytes.Buffer, error) {
+		stdout := new(bytes.Buffer)
+		stderr := new(bytes.Buffer)
+
+		cmd := reexec.Command(append([]string{command, strconv.Itoa(os.Getpid()), "nobody"}, args...)...)
+		cmd.Stdin = stdin
+		cmd.Stdout = stdout
+		cmd.Stderr = stderr
+
+		if err := cmd.Run(); err != nil {
+			return stdout, nstar.ParseError(stderr.Bytes(), err)
+		}
+
+		return stdout, nil
+	}
+
+	BeforeEach(func() {
+		if os.Getuid() != 0 {
+			Skip("the stream helpers must be run as root")
+		}
+
+		var err error
+		nobody, err = user.Lookup("nobody")
+		Expect(err).NotTo(HaveOccurred())
+
+		dir, err = ioutil.TempDir("", "nstar-helpers")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.Chmod(dir, 0755)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	Describe("streaming in", func() {
+		It("creates the destination for the user, and extracts in to it as the user", func() {
+			destination := filepath.Join(dir, "some-destination")
+			_, err := helper(nstar.StreamInCommand, archive(entry{name: "some-file", contents: "hello", mode: 0644}), destination)
+			Expect(err).NotTo(HaveOccurred())
+
+			info, err := os.Stat(filepath.Join(destination, "some-file"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(strconv.Itoa(int(info.Sys().(*syscall.Stat_t).Uid))).To(Equal(nobody.Uid))
+			Expect(strconv.Itoa(int(info.Sys().(*syscall.Stat_t).Gid))).To(Equal(nobody.Gid))
+		})
+
+		It("can't write where the user can't", func() {
+			_, err := helper(nstar.StreamInCommand, archive(entry{name: "some-file", contents: "hello", mode: 0644}), dir)
+			Expect(err).To(MatchError(ContainSubstring("permission denied")))
+			Expect(filepath.Join(dir, "some-file")).NotTo(BeAnExistingFile())
+		})
+	})
+
+	Describe("streaming out", func() {
+		It("can't read what the user can't", func() {
+			Expect(ioutil.WriteFile(filepath.Join(dir, "secret"), []byte("shh"), 0600)).To(Succeed())
+
+			stdout, err := helper(nstar.StreamOutCommand, nil, dir, "secret")
+			Expect(err).To(MatchError(ContainSubstring("permission denied")))
+			Expect(stdout.String()).NotTo(ContainSubstring("shh"))
+		})
+	})
+
+	Context("when the process is in its own mount and pid namespaces", func() {
+		var (
+			mountpoint string
+			container  *exec.Cmd
+		)
+
+		BeforeEach(func() {
+			mountpoint = filepath.Join(dir, "mnt")
+			Expect(os.Mkdir(mountpoint, 0755)).To(Succeed())
+
+			// the tmpfs and /proc are only visible in the process's mount
+			// namespace, and /proc/self doesn't resolve there for the helper
+			container = exec.Command("sh", "-c", fmt.Sprintf(
+				"mount --make-rprivate / && mount -t proc proc /proc && mount -t tmpfs -o mode=0777 tmpfs %s && echo ready && exec sleep 1000",
+				mountpoint,
+			))
+			container.SysProcAttr = &syscall.SysProcAttr{Cloneflags: syscall.CLONE_NEWNS | syscall.CLONE_NEWPID}
+
+			stdout, err := container.StdoutPipe()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(container.Start()).To(Succeed())
+
+			ready, err := bufio.NewReader(stdout).ReadString('\n')
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ready).To(Equal("ready\n"))
+		})
+
+		AfterEach(func() {
+			container.Process.Kill()
+			container.Wait()
+		})
+
+		helperIn := func(command string, stdin io.Reader, args ...string) (*bytes.Buffer, error) {
+			stdout := new(bytes.Buffer)
+			stderr := new(bytes.Buffer)
+
+			cmd := reexec.Command(append([]string{command, strconv.Itoa(container.Process.Pid), "nobody"}, args...)...)
+			cmd.Stdin = stdin
+			cmd.Stdout = stdout
+			cmd.Stderr = stderr
+
+			if err := cmd.Run(); err != nil {
+				return stdout, nstar.ParseError(stderr.Bytes(), err)
+			}
+
+			return stdout, nil
+		}
+
+		It("streams in to the process's filesystem", func() {
+			destination := filepath.Join(mountpoint, "some-destination")
+			_, err := helperIn(nstar.StreamInCommand, archive(entry{name: "some-file", contents: "hello", mode: 0644}), destination)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(filepath.Join(destination, "some-file")).NotTo(BeAnExistingFile())
+
+			inContainer := filepath.Join("/proc", strconv.Itoa(container.Process.Pid), "root", destination, "some-file")
+			Expect(ioutil.ReadFile(inContainer)).To(Equal([]byte("hello")))
+		})
+
+		It("streams out of the process's filesystem", func() {
+			_, err := helperIn(nstar.StreamInCommand, archive(entry{name: "some-file", contents: "hello", mode: 0644}), mountpoint)
+			Expect(err).NotTo(HaveOccurred())
+
+			stdout, err := helperIn(nstar.StreamOutCommand, nil, mountpoint, "some-file")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(entries(stdout)).To(HaveKeyWithValue("some-file", "hello"))
+		})
+	})
+
+	It("reports a process which does not exist", func() {
+		cmd := reexec.Command(nstar.StreamInCommand, "999999999", "nobody", dir)
+		stderr := new(bytes.Buffer)
+		cmd.Stderr = stderr
+
+		runErr := cmd.Run()
+		Expect(runErr).To(HaveOccurred())
+
+		err := nstar.ParseError(stderr.Bytes(), runErr)
+		Expect(err).To(MatchError(ContainSubstring("/proc/999999999/ns/mnt")))
+	})
+})
@@ -7,8 +7,10 @@ import (
 	"os/exec"
 
 	"github.com/cloudfoundry-incubator/guardian/rundmc"
+	nstarhelper "github.com/cloudfoundry-incubator/guardian/rundmc/nstar"
 	"github.com/cloudfoundry/gunk/command_runner/fake_command_runner"
 	. "github.com/cloudfoundry/gunk/command_runner/fake_command_runner/matchers"
+	"github.com/docker/docker/pkg/reexec"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/onsi/gomega/gbytes"
@@ -23,11 +25,7 @@ var _ = Describe("Nstar", func() {
 
 	BeforeEach(func() {
 		fakeCommandRunner = fake_command_runner.New()
-		nstar = rundmc.NewNstarRunner(
-			"path-to-nstar",
-			"path-to-tar",
-			fakeCommandRunner,
-		)
+		nstar = rundmc.NewNstarRunner(fakeCommandRunner)
 	})
 
 	Describe("StreamIn", func() {
@@ -44,9 +42,8 @@ var _ = Describe("Nstar", func() {
 
 			It("executes the nstar command with the right arguments", func() {
 				Expect(fakeCommandRunner).To(HaveExecutedSerially(fake_command_runner.CommandSpec{
-					Path: "path-to-nstar",
+					Path: reexec.Self(),
 					Args: []string{
-						"path-to-tar",
 						"12",
 						"some-user",
 						"some-path",
@@ -54,26 +51,38 @@ var _ = Describe("Nstar", func() {
 				}))
 			})
 
+			It("runs the stream-in helper", func() {
+				Expect(fakeCommandRunner.ExecutedCommands()[0].Args[0]).To(Equal(nstarhelper.StreamInCommand))
+			})
+
 			It("attaches the tarStream reader to stdin", func() {
 				Expect(fakeCommandRunner.ExecutedCommands()[0].Stdin).To(Equal(someStream))
 			})
 		})
 
 		Context("when it fails", func() {
-			It("returns the contents of stdout and error on failure", func() {
+			It("returns the structured error written by the helper", func() {
 				fakeCommandRunner.WhenRunning(fake_command_runner.CommandSpec{}, func(cmd *exec.Cmd) error {
-					cmd.Stderr.Write([]byte("some error output"))
-					cmd.Stdout.Write([]byte("some std output"))
-					return errors.New("someerror")
+					cmd.Stderr.Write([]byte(`{"op":"mkdir","path":"some-path","message":"permission denied"}`))
+					return errors.New("exit status 1")
 				})
 
 				Expect(nstar.StreamIn(lagertest.NewTestLogger("test"), 12, "some-path", "some-user", someStream)).To(
-					MatchError(ContainSubstring("some error output")),
+					Equal(&nstarhelper.Error{Op: "mkdir", Path: "some-path", Message: "permission denied"}),
 				)
+			})
 
-				Expect(nstar.StreamIn(lagertest.NewTestLogger("test"), 12, "some-path", "some-user", someStream)).To(
-					MatchError(ContainSubstring("some std output")),
-				)
+			Context("and the helper did not produce a structured error", func() {
+				It("returns the contents of stderr", func() {
+					fakeCommandRunner.WhenRunning(fake_command_runner.CommandSpec{}, func(cmd *exec.Cmd) error {
+						cmd.Stderr.Write([]byte("some error output"))
+						return errors.New("someerror")
+					})
+
+					Expect(nstar.StreamIn(lagertest.NewTestLogger("test"), 12, "some-path", "some-user", someStream)).To(
+						MatchError(ContainSubstring("some error output")),
+					)
+				})
 			})
 		})
 
@@ -96,9 +105,8 @@ var _ = Describe("Nstar", func() {
 
 				Expect(nstar.StreamIn(lagertest.NewTestLogger("test"), 12, "some-path", "", buffer)).To(Succeed())
 				Expect(fakeCommandRunner).To(HaveExecutedSerially(fake_command_runner.CommandSpec{
-					Path: "path-to-nstar",
+					Path: reexec.Self(),
 					Args: []string{
-						"path-to-tar",
 						"12",
 						"root",
 						"some-path",
@@ -122,15 +130,15 @@ var _ = Describe("Nstar", func() {
 
 			reader, err := nstar.StreamOut(lagertest.NewTestLogger("test"), 12, "some-dir/some-file", "some-user")
 			Expect(err).ToNot(HaveOccurred())
+			Expect(fakeCommandRunner.BackgroundedCommands()[0].Args[0]).To(Equal(nstarhelper.StreamOutCommand))
 
 			bytes, err := ioutil.ReadAll(reader)
 			Expect(err).ToNot(HaveOccurred())
 			Expect(string(bytes)).To(Equal("the-compressed-content"))
 
 			Expect(fakeCommandRunner).To(HaveBackgrounded(fake_command_runner.CommandSpec{
-				Path: "path-to-nstar",
+				Path: reexec.Self(),
 				Args: []string{
-					"path-to-tar",
 					"12",
 					"some-user",
 					"some-dir",
@@ -146,9 +154,8 @@ var _ = Describe("Nstar", func() {
 
 				Expect(fakeCommandRunner).To(HaveBackgrounded(
 					fake_command_runner.CommandSpec{
-						Path: "path-to-nstar",
+						Path: reexec.Self(),
 						Args: []string{
-							"path-to-tar",
 							"12",
 							"some-user",
 							"some-path/directory/dst/",
@@ -194,9 +201,8 @@ var _ = Describe("Nstar", func() {
 				Expect(err).ToNot(HaveOccurred())
 
 				Expect(fakeCommandRunner).To(HaveBackgrounded(fake_command_runner.CommandSpec{
-					Path: "path-to-nstar",
+					Path: reexec.Self(),
 					Args: []string{
-						"path-to-tar",
 						"12",
 						"root",
 						"some-dir",
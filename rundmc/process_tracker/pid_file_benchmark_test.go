@@ -0,0 +1,59 @@
+package process_tracker_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cloudfoundry-incubator/guardian/rundmc/process_tracker"
+	"github.com/pivotal-golang/clock"
+)
+
+// The benchmarks measure how long Run waits for a pid file which the runtime
+// writes a millisecond after it is asked for, e.g.
+//
+//	go test -run '^$' -bench PidFile ./rundmc/process_tracker
+
+func BenchmarkPidFileReader(b *testing.B) {
+	benchmarkPidGetter(b, &process_tracker.PidFileReader{
+		Clock:         clock.NewClock(),
+		Timeout:       10 * time.Second,
+		SleepInterval: 100 * time.Millisecond,
+	})
+}
+
+func BenchmarkPidFileWatcher(b *testing.B) {
+	benchmarkPidGetter(b, &process_tracker.PidFileWatcher{
+		Timeout: 10 * time.Second,
+		Fallback: &process_tracker.PidFileReader{
+			Clock:         clock.NewClock(),
+			Timeout:       10 * time.Second,
+			SleepInterval: 100 * time.Millisecond,
+		},
+	})
+}
+
+func benchmarkPidGetter(b *testing.B, pidGetter process_tracker.PidGetter) {
+	processesDir, err := ioutil.TempDir("", "processes")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer os.RemoveAll(processesDir)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		pidFilePath := filepath.Join(processesDir, "some-process.pid")
+		os.Remove(pidFilePath)
+
+		go func() {
+			time.Sleep(time.Millisecond)
+			ioutil.WriteFile(pidFilePath, []byte("5621"), 0644)
+		}()
+
+		if _, err := pidGetter.Pid(pidFilePath); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
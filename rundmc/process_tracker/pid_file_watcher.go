@@ -0,0 +1,45 @@
+package process_tracker
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PidFileWatcher waits for the runtime to write a pid file by watching the
+// directory it is written to, rather than by polling for it, so that the pid
+// can be read as soon as it is written. Fallback is used instead when the
+// directory can't be watched, e.g. because the host has run out of inotify
+// watches or doesn't have inotify at all.
+type PidFileWatcher struct {
+	Timeout  time.Duration
+	Fallback PidGetter
+}
+
+// readPid returns the pid in the pid file, or 0 while the file is missing or
+// has not been written to yet
+func readPid(pidFilePath string) (int, error) {
+	contents, err := ioutil.ReadFile(pidFilePath)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+
+	if err != nil {
+		return 0, err
+	}
+
+	trimmed := strings.TrimSpace(string(contents))
+	if trimmed == "" {
+		return 0, nil
+	}
+
+	pid, err := strconv.Atoi(trimmed)
+	if err != nil {
+		return 0, fmt.Errorf("parsing pid file contents: %s", err)
+	}
+
+	return pid, nil
+}
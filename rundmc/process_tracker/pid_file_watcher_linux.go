@@ -0,0 +1,103 @@
+package process_tracker
+
+import (
+	"fmt"
+	"path/filepath"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// runc either writes the pid file in place or renames it in to place
+const pidFileWritten = syscall.IN_CLOSE_WRITE | syscall.IN_MOVED_TO
+
+func (w *PidFileWatcher) Pid(pidFilePath string) (int, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC)
+	if err != nil {
+		return w.Fallback.Pid(pidFilePath)
+	}
+	// closing an inotify instance waits for the kernel to finish with its
+	// watches, which takes milliseconds, so don't make the caller wait too
+	defer func() { go syscall.Close(fd) }()
+
+	wd, err := syscall.InotifyAddWatch(fd, filepath.Dir(pidFilePath), pidFileWritten)
+	if err != nil {
+		return w.Fallback.Pid(pidFilePath)
+	}
+
+	written := make(chan string)
+	go readEvents(fd, written)
+
+	defer func() {
+		// removing the watch queues an IN_IGNORED event, which stops
+		// readEvents before the descriptor is closed
+		syscall.InotifyRmWatch(fd, uint32(wd))
+		for range written {
+		}
+	}()
+
+	// the file may have been written before the watch was added
+	if pid, err := readPid(pidFilePath); pid != 0 || err != nil {
+		return pid, err
+	}
+
+	timeout := time.NewTimer(w.Timeout)
+	defer timeout.Stop()
+
+	for {
+		select {
+		case name, ok := <-written:
+			if !ok {
+				// the directory has gone, so the file will never be written
+				return 0, fmt.Errorf("pid file '%s' was not written: its directory was removed", pidFilePath)
+			}
+
+			if name != "" && name != filepath.Base(pidFilePath) {
+				continue
+			}
+
+			if pid, err := readPid(pidFilePath); pid != 0 || err != nil {
+				return pid, err
+			}
+		case <-timeout.C:
+			return 0, fmt.Errorf("timeout: pid file '%s' was not written within %s", pidFilePath, w.Timeout)
+		}
+	}
+}
+
+// readEvents sends the name of each file written in the watched directory,
+// until the watch is removed. An empty name means that events were lost.
+func readEvents(fd int, written chan<- string) {
+	defer close(written)
+
+	buf := make([]byte, 64*(syscall.SizeofInotifyEvent+syscall.NAME_MAX+1))
+	for {
+		n, err := syscall.Read(fd, buf)
+		if err == syscall.EINTR {
+			continue
+		}
+
+		if err != nil || n < syscall.SizeofInotifyEvent {
+			return
+		}
+
+		for offset := 0; offset+syscall.SizeofInotifyEvent <= n; {
+			event := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			if event.Mask&syscall.IN_IGNORED != 0 {
+				return
+			}
+
+			nameStart := offset + syscall.SizeofInotifyEvent
+			name := string(buf[nameStart : nameStart+int(event.Len)])
+			for i := 0; i < len(name); i++ {
+				if name[i] == 0 {
+					name = name[:i]
+					break
+				}
+			}
+
+			written <- name
+			offset = nameStart + int(event.Len)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package process_tracker_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cloudfoundry-incubator/guardian/rundmc/process_tracker"
+	"github.com/cloudfoundry-incubator/guardian/rundmc/process_tracker/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PidFileWatcher", func() {
+	var (
+		processesDir string
+		pidFilePath  string
+		fallback     *fakes.FakePidGetter
+		watcher      *process_tracker.PidFileWatcher
+	)
+
+	BeforeEach(func() {
+		var err error
+		processesDir, err = ioutil.TempDir("", "processes")
+		Expect(err).NotTo(HaveOccurred())
+
+		pidFilePath = filepath.Join(processesDir, "some-process.pid")
+		fallback = new(fakes.FakePidGetter)
+		watcher = &process_tracker.PidFileWatcher{Timeout: time.Second, Fallback: fallback}
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(processesDir)).To(Succeed())
+	})
+
+	pid := func() <-chan int {
+		pids := make(chan int, 1)
+		go func() {
+			defer GinkgoRecover()
+
+			pid, err := watcher.Pid(pidFilePath)
+			Expect(err).NotTo(HaveOccurred())
+			pids <- pid
+		}()
+
+		return pids
+	}
+
+	It("reads a pid file which has already been written", func() {
+		Expect(ioutil.WriteFile(pidFilePath, []byte("5621"), 0644)).To(Succeed())
+		Expect(watcher.Pid(pidFilePath)).To(Equal(5621))
+	})
+
+	It("reads the pid file as soon as it is written", func() {
+		pids := pid()
+
+		Expect(ioutil.WriteFile(filepath.Join(processesDir, "other-process.pid"), []byte("1234"), 0644)).To(Succeed())
+		Consistently(pids, "50ms").ShouldNot(Receive())
+
+		Expect(ioutil.WriteFile(pidFilePath, []byte("5621"), 0644)).To(Succeed())
+		Eventually(pids, "100ms").Should(Receive(Equal(5621)))
+	})
+
+	It("reads the pid file when it is renamed in to place", func() {
+		pids := pid()
+
+		tmp := filepath.Join(processesDir, ".some-process.pid")
+		Expect(ioutil.WriteFile(tmp, []byte("5621"), 0644)).To(Succeed())
+		Expect(os.Rename(tmp, pidFilePath)).To(Succeed())
+		Eventually(pids, "100ms").Should(Receive(Equal(5621)))
+	})
+
+	It("waits while the pid file is empty", func() {
+		Expect(ioutil.WriteFile(pidFilePath, []byte{}, 0644)).To(Succeed())
+		pids := pid()
+		Consistently(pids, "50ms").ShouldNot(Receive())
+
+		Expect(ioutil.WriteFile(pidFilePath, []byte("5621\n"), 0644)).To(Succeed())
+		Eventually(pids, "100ms").Should(Receive(Equal(5621)))
+	})
+
+	It("returns an error when the pid file is not written before the timeout", func() {
+		watcher.Timeout = 50 * time.Millisecond
+		_, err := watcher.Pid(pidFilePath)
+		Expect(err).To(MatchError(ContainSubstring("timeout")))
+	})
+
+	It("returns an error when the pid file's directory is removed", func() {
+		errs := make(chan error, 1)
+		go func() {
+			_, err := watcher.Pid(pidFilePath)
+			errs <- err
+		}()
+
+		Consistently(errs, "50ms").ShouldNot(Receive())
+		Expect(os.RemoveAll(processesDir)).To(Succeed())
+		Eventually(errs).Should(Receive(MatchError(ContainSubstring("its directory was removed"))))
+	})
+
+	It("returns an error when the pid file does not contain a pid", func() {
+		Expect(ioutil.WriteFile(pidFilePath, []byte("notanint"), 0644)).To(Succeed())
+		_, err := watcher.Pid(pidFilePath)
+		Expect(err).To(MatchError(ContainSubstring("parsing pid file contents")))
+	})
+
+	Context("when the pid file's directory can't be watched", func() {
+		BeforeEach(func() {
+			pidFilePath = filepath.Join(processesDir, "missing", "some-process.pid")
+		})
+
+		It("uses the fallback", func() {
+			fallback.PidReturns(5621, nil)
+			Expect(watcher.Pid(pidFilePath)).To(Equal(5621))
+			Expect(fallback.PidArgsForCall(0)).To(Equal(pidFilePath))
+		})
+
+		It("returns the fallback's error", func() {
+			fallback.PidReturns(0, errors.New("timeout: banana"))
+			_, err := watcher.Pid(pidFilePath)
+			Expect(err).To(MatchError("timeout: banana"))
+		})
+	})
+})
@@ -0,0 +1,7 @@
+// +build !linux
+
+package process_tracker
+
+func (w *PidFileWatcher) Pid(pidFilePath string) (int, error) {
+	return w.Fallback.Pid(pidFilePath)
+}
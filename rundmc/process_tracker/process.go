@@ -1,18 +1,14 @@
 package process_tracker
 
 import (
-	"bufio"
-	"fmt"
 	"os"
 	"os/exec"
-	"path"
 	"sync"
 	"syscall"
 
 	"github.com/cloudfoundry-incubator/garden"
 	"github.com/cloudfoundry-incubator/guardian/rundmc/iodaemon/link"
 	"github.com/cloudfoundry-incubator/guardian/rundmc/process_tracker/writer"
-	"github.com/cloudfoundry/gunk/command_runner"
 )
 
 type osSignal garden.Signal
@@ -27,12 +23,10 @@ func (s osSignal) OsSignal() syscall.Signal {
 }
 
 type Process struct {
-	containerPath string
-	iodaemonBin   string
-	runner        command_runner.CommandRunner
-	pidGetter     PidGetter
-	id            string
-	pidFilePath   string
+	socketPath  string
+	pidGetter   PidGetter
+	id          string
+	pidFilePath string
 
 	runningLink *sync.Once
 	linked      chan struct{}
@@ -47,21 +41,20 @@ type Process struct {
 	stderr writer.FanOut
 }
 
+// NewProcess returns a process which the IO daemon listening on socketPath
+// runs, or will run once it is spawned
 func NewProcess(
-	containerPath string,
-	iodaemonBin string,
-	runner command_runner.CommandRunner,
+	socketPath string,
 	pidGetter PidGetter,
 	id string,
 	pidFilePath string,
+	replaySize int,
 ) *Process {
 	return &Process{
-		containerPath: containerPath,
-		iodaemonBin:   iodaemonBin,
-		runner:        runner,
-		pidGetter:     pidGetter,
-		id:            id,
-		pidFilePath:   pidFilePath,
+		socketPath:  socketPath,
+		pidGetter:   pidGetter,
+		id:          id,
+		pidFilePath: pidFilePath,
 
 		runningLink: &sync.Once{},
 		linked:      make(chan struct{}),
@@ -69,11 +62,21 @@ func NewProcess(
 		exited: make(chan struct{}),
 
 		stdin:  writer.NewFanIn(),
-		stdout: writer.NewFanOut(),
-		stderr: writer.NewFanOut(),
+		stdout: newFanOut(replaySize),
+		stderr: newFanOut(replaySize),
 	}
 }
 
+// newFanOut only keeps output to replay when replaySize is positive, so that
+// replay is opt-in
+func newFanOut(replaySize int) writer.FanOut {
+	if replaySize <= 0 {
+		return writer.NewFanOut()
+	}
+
+	return writer.NewReplayingFanOut(replaySize)
+}
+
 func (p *Process) ID() string {
 	return p.id
 }
@@ -109,78 +112,43 @@ func (p *Process) Signal(signal garden.Signal) error {
 	return process.Signal(osSignal(signal).OsSignal())
 }
 
-func (p *Process) Spawn(cmd *exec.Cmd, tty *garden.TTYSpec) (ready, active chan error) {
-	ready = make(chan error, 1)
-	active = make(chan error, 1)
-
-	processSock := path.Join(p.containerPath, "processes", fmt.Sprintf("%s.sock", p.ID()))
-
-	bashFlags := []string{
-		"-c",
-		// spawn but not as a child process (fork off in the bash subprocess).
-		p.iodaemonBin + ` "$@" &`,
-		p.iodaemonBin,
+// Spawn asks the IO daemon to run cmd, linking to it; its output goes to
+// whatever has been attached so far
+func (p *Process) Spawn(cmd *exec.Cmd, tty *garden.TTYSpec) error {
+	spec := link.SpawnSpec{
+		Path: cmd.Path,
+		Args: cmd.Args,
+		Env:  cmd.Env,
+		Dir:  cmd.Dir,
 	}
 
 	if tty != nil {
-		bashFlags = append(bashFlags, "-tty")
+		spec.TTY = &link.WindowSize{Columns: 80, Rows: 24}
 
 		if tty.WindowSize != nil {
-			bashFlags = append(
-				bashFlags,
-				fmt.Sprintf("-windowColumns=%d", tty.WindowSize.Columns),
-				fmt.Sprintf("-windowRows=%d", tty.WindowSize.Rows),
-			)
+			spec.TTY.Columns = tty.WindowSize.Columns
+			spec.TTY.Rows = tty.WindowSize.Rows
 		}
 	}
 
-	bashFlags = append(bashFlags, "spawn", processSock)
-
-	spawn := exec.Command("bash", append(bashFlags, cmd.Args...)...)
-	spawn.Env = cmd.Env
-	spawn.Dir = cmd.Dir
-
-	spawnR, err := spawn.StdoutPipe()
+	l, err := link.Spawn(p.socketPath, p.id, spec, p.stdout, p.stderr)
 	if err != nil {
-		ready <- err
-		return
-	}
-
-	spawnOut := bufio.NewReader(spawnR)
-
-	err = p.runner.Start(spawn)
-	if err != nil {
-		ready <- err
-		return
+		return err
 	}
 
-	go func() {
-		_, err := spawnOut.ReadBytes('\n')
-		if err != nil {
-			ready <- fmt.Errorf("failed to read ready: %s", err)
-			return
-		}
-
-		ready <- nil
-
-		_, err = spawnOut.ReadBytes('\n')
-		if err != nil {
-			active <- fmt.Errorf("failed to read active: %s", err)
-			return
-		}
-
-		active <- nil
-
-		spawn.Wait()
-	}()
-
-	return
+	p.link = l
+	return nil
 }
 
 func (p *Process) Link() {
 	p.runningLink.Do(p.runLinker)
 }
 
+// Attach streams the process's input and output to and from processIO. When
+// replaySize is non-zero, the last replaySize bytes the process has written to
+// each of stdout and stderr are written first, so that a client which attaches
+// late, or re-attaches, sees recent output as well as what the process writes
+// from now on. Otherwise only what it writes from now on is streamed.
 func (p *Process) Attach(processIO garden.ProcessIO) {
 	if processIO.Stdin != nil {
 		p.stdin.AddSource(processIO.Stdin)
@@ -196,18 +164,20 @@ func (p *Process) Attach(processIO garden.ProcessIO) {
 }
 
 // This is guarded by runningLink so will only run once per Process per garden.
+// A process which was not spawned by this garden, e.g. one restored after a
+// restart, is attached to here.
 func (p *Process) runLinker() {
-	processSock := path.Join(p.containerPath, "processes", fmt.Sprintf("%s.sock", p.ID()))
+	if p.link == nil {
+		l, err := link.Attach(p.socketPath, p.id, p.stdout, p.stderr)
+		if err != nil {
+			p.completed(-1, err)
+			return
+		}
 
-	link, err := link.Create(processSock, p.stdout, p.stderr)
-	if err != nil {
-		p.completed(-1, err)
-		return
+		p.link = l
 	}
 
-	p.stdin.AddSink(link)
-
-	p.link = link
+	p.stdin.AddSink(p.link)
 	close(p.linked)
 
 	p.completed(p.link.Wait())
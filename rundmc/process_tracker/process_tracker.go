@@ -1,14 +1,23 @@
 package process_tracker
 
 import (
+	"bufio"
 	"fmt"
+	"net"
 	"os/exec"
+	"path"
 	"sync"
+	"syscall"
 
 	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/guardian/rundmc/iodaemon/link"
 	"github.com/cloudfoundry/gunk/command_runner"
 )
 
+// DaemonSocketName is the socket, in the tracker's directory, of the IO
+// daemon which runs the tracked processes
+const DaemonSocketName = "iodaemon.sock"
+
 //go:generate counterfeiter . PidGetter
 type PidGetter interface {
 	Pid(pidFilePath string) (int, error)
@@ -19,9 +28,12 @@ type ProcessTracker struct {
 	iodaemonBin   string
 	runner        command_runner.CommandRunner
 	pidGetter     PidGetter
+	replaySize    int
 
 	processes      map[string]*Process
 	processesMutex *sync.RWMutex
+
+	daemonMutex *sync.Mutex
 }
 
 type UnknownProcessError struct {
@@ -37,40 +49,48 @@ func New(
 	iodaemonBin string,
 	runner command_runner.CommandRunner,
 	pidGetter PidGetter,
+	replaySize int,
 ) *ProcessTracker {
 	return &ProcessTracker{
 		containerPath: containerPath,
 		iodaemonBin:   iodaemonBin,
 		runner:        runner,
 		pidGetter:     pidGetter,
+		replaySize:    replaySize,
 
 		processesMutex: new(sync.RWMutex),
 		processes:      make(map[string]*Process),
+
+		daemonMutex: new(sync.Mutex),
 	}
 }
 
 func (t *ProcessTracker) Run(processID string, cmd *exec.Cmd, processIO garden.ProcessIO, tty *garden.TTYSpec, pidFilePath string) (garden.Process, error) {
 	t.processesMutex.Lock()
-	process := NewProcess(t.containerPath, t.iodaemonBin, t.runner, t.pidGetter, processID, pidFilePath)
+	process := NewProcess(t.socketPath(), t.pidGetter, processID, pidFilePath, t.replaySize)
 	t.processes[processID] = process
 	t.processesMutex.Unlock()
 
-	ready, active := process.Spawn(cmd, tty)
+	// stdin is only attached once the process has been spawned, as nothing
+	// would ever consume what was read from it otherwise
+	process.Attach(garden.ProcessIO{Stdout: processIO.Stdout, Stderr: processIO.Stderr})
+
+	err := process.Spawn(cmd, tty)
+	if err != nil && !t.daemonRunning() {
+		if err = t.startDaemon(); err == nil {
+			err = process.Spawn(cmd, tty)
+		}
+	}
 
-	err := <-ready
 	if err != nil {
+		t.unregister(processID)
 		return nil, err
 	}
 
-	process.Attach(processIO)
+	process.Attach(garden.ProcessIO{Stdin: processIO.Stdin})
 
 	go t.link(process.ID())
 
-	err = <-active
-	if err != nil {
-		return nil, err
-	}
-
 	return process, nil
 }
 
@@ -93,7 +113,7 @@ func (t *ProcessTracker) Attach(processID string, processIO garden.ProcessIO) (g
 func (t *ProcessTracker) Restore(processID string) {
 	t.processesMutex.Lock()
 
-	process := NewProcess(t.containerPath, t.iodaemonBin, t.runner, t.pidGetter, processID, "")
+	process := NewProcess(t.socketPath(), t.pidGetter, processID, "", t.replaySize)
 
 	t.processes[processID] = process
 
@@ -102,6 +122,31 @@ func (t *ProcessTracker) Restore(processID string) {
 	t.processesMutex.Unlock()
 }
 
+// Recover restores the processes which the IO daemon is still running, e.g.
+// after guardian has restarted, so that they can be attached to again
+func (t *ProcessTracker) Recover() error {
+	if !t.daemonRunning() {
+		return nil
+	}
+
+	processIDs, err := link.List(t.socketPath())
+	if err != nil {
+		return err
+	}
+
+	for _, processID := range processIDs {
+		t.processesMutex.RLock()
+		_, tracked := t.processes[processID]
+		t.processesMutex.RUnlock()
+
+		if !tracked {
+			t.Restore(processID)
+		}
+	}
+
+	return nil
+}
+
 func (t *ProcessTracker) ActiveProcesses() []garden.Process {
 	t.processesMutex.RLock()
 	defer t.processesMutex.RUnlock()
@@ -139,3 +184,52 @@ func (t *ProcessTracker) unregister(processID string) {
 
 	delete(t.processes, processID)
 }
+
+func (t *ProcessTracker) socketPath() string {
+	return path.Join(t.containerPath, DaemonSocketName)
+}
+
+func (t *ProcessTracker) daemonRunning() bool {
+	conn, err := net.Dial("unix", t.socketPath())
+	if err != nil {
+		return false
+	}
+
+	conn.Close()
+	return true
+}
+
+// startDaemon starts the IO daemon in a session of its own, so that it and
+// its processes outlive guardian
+func (t *ProcessTracker) startDaemon() error {
+	t.daemonMutex.Lock()
+	defer t.daemonMutex.Unlock()
+
+	// another Run may have started it meanwhile
+	if t.daemonRunning() {
+		return nil
+	}
+
+	cmd := exec.Command(t.iodaemonBin, "serve", t.socketPath())
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+
+	if err := t.runner.Start(cmd); err != nil {
+		return fmt.Errorf("process_tracker: failed to start i/o daemon: %s", err)
+	}
+
+	_, err = bufio.NewReader(stdout).ReadBytes('\n')
+
+	// reap the daemon, should it exit
+	go t.runner.Wait(cmd)
+
+	if err != nil {
+		return fmt.Errorf("process_tracker: i/o daemon did not become ready: %s", err)
+	}
+
+	return nil
+}
@@ -5,8 +5,12 @@ import (
 	. "github.com/onsi/gomega"
 
 	"encoding/json"
+	"net"
+	"path/filepath"
+	"syscall"
 	"testing"
 
+	"github.com/cloudfoundry-incubator/guardian/rundmc/process_tracker"
 	"github.com/onsi/gomega/gexec"
 )
 
@@ -44,3 +48,26 @@ func TestProcess_tracker(t *testing.T) {
 	RegisterFailHandler(Fail)
 	RunSpecs(t, "Process Tracker Suite")
 }
+
+// killDaemon kills the IO daemon which a tracker started in dir, which would
+// otherwise outlive the tests
+func killDaemon(dir string) {
+	conn, err := net.Dial("unix", filepath.Join(dir, process_tracker.DaemonSocketName))
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	f, err := conn.(*net.UnixConn).File()
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	cred, err := syscall.GetsockoptUcred(int(f.Fd()), syscall.SOL_SOCKET, syscall.SO_PEERCRED)
+	if err != nil {
+		return
+	}
+
+	syscall.Kill(int(cred.Pid), syscall.SIGKILL)
+}
@@ -43,10 +43,12 @@ var _ = Describe("Process tracker", func() {
 			iodaemonBin,
 			linux_command_runner.New(),
 			pidGetter,
+			16,
 		)
 	})
 
 	AfterEach(func() {
+		killDaemon(tmpdir)
 		os.RemoveAll(tmpdir)
 	})
 
@@ -328,6 +330,36 @@ var _ = Describe("Process tracker", func() {
 		})
 	})
 
+	Describe("Recovering processes", func() {
+		It("restores the processes which the IO daemon is still running, so that they can be attached to", func() {
+			stdin, _ := io.Pipe()
+			_, err := processTracker.Run("1000", exec.Command("bash", "-c", "read; echo recovered; exit 7"), garden.ProcessIO{Stdin: stdin}, nil, "")
+			Expect(err).NotTo(HaveOccurred())
+
+			restarted := process_tracker.New(tmpdir, iodaemonBin, linux_command_runner.New(), pidGetter, 16)
+			Expect(restarted.Recover()).To(Succeed())
+
+			activeProcesses := restarted.ActiveProcesses()
+			Expect(activeProcesses).To(HaveLen(1))
+			Expect(activeProcesses[0].ID()).To(Equal("1000"))
+
+			stdout := gbytes.NewBuffer()
+			process, err := restarted.Attach("1000", garden.ProcessIO{
+				Stdin:  bytes.NewBufferString("\n"),
+				Stdout: stdout,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(stdout).Should(gbytes.Say("recovered"))
+			Expect(process.Wait()).To(Equal(7))
+		})
+
+		It("does nothing when the IO daemon is not running", func() {
+			Expect(processTracker.Recover()).To(Succeed())
+			Expect(processTracker.ActiveProcesses()).To(BeEmpty())
+		})
+	})
+
 	Describe("Attaching to running processes", func() {
 		It("streams stdout, stdin, and stderr", func() {
 			cmd := exec.Command("bash", "-c", `
@@ -352,6 +384,74 @@ var _ = Describe("Process tracker", func() {
 			Eventually(stdout).Should(gbytes.Say("hi stdout this-is-stdin"))
 			Eventually(stderr).Should(gbytes.Say("hi stderr this-is-stdin"))
 		})
+
+		It("replays the most recent output the process wrote before attaching", func() {
+			stdin, stdinW := io.Pipe()
+			cmd := exec.Command("bash", "-c", `
+			echo "some old output"
+			echo "older stderr" >&2
+			echo "new stderr" >&2
+			cat
+		`)
+
+			process, err := processTracker.Run("856", cmd, garden.ProcessIO{Stdin: stdin}, nil, "")
+			Expect(err).NotTo(HaveOccurred())
+
+			firstStdout := gbytes.NewBuffer()
+			firstStderr := gbytes.NewBuffer()
+			_, err = processTracker.Attach(process.ID(), garden.ProcessIO{
+				Stdout: firstStdout,
+				Stderr: firstStderr,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(firstStdout).Should(gbytes.Say("some old output"))
+			Eventually(firstStderr).Should(gbytes.Say("new stderr"))
+
+			stdout := gbytes.NewBuffer()
+			stderr := gbytes.NewBuffer()
+			_, err = processTracker.Attach(process.ID(), garden.ProcessIO{
+				Stdout: stdout,
+				Stderr: stderr,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			stdinW.Write([]byte("live output\n"))
+			Eventually(stdout).Should(gbytes.Say("some old output\nlive output"))
+			Expect(string(stderr.Contents())).To(Equal("derr\nnew stderr\n"))
+
+			stdinW.Close()
+			Expect(process.Wait()).To(Equal(0))
+		})
+
+		Context("when replay is off", func() {
+			BeforeEach(func() {
+				processTracker = process_tracker.New(tmpdir, iodaemonBin, linux_command_runner.New(), pidGetter, 0)
+			})
+
+			It("only streams output written after attaching", func() {
+				stdin, stdinW := io.Pipe()
+				cmd := exec.Command("bash", "-c", `
+				echo "some old output"
+				cat
+			`)
+
+				firstStdout := gbytes.NewBuffer()
+				process, err := processTracker.Run("857", cmd, garden.ProcessIO{Stdin: stdin, Stdout: firstStdout}, nil, "")
+				Expect(err).NotTo(HaveOccurred())
+				Eventually(firstStdout).Should(gbytes.Say("some old output"))
+
+				stdout := gbytes.NewBuffer()
+				_, err = processTracker.Attach(process.ID(), garden.ProcessIO{Stdout: stdout})
+				Expect(err).NotTo(HaveOccurred())
+
+				stdinW.Write([]byte("live output\n"))
+				Eventually(stdout).Should(gbytes.Say("live output"))
+				Expect(string(stdout.Contents())).To(Equal("live output\n"))
+
+				stdinW.Close()
+				Expect(process.Wait()).To(Equal(0))
+			})
+		})
 	})
 
 	Describe("Listing active process IDs", func() {
@@ -0,0 +1,75 @@
+package process_tracker_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"sync/atomic"
+	"testing"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/guardian/rundmc/process_tracker"
+	"github.com/cloudfoundry-incubator/guardian/rundmc/process_tracker/fakes"
+	"github.com/cloudfoundry/gunk/command_runner/linux_command_runner"
+	"github.com/onsi/gomega/gexec"
+)
+
+// The benchmarks measure exec throughput: running processes which exit
+// straight away, and collecting their exit status, e.g.
+//
+//	go test -run '^$' -bench Run ./rundmc/process_tracker
+
+func BenchmarkRun(b *testing.B) {
+	tracker, cleanup := benchmarkTracker(b)
+	defer cleanup()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		runTrue(b, tracker, fmt.Sprintf("process-%d", i))
+	}
+}
+
+func BenchmarkRunParallel(b *testing.B) {
+	tracker, cleanup := benchmarkTracker(b)
+	defer cleanup()
+
+	var n int64
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			runTrue(b, tracker, fmt.Sprintf("process-%d", atomic.AddInt64(&n, 1)))
+		}
+	})
+}
+
+func benchmarkTracker(b *testing.B) (*process_tracker.ProcessTracker, func()) {
+	iodaemonBin, err := gexec.Build("github.com/cloudfoundry-incubator/guardian/rundmc/iodaemon/cmd/iodaemon")
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	processesDir, err := ioutil.TempDir("", "processes")
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	tracker := process_tracker.New(processesDir, iodaemonBin, linux_command_runner.New(), new(fakes.FakePidGetter), 1024)
+
+	return tracker, func() {
+		killDaemon(processesDir)
+		os.RemoveAll(processesDir)
+		gexec.CleanupBuildArtifacts()
+	}
+}
+
+func runTrue(b *testing.B, tracker *process_tracker.ProcessTracker, processID string) {
+	process, err := tracker.Run(processID, exec.Command("true"), garden.ProcessIO{}, nil, "")
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	if status, err := process.Wait(); err != nil || status != 0 {
+		b.Fatalf("process exited with %d: %s", status, err)
+	}
+}
@@ -14,9 +14,20 @@ func NewFanOut() FanOut {
 	return &fanOut{}
 }
 
+// NewReplayingFanOut returns a FanOut which keeps the last size bytes written
+// to it, and writes them to each sink as it is added, before anything written
+// afterwards. Once more than size bytes have been written the oldest are
+// dropped, so a sink added late may start part way through a line, or even a
+// multi-byte character.
+func NewReplayingFanOut(size int) FanOut {
+	return &fanOut{replay: &ring{buf: make([]byte, size)}}
+}
+
 type fanOut struct {
 	sinks  []io.Writer
 	sinksL sync.Mutex
+
+	replay *ring
 }
 
 func (w *fanOut) Write(data []byte) (int, error) {
@@ -29,6 +40,10 @@ func (w *fanOut) Write(data []byte) (int, error) {
 		s.Write(data)
 	}
 
+	if w.replay != nil {
+		w.replay.Write(data)
+	}
+
 	return len(data), nil
 }
 
@@ -36,5 +51,46 @@ func (w *fanOut) AddSink(sink io.Writer) {
 	w.sinksL.Lock()
 	defer w.sinksL.Unlock()
 
+	if w.replay != nil && w.replay.len > 0 {
+		sink.Write(w.replay.Bytes())
+	}
+
 	w.sinks = append(w.sinks, sink)
 }
+
+// ring keeps the last len(buf) bytes written to it
+type ring struct {
+	buf   []byte
+	start int
+	len   int
+}
+
+func (r *ring) Write(data []byte) {
+	size := len(r.buf)
+	if size == 0 {
+		return
+	}
+
+	if len(data) >= size {
+		copy(r.buf, data[len(data)-size:])
+		r.start, r.len = 0, size
+		return
+	}
+
+	end := (r.start + r.len) % size
+	n := copy(r.buf[end:], data)
+	copy(r.buf, data[n:])
+
+	r.len += len(data)
+	if r.len > size {
+		r.start = (r.start + r.len - size) % size
+		r.len = size
+	}
+}
+
+func (r *ring) Bytes() []byte {
+	out := make([]byte, r.len)
+	n := copy(out, r.buf[r.start:])
+	copy(out[n:], r.buf)
+	return out
+}
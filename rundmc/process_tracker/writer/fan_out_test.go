@@ -1,6 +1,7 @@
 package writer_test
 
 import (
+	"bytes"
 	"errors"
 
 	"github.com/cloudfoundry-incubator/guardian/rundmc/process_tracker/writer"
@@ -66,3 +67,66 @@ var _ = Describe("FanOut", func() {
 		Expect(n).To(Equal(1))
 	})
 })
+
+var _ = Describe("ReplayingFanOut", func() {
+	var fanOut writer.FanOut
+
+	BeforeEach(func() {
+		fanOut = writer.NewReplayingFanOut(8)
+	})
+
+	It("writes what was written before a sink was added to it, before anything later", func() {
+		fanOut.Write([]byte("abc"))
+		fanOut.Write([]byte("de"))
+
+		sink := new(bytes.Buffer)
+		fanOut.AddSink(sink)
+		fanOut.Write([]byte("f"))
+
+		Expect(sink.String()).To(Equal("abcdef"))
+	})
+
+	It("drops the oldest bytes once more than its size have been written", func() {
+		fanOut.Write([]byte("abcdef"))
+		fanOut.Write([]byte("ghij"))
+
+		sink := new(bytes.Buffer)
+		fanOut.AddSink(sink)
+		Expect(sink.String()).To(Equal("cdefghij"))
+
+		fanOut.Write([]byte("klmnopqrstu"))
+		sink = new(bytes.Buffer)
+		fanOut.AddSink(sink)
+		Expect(sink.String()).To(Equal("nopqrstu"))
+	})
+
+	It("replays to every sink added", func() {
+		fanOut.Write([]byte("abc"))
+
+		first, second := new(bytes.Buffer), new(bytes.Buffer)
+		fanOut.AddSink(first)
+		fanOut.Write([]byte("d"))
+		fanOut.AddSink(second)
+
+		Expect(first.String()).To(Equal("abcd"))
+		Expect(second.String()).To(Equal("abcd"))
+	})
+
+	It("does not write to a sink when nothing has been written", func() {
+		fWriter := &fakeWriter{}
+		fanOut.AddSink(fWriter)
+		Expect(fWriter.writeCalls()).To(Equal(0))
+	})
+
+	Context("when its size is zero", func() {
+		It("replays nothing", func() {
+			fanOut = writer.NewReplayingFanOut(0)
+			fanOut.Write([]byte("abc"))
+
+			sink := new(bytes.Buffer)
+			fanOut.AddSink(sink)
+			fanOut.Write([]byte("d"))
+			Expect(sink.String()).To(Equal("d"))
+		})
+	})
+})
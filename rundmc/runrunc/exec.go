@@ -62,7 +62,9 @@ func (r *ExecPreparer) Prepare(log lager.Logger, id, bundlePath, pidFilePath str
 			UID: uint32(u.containerUid),
 			GID: uint32(u.containerGid),
 		},
-		Cwd: cwd,
+		Cwd:             cwd,
+		ApparmorProfile: bndl.Spec.Spec.Process.ApparmorProfile,
+		SelinuxLabel:    bndl.Spec.Spec.Process.SelinuxLabel,
 	})
 
 	if err != nil {
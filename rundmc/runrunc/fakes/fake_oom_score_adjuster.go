@@ -0,0 +1,59 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"os/exec"
+	"sync"
+
+	"github.com/cloudfoundry-incubator/guardian/rundmc/runrunc"
+	"github.com/pivotal-golang/lager"
+)
+
+type FakeOomScoreAdjuster struct {
+	AdjustStub        func(log lager.Logger, bundlePath string, cmd *exec.Cmd) error
+	adjustMutex       sync.RWMutex
+	adjustArgsForCall []struct {
+		log        lager.Logger
+		bundlePath string
+		cmd        *exec.Cmd
+	}
+	adjustReturns struct {
+		result1 error
+	}
+}
+
+func (fake *FakeOomScoreAdjuster) Adjust(log lager.Logger, bundlePath string, cmd *exec.Cmd) error {
+	fake.adjustMutex.Lock()
+	fake.adjustArgsForCall = append(fake.adjustArgsForCall, struct {
+		log        lager.Logger
+		bundlePath string
+		cmd        *exec.Cmd
+	}{log, bundlePath, cmd})
+	fake.adjustMutex.Unlock()
+	if fake.AdjustStub != nil {
+		return fake.AdjustStub(log, bundlePath, cmd)
+	} else {
+		return fake.adjustReturns.result1
+	}
+}
+
+func (fake *FakeOomScoreAdjuster) AdjustCallCount() int {
+	fake.adjustMutex.RLock()
+	defer fake.adjustMutex.RUnlock()
+	return len(fake.adjustArgsForCall)
+}
+
+func (fake *FakeOomScoreAdjuster) AdjustArgsForCall(i int) (lager.Logger, string, *exec.Cmd) {
+	fake.adjustMutex.RLock()
+	defer fake.adjustMutex.RUnlock()
+	return fake.adjustArgsForCall[i].log, fake.adjustArgsForCall[i].bundlePath, fake.adjustArgsForCall[i].cmd
+}
+
+func (fake *FakeOomScoreAdjuster) AdjustReturns(result1 error) {
+	fake.AdjustStub = nil
+	fake.adjustReturns = struct {
+		result1 error
+	}{result1}
+}
+
+var _ runrunc.OomScoreAdjuster = new(FakeOomScoreAdjuster)
@@ -45,6 +45,28 @@ type FakeRuncBinary struct {
 	killCommandReturns struct {
 		result1 *exec.Cmd
 	}
+	PauseCommandStub        func(id string) *exec.Cmd
+	pauseCommandMutex       sync.RWMutex
+	pauseCommandArgsForCall []struct {
+		id string
+	}
+	pauseCommandReturns struct {
+		result1 *exec.Cmd
+	}
+	ResumeCommandStub        func(id string) *exec.Cmd
+	resumeCommandMutex       sync.RWMutex
+	resumeCommandArgsForCall []struct {
+		id string
+	}
+	resumeCommandReturns struct {
+		result1 *exec.Cmd
+	}
+	LogToStub        func(cmd *exec.Cmd, logFile string)
+	logToMutex       sync.RWMutex
+	logToArgsForCall []struct {
+		cmd     *exec.Cmd
+		logFile string
+	}
 }
 
 func (fake *FakeRuncBinary) StartCommand(path string, id string) *exec.Cmd {
@@ -179,4 +201,92 @@ func (fake *FakeRuncBinary) KillCommandReturns(result1 *exec.Cmd) {
 	}{result1}
 }
 
+func (fake *FakeRuncBinary) PauseCommand(id string) *exec.Cmd {
+	fake.pauseCommandMutex.Lock()
+	fake.pauseCommandArgsForCall = append(fake.pauseCommandArgsForCall, struct {
+		id string
+	}{id})
+	fake.pauseCommandMutex.Unlock()
+	if fake.PauseCommandStub != nil {
+		return fake.PauseCommandStub(id)
+	} else {
+		return fake.pauseCommandReturns.result1
+	}
+}
+
+func (fake *FakeRuncBinary) PauseCommandCallCount() int {
+	fake.pauseCommandMutex.RLock()
+	defer fake.pauseCommandMutex.RUnlock()
+	return len(fake.pauseCommandArgsForCall)
+}
+
+func (fake *FakeRuncBinary) PauseCommandArgsForCall(i int) string {
+	fake.pauseCommandMutex.RLock()
+	defer fake.pauseCommandMutex.RUnlock()
+	return fake.pauseCommandArgsForCall[i].id
+}
+
+func (fake *FakeRuncBinary) PauseCommandReturns(result1 *exec.Cmd) {
+	fake.PauseCommandStub = nil
+	fake.pauseCommandReturns = struct {
+		result1 *exec.Cmd
+	}{result1}
+}
+
+func (fake *FakeRuncBinary) ResumeCommand(id string) *exec.Cmd {
+	fake.resumeCommandMutex.Lock()
+	fake.resumeCommandArgsForCall = append(fake.resumeCommandArgsForCall, struct {
+		id string
+	}{id})
+	fake.resumeCommandMutex.Unlock()
+	if fake.ResumeCommandStub != nil {
+		return fake.ResumeCommandStub(id)
+	} else {
+		return fake.resumeCommandReturns.result1
+	}
+}
+
+func (fake *FakeRuncBinary) ResumeCommandCallCount() int {
+	fake.resumeCommandMutex.RLock()
+	defer fake.resumeCommandMutex.RUnlock()
+	return len(fake.resumeCommandArgsForCall)
+}
+
+func (fake *FakeRuncBinary) ResumeCommandArgsForCall(i int) string {
+	fake.resumeCommandMutex.RLock()
+	defer fake.resumeCommandMutex.RUnlock()
+	return fake.resumeCommandArgsForCall[i].id
+}
+
+func (fake *FakeRuncBinary) ResumeCommandReturns(result1 *exec.Cmd) {
+	fake.ResumeCommandStub = nil
+	fake.resumeCommandReturns = struct {
+		result1 *exec.Cmd
+	}{result1}
+}
+
+func (fake *FakeRuncBinary) LogTo(cmd *exec.Cmd, logFile string) {
+	fake.logToMutex.Lock()
+	fake.logToArgsForCall = append(fake.logToArgsForCall, struct {
+		cmd     *exec.Cmd
+		logFile string
+	}{cmd, logFile})
+	fake.logToMutex.Unlock()
+	if fake.LogToStub != nil {
+		fake.LogToStub(cmd, logFile)
+	}
+}
+
+func (fake *FakeRuncBinary) LogToCallCount() int {
+	fake.logToMutex.RLock()
+	defer fake.logToMutex.RUnlock()
+	return len(fake.logToArgsForCall)
+}
+
+func (fake *FakeRuncBinary) LogToArgsForCall(i int) (*exec.Cmd, string) {
+	fake.logToMutex.RLock()
+	defer fake.logToMutex.RUnlock()
+	return fake.logToArgsForCall[i].cmd, fake.logToArgsForCall[i].logFile
+}
+
 var _ runrunc.RuncBinary = new(FakeRuncBinary)
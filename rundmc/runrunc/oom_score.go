@@ -0,0 +1,70 @@
+package runrunc
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strconv"
+	"syscall"
+
+	"github.com/docker/docker/pkg/reexec"
+	"github.com/pivotal-golang/lager"
+)
+
+// OomScoreAdjCommand is the helper which 'runc exec' is run through when a
+// container has an oom_score_adj. The guardian binary re-executes itself as
+// the helper, which sets its own oom_score_adj and then execs runc, so the
+// exec'd process inherits the score before it starts.
+const OomScoreAdjCommand = "set-oom-score-adj"
+
+func init() {
+	reexec.Register(OomScoreAdjCommand, setOomScoreAdj)
+}
+
+// setOomScoreAdj expects <oom_score_adj> <path> <args...>
+func setOomScoreAdj() {
+	if len(os.Args) < 4 {
+		fmt.Fprintf(os.Stderr, "%s: expected <oom_score_adj> <path> <args...>\n", OomScoreAdjCommand)
+		os.Exit(1)
+	}
+
+	if err := ioutil.WriteFile("/proc/self/oom_score_adj", []byte(os.Args[1]), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", OomScoreAdjCommand, err)
+		os.Exit(1)
+	}
+
+	err := syscall.Exec(os.Args[2], os.Args[3:], os.Environ())
+	fmt.Fprintf(os.Stderr, "%s: exec %s: %s\n", OomScoreAdjCommand, os.Args[2], err)
+	os.Exit(1)
+}
+
+// OomScoreAdj gives processes run with 'runc exec' the oom_score_adj in the
+// container's bundle, which runc only applies to the container's init process
+type OomScoreAdj struct {
+	BundleLoader BundleLoader
+
+	// Self is the path of the guardian binary. Commands are spawned by the
+	// process tracker's daemon, so /proc/self/exe would not be guardian.
+	Self string
+}
+
+// Adjust makes cmd run through the OomScoreAdjCommand helper, if the bundle
+// has an oom_score_adj
+func (o OomScoreAdj) Adjust(log lager.Logger, bundlePath string, cmd *exec.Cmd) error {
+	bndl, err := o.BundleLoader.Load(bundlePath)
+	if err != nil {
+		return err
+	}
+
+	resources := bndl.Spec.Linux.Resources
+	if resources == nil || resources.OOMScoreAdj == nil {
+		return nil
+	}
+
+	log.Debug("adjust-oom-score", lager.Data{"oom_score_adj": *resources.OOMScoreAdj})
+
+	cmd.Args = append([]string{OomScoreAdjCommand, strconv.Itoa(*resources.OOMScoreAdj), cmd.Path}, cmd.Args...)
+	cmd.Path = o.Self
+	return nil
+}
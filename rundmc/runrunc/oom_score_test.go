@@ -0,0 +1,83 @@
+package runrunc_test
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/goci"
+	"github.com/cloudfoundry-incubator/guardian/rundmc/runrunc"
+	"github.com/cloudfoundry-incubator/guardian/rundmc/runrunc/fakes"
+	"github.com/docker/docker/pkg/reexec"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/opencontainers/specs"
+	"github.com/pivotal-golang/lager/lagertest"
+)
+
+var _ = Describe("OomScoreAdj", func() {
+	var (
+		bundleLoader *fakes.FakeBundleLoader
+		adjuster     runrunc.OomScoreAdj
+		cmd          *exec.Cmd
+	)
+
+	BeforeEach(func() {
+		bundleLoader = new(fakes.FakeBundleLoader)
+		adjuster = runrunc.OomScoreAdj{
+			BundleLoader: bundleLoader,
+			Self:         "/path/to/guardian",
+		}
+
+		cmd = exec.Command("/path/to/runc", "exec", "some-id")
+	})
+
+	Context("when the bundle has an oom_score_adj", func() {
+		BeforeEach(func() {
+			adj := 500
+			bundleLoader.LoadReturns(goci.Bundle().WithResources(&specs.Resources{OOMScoreAdj: &adj}), nil)
+		})
+
+		It("runs the command through the helper, so the score is set before the process starts", func() {
+			Expect(adjuster.Adjust(lagertest.NewTestLogger("test"), "/path/to/bundle", cmd)).To(Succeed())
+
+			Expect(bundleLoader.LoadArgsForCall(0)).To(Equal("/path/to/bundle"))
+			Expect(cmd.Path).To(Equal("/path/to/guardian"))
+			Expect(cmd.Args).To(Equal([]string{runrunc.OomScoreAdjCommand, "500", "/path/to/runc", "/path/to/runc", "exec", "some-id"}))
+		})
+	})
+
+	Context("when the bundle has no oom_score_adj", func() {
+		It("leaves the command alone", func() {
+			bundleLoader.LoadReturns(goci.Bundle(), nil)
+			Expect(adjuster.Adjust(lagertest.NewTestLogger("test"), "/path/to/bundle", cmd)).To(Succeed())
+
+			Expect(cmd.Path).To(Equal("/path/to/runc"))
+			Expect(cmd.Args).To(Equal([]string{"/path/to/runc", "exec", "some-id"}))
+		})
+	})
+
+	It("returns an error when the bundle can't be loaded", func() {
+		bundleLoader.LoadReturns(nil, errors.New("no bundle"))
+		Expect(adjuster.Adjust(lagertest.NewTestLogger("test"), "/path/to/bundle", cmd)).To(MatchError("no bundle"))
+	})
+
+	Describe("the helper", func() {
+		It("sets its oom_score_adj and then execs the command", func() {
+			catPath, err := exec.LookPath("cat")
+			Expect(err).NotTo(HaveOccurred())
+
+			helper := reexec.Command(runrunc.OomScoreAdjCommand, "500", catPath, "cat", "/proc/self/oom_score_adj")
+			out, err := helper.Output()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(strings.TrimSpace(string(out))).To(Equal("500"))
+		})
+
+		It("fails when it cannot set the score", func() {
+			helper := reexec.Command(runrunc.OomScoreAdjCommand, "lots", "/bin/true", "true")
+			out, err := helper.CombinedOutput()
+			Expect(err).To(HaveOccurred())
+			Expect(string(out)).To(ContainSubstring(runrunc.OomScoreAdjCommand))
+		})
+	})
+})
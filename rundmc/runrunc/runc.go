@@ -0,0 +1,134 @@
+package runrunc
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/cloudfoundry/gunk/command_runner"
+)
+
+// SupportedVersions are the runtime versions whose command line Runc speaks;
+// runc 0.1.0 changed how containers are started
+const SupportedVersions = ">= 0.0.5, < 0.1.0"
+
+var (
+	minimumVersion          = [3]int{0, 0, 5}
+	firstUnsupportedVersion = [3]int{0, 1, 0}
+)
+
+// Runc builds the command lines of runc, or of another OCI runtime which
+// accepts the same ones. Root is where the runtime keeps the state of its
+// containers; the runtime's default is used when it is empty.
+type Runc struct {
+	Path string
+	Root string
+}
+
+func (r Runc) StartCommand(path, id string) *exec.Cmd {
+	cmd := r.command("--id", id, "start")
+	cmd.Dir = path
+	return cmd
+}
+
+func (r Runc) ExecCommand(id, processJSONPath, pidFilePath string) *exec.Cmd {
+	return r.command("exec", id, "--pid-file", pidFilePath, processJSONPath)
+}
+
+func (r Runc) EventsCommand(id string) *exec.Cmd {
+	return r.command("events", id)
+}
+
+func (r Runc) KillCommand(id, signal string) *exec.Cmd {
+	return r.command("kill", id, signal)
+}
+
+func (r Runc) PauseCommand(id string) *exec.Cmd {
+	return r.command("pause", id)
+}
+
+func (r Runc) ResumeCommand(id string) *exec.Cmd {
+	return r.command("resume", id)
+}
+
+// LogTo makes the command log to logFile, which the runtime otherwise
+// discards
+func (r Runc) LogTo(cmd *exec.Cmd, logFile string) {
+	cmd.Args = append([]string{cmd.Args[0], "--log", logFile}, cmd.Args[1:]...)
+}
+
+// Version returns the version the runtime reports, e.g. "0.0.7", and an
+// error if it is not one of the SupportedVersions
+func (r Runc) Version(runner command_runner.CommandRunner) (string, error) {
+	out := new(bytes.Buffer)
+	cmd := exec.Command(r.Path, "--version")
+	cmd.Stdout = out
+	cmd.Stderr = out
+	if err := runner.Run(cmd); err != nil {
+		return "", fmt.Errorf("%s --version: %s: %s", r.Path, err, strings.TrimSpace(out.String()))
+	}
+
+	// e.g. "runc version 0.0.7"
+	fields := strings.Fields(strings.SplitN(out.String(), "\n", 2)[0])
+	if len(fields) == 0 {
+		return "", fmt.Errorf("%s --version: no version reported", r.Path)
+	}
+
+	version := fields[len(fields)-1]
+	parsed, err := parseVersion(version)
+	if err != nil {
+		return version, err
+	}
+
+	if less(parsed, minimumVersion) || !less(parsed, firstUnsupportedVersion) {
+		return version, fmt.Errorf("version %s is not supported (supported: %s)", version, SupportedVersions)
+	}
+
+	return version, nil
+}
+
+func (r Runc) command(args ...string) *exec.Cmd {
+	if r.Root != "" {
+		args = append([]string{"--root", r.Root}, args...)
+	}
+
+	return exec.Command(r.Path, args...)
+}
+
+// parseVersion parses a major.minor.patch version, ignoring any leading "v"
+// and any pre-release or build suffix (e.g. "0.0.7-dev")
+func parseVersion(version string) ([3]int, error) {
+	trimmed := strings.TrimPrefix(version, "v")
+	if i := strings.IndexAny(trimmed, "-+~"); i >= 0 {
+		trimmed = trimmed[:i]
+	}
+
+	var parsed [3]int
+	parts := strings.Split(trimmed, ".")
+	if len(parts) != 3 {
+		return parsed, fmt.Errorf("invalid version %q", version)
+	}
+
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return parsed, fmt.Errorf("invalid version %q", version)
+		}
+
+		parsed[i] = n
+	}
+
+	return parsed, nil
+}
+
+func less(a, b [3]int) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,121 @@
+package runrunc_test
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+
+	"github.com/cloudfoundry-incubator/guardian/rundmc/runrunc"
+	"github.com/cloudfoundry/gunk/command_runner/fake_command_runner"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Runc", func() {
+	var runc runrunc.Runc
+
+	BeforeEach(func() {
+		runc = runrunc.Runc{Path: "/path/to/runc"}
+	})
+
+	Describe("commands", func() {
+		It("starts the container in its bundle directory", func() {
+			cmd := runc.StartCommand("/path/to/bundle", "some-id")
+			Expect(cmd.Path).To(Equal("/path/to/runc"))
+			Expect(cmd.Args).To(Equal([]string{"/path/to/runc", "--id", "some-id", "start"}))
+			Expect(cmd.Dir).To(Equal("/path/to/bundle"))
+		})
+
+		It("builds the runtime's other commands", func() {
+			Expect(runc.ExecCommand("some-id", "process.json", "some.pid").Args).To(Equal([]string{"/path/to/runc", "exec", "some-id", "--pid-file", "some.pid", "process.json"}))
+			Expect(runc.EventsCommand("some-id").Args).To(Equal([]string{"/path/to/runc", "events", "some-id"}))
+			Expect(runc.KillCommand("some-id", "KILL").Args).To(Equal([]string{"/path/to/runc", "kill", "some-id", "KILL"}))
+			Expect(runc.PauseCommand("some-id").Args).To(Equal([]string{"/path/to/runc", "pause", "some-id"}))
+			Expect(runc.ResumeCommand("some-id").Args).To(Equal([]string{"/path/to/runc", "resume", "some-id"}))
+		})
+
+		It("makes a command log to a file, before its other arguments", func() {
+			cmd := runc.KillCommand("some-id", "KILL")
+			runc.LogTo(cmd, "/path/to/kill.log")
+			Expect(cmd.Path).To(Equal("/path/to/runc"))
+			Expect(cmd.Args).To(Equal([]string{"/path/to/runc", "--log", "/path/to/kill.log", "kill", "some-id", "KILL"}))
+		})
+
+		Context("when a root is given", func() {
+			BeforeEach(func() {
+				runc.Root = "/run/some-root"
+			})
+
+			It("passes it to every command, before the subcommand", func() {
+				for _, cmd := range []*exec.Cmd{
+					runc.StartCommand("/path/to/bundle", "some-id"),
+					runc.ExecCommand("some-id", "process.json", "some.pid"),
+					runc.EventsCommand("some-id"),
+					runc.KillCommand("some-id", "KILL"),
+					runc.PauseCommand("some-id"),
+					runc.ResumeCommand("some-id"),
+				} {
+					Expect(cmd.Args[1:3]).To(Equal([]string{"--root", "/run/some-root"}))
+				}
+			})
+		})
+	})
+
+	Describe("Version", func() {
+		var runner *fake_command_runner.FakeCommandRunner
+
+		BeforeEach(func() {
+			runner = fake_command_runner.New()
+		})
+
+		reports := func(output string) {
+			runner.WhenRunning(fake_command_runner.CommandSpec{
+				Path: "/path/to/runc",
+				Args: []string{"--version"},
+			}, func(cmd *exec.Cmd) error {
+				fmt.Fprint(cmd.Stdout, output)
+				return nil
+			})
+		}
+
+		It("returns the version the runtime reports", func() {
+			reports("runc version 0.0.7\ncommit: abc\n")
+			Expect(runc.Version(runner)).To(Equal("0.0.7"))
+		})
+
+		It("accepts versions with a suffix", func() {
+			reports("runc version 0.0.8-dev\n")
+			Expect(runc.Version(runner)).To(Equal("0.0.8-dev"))
+		})
+
+		It("rejects versions older than the oldest supported one", func() {
+			reports("runc version 0.0.4\n")
+			_, err := runc.Version(runner)
+			Expect(err).To(MatchError("version 0.0.4 is not supported (supported: " + runrunc.SupportedVersions + ")"))
+		})
+
+		It("rejects versions with an incompatible command line", func() {
+			reports("runc version 1.0.0-rc1\n")
+			_, err := runc.Version(runner)
+			Expect(err).To(MatchError(ContainSubstring("version 1.0.0-rc1 is not supported")))
+		})
+
+		It("rejects versions it can't parse", func() {
+			reports("runc version banana\n")
+			_, err := runc.Version(runner)
+			Expect(err).To(MatchError(`invalid version "banana"`))
+		})
+
+		It("returns an error when the runtime can't report its version", func() {
+			runner.WhenRunning(fake_command_runner.CommandSpec{
+				Path: "/path/to/runc",
+			}, func(cmd *exec.Cmd) error {
+				fmt.Fprint(cmd.Stderr, "no such flag")
+				return errors.New("exit status 1")
+			})
+
+			_, err := runc.Version(runner)
+			Expect(err).To(MatchError("/path/to/runc --version: exit status 1: no such flag"))
+		})
+	})
+})
@@ -8,6 +8,7 @@ import (
 	"os"
 	"os/exec"
 	"path"
+	"sync"
 
 	"github.com/cloudfoundry-incubator/garden"
 	"github.com/cloudfoundry-incubator/goci"
@@ -50,6 +51,11 @@ func (fn LookupFunc) Lookup(rootfsPath, user string) (*user.ExecUser, error) {
 	return fn(rootfsPath, user)
 }
 
+//go:generate counterfeiter . OomScoreAdjuster
+type OomScoreAdjuster interface {
+	Adjust(log lager.Logger, bundlePath string, cmd *exec.Cmd) error
+}
+
 //go:generate counterfeiter . BundleLoader
 type BundleLoader interface {
 	Load(path string) (*goci.Bndl, error)
@@ -62,7 +68,9 @@ type RunRunc struct {
 	pidGenerator  UidGenerator
 	runc          RuncBinary
 
-	execPreparer *ExecPreparer
+	execPreparer     *ExecPreparer
+	oomScoreAdjuster OomScoreAdjuster
+	logs             *RuntimeLogs
 }
 
 //go:generate counterfeiter . RuncBinary
@@ -71,15 +79,20 @@ type RuncBinary interface {
 	ExecCommand(id, processJSONPath, pidFilePath string) *exec.Cmd
 	EventsCommand(id string) *exec.Cmd
 	KillCommand(id, signal string) *exec.Cmd
+	PauseCommand(id string) *exec.Cmd
+	ResumeCommand(id string) *exec.Cmd
+	LogTo(cmd *exec.Cmd, logFile string)
 }
 
-func New(tracker ProcessTracker, runner command_runner.CommandRunner, pidgen UidGenerator, runc RuncBinary, execPreparer *ExecPreparer) *RunRunc {
+func New(tracker ProcessTracker, runner command_runner.CommandRunner, pidgen UidGenerator, runc RuncBinary, execPreparer *ExecPreparer, oomScoreAdjuster OomScoreAdjuster, logs *RuntimeLogs) *RunRunc {
 	return &RunRunc{
-		tracker:       tracker,
-		commandRunner: runner,
-		pidGenerator:  pidgen,
-		runc:          runc,
-		execPreparer:  execPreparer,
+		tracker:          tracker,
+		commandRunner:    runner,
+		pidGenerator:     pidgen,
+		runc:             runc,
+		execPreparer:     execPreparer,
+		oomScoreAdjuster: oomScoreAdjuster,
+		logs:             logs,
 	}
 }
 
@@ -91,14 +104,16 @@ func (r *RunRunc) Start(log lager.Logger, bundlePath, id string, io garden.Proce
 	defer log.Info("finished")
 
 	cmd := r.runc.StartCommand(bundlePath, id)
+	collect := r.logTo(log, id, "start", cmd)
 
 	process, err := r.tracker.Run(r.pidGenerator.Generate(), cmd, io, nil, "")
 	if err != nil {
+		collect()
 		log.Error("run", err)
 		return nil, err
 	}
 
-	return process, nil
+	return &runtimeProcess{Process: process, log: log, operation: "start", collect: collect}, nil
 }
 
 // Exec a process in a bundle using 'runc exec'
@@ -117,13 +132,25 @@ func (r *RunRunc) Exec(log lager.Logger, bundlePath, id string, spec garden.Proc
 		return nil, err
 	}
 
+	collect := r.logTo(log, id, "exec", cmd)
+
+	if err := r.oomScoreAdjuster.Adjust(log, bundlePath, cmd); err != nil {
+		collect()
+		log.Error("adjust-oom-score-failed", err)
+		return nil, err
+	}
+
 	process, err := r.tracker.Run(pid, cmd, io, spec.TTY, pidFilePath)
 	if err != nil {
+		collect()
 		log.Error("run-failed", err)
 		return nil, err
 	}
 
-	return process, nil
+	return &runtimeProcess{Process: process, log: log, operation: "exec", collect: collect, started: func() bool {
+		_, err := os.Stat(pidFilePath)
+		return err == nil
+	}}, nil
 }
 
 func (r *RunRunc) Watch(log lager.Logger, handle string, notifier Notifier) error {
@@ -169,18 +196,104 @@ func (r *RunRunc) Watch(log lager.Logger, handle string, notifier Notifier) erro
 
 // Kill a bundle using 'runc kill'
 func (r *RunRunc) Kill(log lager.Logger, handle string) error {
-	log = log.Session("kill", lager.Data{"handle": handle})
+	return r.run(log.Session("kill", lager.Data{"handle": handle}), handle, "kill", r.runc.KillCommand(handle, "KILL"))
+}
+
+// Pause freezes the processes in a container using 'runc pause'
+func (r *RunRunc) Pause(log lager.Logger, handle string) error {
+	return r.run(log.Session("pause", lager.Data{"handle": handle}), handle, "pause", r.runc.PauseCommand(handle))
+}
+
+// Resume thaws the processes in a paused container using 'runc resume'
+func (r *RunRunc) Resume(log lager.Logger, handle string) error {
+	return r.run(log.Session("resume", lager.Data{"handle": handle}), handle, "resume", r.runc.ResumeCommand(handle))
+}
 
+func (r *RunRunc) run(log lager.Logger, handle, operation string, cmd *exec.Cmd) error {
 	log.Info("started")
 	defer log.Info("finished")
 
+	collect := r.logTo(log, handle, operation, cmd)
+
 	buf := &bytes.Buffer{}
-	cmd := r.runc.KillCommand(handle, "KILL")
 	cmd.Stderr = buf
-	if err := r.commandRunner.Run(cmd); err != nil {
-		log.Error("run-failed", err, lager.Data{"stderr": buf.String()})
-		return fmt.Errorf("runc kill: %s: %s", err, string(buf.String()))
+	err := r.commandRunner.Run(cmd)
+	logged := collect()
+	if err != nil {
+		log.Error("run-failed", err, lager.Data{"stderr": buf.String(), "runtime-error": logged})
+		if logged != "" {
+			return fmt.Errorf("runc %s: %s", operation, logged)
+		}
+
+		return fmt.Errorf("runc %s: %s: %s", operation, err, buf.String())
 	}
 
 	return nil
 }
+
+// logTo gives the operation a log file of its own, and returns a function
+// which collects it once the operation is over, returning the error the
+// runtime logged. The operation runs without a log file if it can't have one,
+// as a missing log shouldn't stop e.g. a container from being killed.
+func (r *RunRunc) logTo(log lager.Logger, handle, operation string, cmd *exec.Cmd) (collect func() string) {
+	logFile, err := r.logs.Create(handle, operation)
+	if err != nil {
+		log.Error("create-runtime-log-failed", err)
+		return func() string { return "" }
+	}
+
+	r.runc.LogTo(cmd, logFile)
+
+	return func() string {
+		logged, err := r.logs.Collect(handle, logFile)
+		if err != nil {
+			log.Error("collect-runtime-log-failed", err)
+		}
+
+		return logged
+	}
+}
+
+// runtimeProcess is a process run by the runtime, whose Wait returns the
+// error the runtime logged, if any, when the runtime itself failed. Once the
+// user's process has started, its exit status is returned as it is, and
+// anything the runtime logged is only logged.
+type runtimeProcess struct {
+	garden.Process
+
+	log       lager.Logger
+	operation string
+	collect   func() string
+
+	// started reports whether the runtime got as far as starting the user's
+	// process. It is nil when there is none, as with 'runc start', whose
+	// process only exits when the container fails.
+	started func() bool
+
+	once sync.Once
+	err  error
+}
+
+func (p *runtimeProcess) Wait() (int, error) {
+	status, err := p.Process.Wait()
+
+	p.once.Do(func() {
+		logged := p.collect()
+		if logged == "" {
+			return
+		}
+
+		if err == nil && p.started != nil && p.started() {
+			p.log.Info("runtime-logged-error", lager.Data{"error": logged, "status": status})
+			return
+		}
+
+		p.err = fmt.Errorf("runc %s: %s", p.operation, logged)
+	})
+
+	if p.err != nil {
+		return status, p.err
+	}
+
+	return status, err
+}
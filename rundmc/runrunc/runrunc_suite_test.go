@@ -1,6 +1,7 @@
 package runrunc_test
 
 import (
+	"github.com/docker/docker/pkg/reexec"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
@@ -8,6 +9,10 @@ import (
 )
 
 func TestRunrunc(t *testing.T) {
+	if reexec.Init() {
+		return
+	}
+
 	RegisterFailHandler(Fail)
 	RunSpecs(t, "Runrunc Suite")
 }
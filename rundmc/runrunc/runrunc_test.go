@@ -10,6 +10,7 @@ import (
 	"os/exec"
 	"path"
 	"path/filepath"
+	"strings"
 
 	"github.com/cloudfoundry-incubator/garden"
 	"github.com/cloudfoundry-incubator/goci"
@@ -34,7 +35,9 @@ var _ = Describe("RuncRunner", func() {
 		bundleLoader  *fakes.FakeBundleLoader
 		users         *fakes.FakeUserLookupper
 		mkdirer       *fakes.FakeMkdirer
+		oomScoreAdj   *fakes.FakeOomScoreAdjuster
 		bundlePath    string
+		depotPath     string
 		logger        lager.Logger
 
 		runner *runrunc.RunRunc
@@ -52,12 +55,16 @@ var _ = Describe("RuncRunner", func() {
 		bundleLoader = new(fakes.FakeBundleLoader)
 		users = new(fakes.FakeUserLookupper)
 		mkdirer = new(fakes.FakeMkdirer)
+		oomScoreAdj = new(fakes.FakeOomScoreAdjuster)
 		logger = lagertest.NewTestLogger("test")
 
 		var err error
 		bundlePath, err = ioutil.TempDir("", "bundle")
 		Expect(err).NotTo(HaveOccurred())
 
+		depotPath, err = ioutil.TempDir("", "depot")
+		Expect(err).NotTo(HaveOccurred())
+
 		runner = runrunc.New(
 			tracker,
 			commandRunner,
@@ -68,6 +75,8 @@ var _ = Describe("RuncRunner", func() {
 				users,
 				mkdirer,
 			),
+			oomScoreAdj,
+			&runrunc.RuntimeLogs{DepotPath: depotPath},
 		)
 
 		bundleLoader.LoadStub = func(path string) (*goci.Bndl, error) {
@@ -89,6 +98,18 @@ var _ = Describe("RuncRunner", func() {
 		runcBinary.KillCommandStub = func(id, signal string) *exec.Cmd {
 			return exec.Command("funC", "kill", id, signal)
 		}
+
+		runcBinary.PauseCommandStub = func(id string) *exec.Cmd {
+			return exec.Command("funC", "pause", id)
+		}
+
+		runcBinary.ResumeCommandStub = func(id string) *exec.Cmd {
+			return exec.Command("funC", "resume", id)
+		}
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(depotPath)
 	})
 
 	Describe("Start", func() {
@@ -156,6 +177,34 @@ var _ = Describe("RuncRunner", func() {
 			Expect(pidFile).To(Equal(path.Join(bundlePath, "/processes/another-process-guid.pid")))
 		})
 
+		It("adjusts the oom score of the command before the process is run", func() {
+			oomScoreAdj.AdjustStub = func(_ lager.Logger, _ string, cmd *exec.Cmd) error {
+				Expect(tracker.RunCallCount()).To(Equal(0))
+				cmd.Path = "/path/to/helper"
+				return nil
+			}
+
+			runner.Exec(logger, bundlePath, "some-id", garden.ProcessSpec{}, garden.ProcessIO{Stdout: GinkgoWriter})
+			Expect(oomScoreAdj.AdjustCallCount()).To(Equal(1))
+
+			_, adjustedBundlePath, _ := oomScoreAdj.AdjustArgsForCall(0)
+			Expect(adjustedBundlePath).To(Equal(bundlePath))
+
+			Expect(tracker.RunCallCount()).To(Equal(1))
+			_, cmd, _, _, _ := tracker.RunArgsForCall(0)
+			Expect(cmd.Path).To(Equal("/path/to/helper"))
+		})
+
+		Context("when adjusting the oom score fails", func() {
+			It("does not run the process", func() {
+				oomScoreAdj.AdjustReturns(errors.New("no bundle"))
+
+				_, err := runner.Exec(logger, bundlePath, "some-id", garden.ProcessSpec{}, garden.ProcessIO{})
+				Expect(err).To(MatchError("no bundle"))
+				Expect(tracker.RunCallCount()).To(Equal(0))
+			})
+		})
+
 		Describe("the process.json passed to 'runc exec'", func() {
 			var spec specs.Process
 
@@ -175,6 +224,19 @@ var _ = Describe("RuncRunner", func() {
 				Expect(spec.Args).To(Equal([]string{"to enlightenment", "infinity", "and beyond"}))
 			})
 
+			It("confines the process with the same apparmor profile and selinux label as the container", func() {
+				bndl := &goci.Bndl{}
+				bndl.Spec.Spec.Process.ApparmorProfile = "garden-default"
+				bndl.Spec.Spec.Process.SelinuxLabel = "system_u:system_r:svirt_lxc_net_t:s0:c1,c2"
+				bundleLoader.LoadReturns(bndl, nil)
+
+				_, err := runner.Exec(logger, "some/oci/container", "someid", garden.ProcessSpec{}, garden.ProcessIO{})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(spec.ApparmorProfile).To(Equal("garden-default"))
+				Expect(spec.SelinuxLabel).To(Equal("system_u:system_r:svirt_lxc_net_t:s0:c1,c2"))
+			})
+
 			Describe("passing the correct uid and gid", func() {
 				Context("when the bundle can be loaded", func() {
 					BeforeEach(func() {
@@ -477,6 +539,148 @@ var _ = Describe("RuncRunner", func() {
 		})
 	})
 
+	Describe("Pause", func() {
+		It("runs 'runc pause'", func() {
+			Expect(runner.Pause(logger, "some-container")).To(Succeed())
+			Expect(commandRunner).To(HaveExecutedSerially(fake_command_runner.CommandSpec{
+				Path: "funC",
+				Args: []string{"pause", "some-container"},
+			}))
+		})
+
+		It("returns any stderr output when 'runc pause' fails", func() {
+			commandRunner.WhenRunning(fake_command_runner.CommandSpec{}, func(cmd *exec.Cmd) error {
+				cmd.Stderr.Write([]byte("container not running"))
+				return errors.New("exit status 1")
+			})
+
+			Expect(runner.Pause(logger, "some-container")).To(MatchError("runc pause: exit status 1: container not running"))
+		})
+	})
+
+	Describe("Resume", func() {
+		It("runs 'runc resume'", func() {
+			Expect(runner.Resume(logger, "some-container")).To(Succeed())
+			Expect(commandRunner).To(HaveExecutedSerially(fake_command_runner.CommandSpec{
+				Path: "funC",
+				Args: []string{"resume", "some-container"},
+			}))
+		})
+
+		It("returns any stderr output when 'runc resume' fails", func() {
+			commandRunner.WhenRunning(fake_command_runner.CommandSpec{}, func(cmd *exec.Cmd) error {
+				cmd.Stderr.Write([]byte("container not paused"))
+				return errors.New("exit status 1")
+			})
+
+			Expect(runner.Resume(logger, "some-container")).To(MatchError("runc resume: exit status 1: container not paused"))
+		})
+	})
+
+	Describe("runtime logs", func() {
+		var logsTo func(lines ...string)
+
+		BeforeEach(func() {
+			Expect(os.MkdirAll(filepath.Join(depotPath, "some-container"), 0755)).To(Succeed())
+			runcBinary.LogToStub = runrunc.Runc{}.LogTo
+
+			logsTo = func(lines ...string) {
+				commandRunner.WhenRunning(fake_command_runner.CommandSpec{}, func(cmd *exec.Cmd) error {
+					Expect(cmd.Args[1]).To(Equal("--log"))
+					Expect(ioutil.WriteFile(cmd.Args[2], []byte(strings.Join(lines, "\n")), 0644)).To(Succeed())
+					return errors.New("exit status 1")
+				})
+			}
+		})
+
+		It("gives each operation a log file of its own in the container's depot directory", func() {
+			runner.Kill(logger, "some-container")
+			runner.Pause(logger, "some-container")
+			Expect(runcBinary.LogToCallCount()).To(Equal(2))
+
+			_, killLog := runcBinary.LogToArgsForCall(0)
+			_, pauseLog := runcBinary.LogToArgsForCall(1)
+			Expect(filepath.Dir(killLog)).To(Equal(filepath.Join(depotPath, "some-container")))
+			Expect(killLog).NotTo(Equal(pauseLog))
+		})
+
+		It("returns the error the runtime logged instead of its stderr", func() {
+			logsTo(
+				`time="2016-01-19T14:21:11Z" level=warning msg="signal: killed"`,
+				`time="2016-01-19T14:21:11Z" level=fatal msg="container \"some-container\" does not exist"`,
+			)
+
+			Expect(runner.Kill(logger, "some-container")).To(MatchError(`runc kill: container "some-container" does not exist`))
+		})
+
+		It("keeps what the runtime logged, and removes the operation's log file", func() {
+			logsTo(`{"level":"error","msg":"container not running","time":"2016-01-19T14:21:11Z"}`)
+			runner.Pause(logger, "some-container")
+
+			Expect(ioutil.ReadFile(filepath.Join(depotPath, "some-container", runrunc.RuntimeLogName))).To(Equal([]byte(`{"level":"error","msg":"container not running","time":"2016-01-19T14:21:11Z"}` + "\n")))
+			_, pauseLog := runcBinary.LogToArgsForCall(0)
+			Expect(pauseLog).NotTo(BeAnExistingFile())
+		})
+
+		It("still runs the operation when the container has no depot directory", func() {
+			Expect(runner.Kill(logger, "missing-container")).To(Succeed())
+			Expect(commandRunner).To(HaveExecutedSerially(fake_command_runner.CommandSpec{
+				Path: "funC",
+				Args: []string{"kill", "missing-container", "KILL"},
+			}))
+		})
+
+		Context("when the runtime's process exits", func() {
+			BeforeEach(func() {
+				tracker.RunStub = func(_ string, cmd *exec.Cmd, _ garden.ProcessIO, _ *garden.TTYSpec, _ string) (garden.Process, error) {
+					Expect(cmd.Args[1]).To(Equal("--log"))
+					Expect(ioutil.WriteFile(cmd.Args[2], []byte(`{"level":"fatal","msg":"no such file or directory"}`), 0644)).To(Succeed())
+					return exitedProcess(1), nil
+				}
+			})
+
+			It("returns the error the runtime logged from the start process' Wait", func() {
+				process, err := runner.Start(logger, bundlePath, "some-container", garden.ProcessIO{})
+				Expect(err).NotTo(HaveOccurred())
+
+				status, err := process.Wait()
+				Expect(status).To(Equal(1))
+				Expect(err).To(MatchError("runc start: no such file or directory"))
+			})
+
+			It("returns the error the runtime logged from an exec'd process' Wait, every time", func() {
+				process, err := runner.Exec(logger, bundlePath, "some-container", garden.ProcessSpec{}, garden.ProcessIO{})
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = process.Wait()
+				Expect(err).To(MatchError("runc exec: no such file or directory"))
+				_, err = process.Wait()
+				Expect(err).To(MatchError("runc exec: no such file or directory"))
+			})
+		})
+
+		Context("when an exec'd process exits after the runtime started it", func() {
+			BeforeEach(func() {
+				tracker.RunStub = func(_ string, cmd *exec.Cmd, _ garden.ProcessIO, _ *garden.TTYSpec, pidFilePath string) (garden.Process, error) {
+					Expect(ioutil.WriteFile(cmd.Args[2], []byte(`{"level":"error","msg":"signal: killed"}`), 0644)).To(Succeed())
+					Expect(os.MkdirAll(filepath.Dir(pidFilePath), 0755)).To(Succeed())
+					Expect(ioutil.WriteFile(pidFilePath, []byte("123"), 0644)).To(Succeed())
+					return exitedProcess(137), nil
+				}
+			})
+
+			It("returns its exit status, and only logs what the runtime logged", func() {
+				process, err := runner.Exec(logger, bundlePath, "some-container", garden.ProcessSpec{}, garden.ProcessIO{})
+				Expect(err).NotTo(HaveOccurred())
+
+				status, err := process.Wait()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(status).To(Equal(137))
+				Expect(logger.(*lagertest.TestLogger).LogMessages()).To(ContainElement("test.exec.runtime-logged-error"))
+			})
+		})
+	})
+
 	Describe("Watching for Events", func() {
 		var (
 			eventsCh chan bool
@@ -560,3 +764,10 @@ var _ = Describe("RuncRunner", func() {
 		})
 	})
 })
+
+type exitedProcess int
+
+func (p exitedProcess) ID() string                  { return "" }
+func (p exitedProcess) Wait() (int, error)          { return int(p), nil }
+func (p exitedProcess) SetTTY(garden.TTYSpec) error { return nil }
+func (p exitedProcess) Signal(garden.Signal) error  { return nil }
@@ -0,0 +1,174 @@
+package runrunc
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// RuntimeLogName is the file in a container's depot directory which keeps
+// the last lines the runtime logged about the container
+const RuntimeLogName = "runtime.log"
+
+// RuntimeLogs gives each runtime operation on a container a log file of its
+// own, in the container's depot directory. Once the operation is over, the
+// error the runtime failed with is parsed out of the file, and its lines are
+// added to the container's RuntimeLogName, which keeps the last MaxLines for
+// post-mortems (all of them if MaxLines is zero).
+type RuntimeLogs struct {
+	DepotPath string
+	MaxLines  int
+
+	mu sync.Mutex
+}
+
+// Create returns the path of a new, empty log file for the operation
+func (l *RuntimeLogs) Create(handle, operation string) (string, error) {
+	file, err := ioutil.TempFile(filepath.Join(l.DepotPath, handle), operation+".runtime-log.")
+	if err != nil {
+		return "", err
+	}
+
+	return file.Name(), file.Close()
+}
+
+// Collect removes the operation's log file, keeping its lines, and returns
+// the last error the runtime logged in it. It is not an error for the file
+// to be gone, e.g. because the container has been destroyed.
+func (l *RuntimeLogs) Collect(handle, logFile string) (string, error) {
+	contents, err := ioutil.ReadFile(logFile)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.Remove(logFile); err != nil {
+		return "", err
+	}
+
+	var lines []string
+	for _, line := range strings.Split(string(contents), "\n") {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	return lastError(lines), l.keep(handle, lines)
+}
+
+func (l *RuntimeLogs) keep(handle string, lines []string) error {
+	if len(lines) == 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	logPath := filepath.Join(l.DepotPath, handle, RuntimeLogName)
+	kept, err := ioutil.ReadFile(logPath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	all := append(strings.Split(strings.TrimSuffix(string(kept), "\n"), "\n"), lines...)
+	if len(kept) == 0 {
+		all = lines
+	}
+
+	if l.MaxLines > 0 && len(all) > l.MaxLines {
+		all = all[len(all)-l.MaxLines:]
+	}
+
+	return ioutil.WriteFile(logPath, []byte(strings.Join(all, "\n")+"\n"), 0644)
+}
+
+// lastError returns the message of the last error the runtime logged. Lines
+// may be JSON, as logged with '--log-format json', or logrus' default text
+// format, e.g.
+//
+//	time="2016-01-19T14:21:11Z" level=fatal msg="container \"foo\" does not exist"
+func lastError(lines []string) string {
+	for i := len(lines) - 1; i >= 0; i-- {
+		fields := parseLogLine(lines[i])
+		switch fields["level"] {
+		case "error", "fatal", "panic":
+			if fields["msg"] != "" {
+				return fields["msg"]
+			}
+		}
+	}
+
+	return ""
+}
+
+func parseLogLine(line string) map[string]string {
+	line = strings.TrimSpace(line)
+	if strings.HasPrefix(line, "{") {
+		var fields map[string]interface{}
+		if err := json.NewDecoder(bytes.NewBufferString(line)).Decode(&fields); err != nil {
+			return nil
+		}
+
+		parsed := map[string]string{}
+		for key, value := range fields {
+			if s, ok := value.(string); ok {
+				parsed[key] = s
+			}
+		}
+
+		return parsed
+	}
+
+	return parseLogfmt(line)
+}
+
+// parseLogfmt parses key=value pairs, where values may be quoted
+func parseLogfmt(line string) map[string]string {
+	fields := map[string]string{}
+	for {
+		line = strings.TrimLeft(line, " ")
+		eq := strings.IndexByte(line, '=')
+		if eq <= 0 {
+			return fields
+		}
+
+		key, rest := line[:eq], line[eq+1:]
+		if !strings.HasPrefix(rest, `"`) {
+			end := strings.IndexByte(rest, ' ')
+			if end < 0 {
+				end = len(rest)
+			}
+
+			fields[key], line = rest[:end], rest[end:]
+			continue
+		}
+
+		end := 1
+		for end < len(rest) && rest[end] != '"' {
+			if rest[end] == '\\' {
+				end++
+			}
+			end++
+		}
+
+		if end >= len(rest) {
+			fields[key] = rest[1:]
+			return fields
+		}
+
+		value, err := strconv.Unquote(rest[:end+1])
+		if err != nil {
+			value = rest[1:end]
+		}
+
+		fields[key], line = value, rest[end+1:]
+	}
+}
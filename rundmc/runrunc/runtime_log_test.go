@@ -0,0 +1,79 @@
+package runrunc_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry-incubator/guardian/rundmc/runrunc"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RuntimeLogs", func() {
+	var (
+		depotPath string
+		logs      *runrunc.RuntimeLogs
+	)
+
+	BeforeEach(func() {
+		var err error
+		depotPath, err = ioutil.TempDir("", "depot")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.MkdirAll(filepath.Join(depotPath, "some-handle"), 0755)).To(Succeed())
+
+		logs = &runrunc.RuntimeLogs{DepotPath: depotPath, MaxLines: 3}
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(depotPath)
+	})
+
+	collect := func(contents string) string {
+		logFile, err := logs.Create("some-handle", "kill")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ioutil.WriteFile(logFile, []byte(contents), 0644)).To(Succeed())
+
+		logged, err := logs.Collect("some-handle", logFile)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(logFile).NotTo(BeAnExistingFile())
+		return logged
+	}
+
+	It("creates an empty log file for the operation in the container's directory", func() {
+		logFile, err := logs.Create("some-handle", "kill")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(filepath.Dir(logFile)).To(Equal(filepath.Join(depotPath, "some-handle")))
+		Expect(ioutil.ReadFile(logFile)).To(BeEmpty())
+	})
+
+	It("returns the last error logged in the default text format", func() {
+		Expect(collect(`time="2016-01-19T14:21:11Z" level=error msg="first"
+time="2016-01-19T14:21:11Z" level=fatal msg="container \"some-handle\" does not exist"
+time="2016-01-19T14:21:12Z" level=info msg="not an error"
+`)).To(Equal(`container "some-handle" does not exist`))
+	})
+
+	It("returns the last error logged as JSON", func() {
+		Expect(collect(`{"level":"error","msg":"container not running","time":"2016-01-19T14:21:11Z"}`)).To(Equal("container not running"))
+	})
+
+	It("returns nothing when no error was logged", func() {
+		Expect(collect("garbage\n" + `level=warning msg="just a warning"`)).To(BeEmpty())
+	})
+
+	It("keeps the last MaxLines lines logged about the container", func() {
+		collect("one\ntwo\n")
+		collect("three\n\nfour\nfive")
+
+		Expect(ioutil.ReadFile(filepath.Join(depotPath, "some-handle", runrunc.RuntimeLogName))).To(Equal([]byte("three\nfour\nfive\n")))
+	})
+
+	It("does not fail when the log file has gone with the container", func() {
+		logFile, err := logs.Create("some-handle", "exec")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.RemoveAll(filepath.Join(depotPath, "some-handle"))).To(Succeed())
+
+		Expect(logs.Collect("some-handle", logFile)).To(BeEmpty())
+	})
+})
@@ -1,19 +1,47 @@
 package rundmc
 
 import (
+	"bytes"
 	"errors"
+	"fmt"
 	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
 	"time"
 
+	"github.com/cloudfoundry-incubator/garden"
 	"github.com/pivotal-golang/lager"
 )
 
+const (
+	// NotifySocketEnv tells the init process where to send its readiness
+	// notification. It is set by the bundlerules.NotifySocket rule.
+	NotifySocketEnv = "NOTIFY_SOCKET"
+
+	// ReadyMessage is the datagram init sends once it is ready
+	ReadyMessage = "READY=1"
+
+	maxStderr = 4096
+)
+
+// StartFunc starts the init process of a container, writing its output to the
+// passed writers.
+type StartFunc func(stdout, stderr io.Writer) (garden.Process, error)
+
+// StartChecker waits for a container's init process to report that it is
+// ready, by sending ReadyMessage to a unix datagram socket in the bundle
+// directory. If Expect is set, it being printed anywhere on init's stdout is
+// also accepted, for compatibility with init binaries which predate the
+// socket.
 type StartChecker struct {
-	Expect  string
-	Timeout time.Duration
+	SocketName string
+	Expect     string
+	Timeout    time.Duration
 }
 
-func (s StartChecker) Check(log lager.Logger, output io.Reader) error {
+func (s StartChecker) Check(log lager.Logger, bundlePath string, start StartFunc) error {
 	log = log.Session("check", lager.Data{
 		"expect":  s.Expect,
 		"timeout": s.Timeout,
@@ -22,20 +50,157 @@ func (s StartChecker) Check(log lager.Logger, output io.Reader) error {
 	log.Info("started")
 	defer log.Info("finished")
 
-	detected := make(chan struct{})
-	go func() {
-		buff := make([]byte, len(s.Expect))
-		io.ReadAtLeast(output, buff, len(s.Expect))
+	ready := &readiness{ch: make(chan string, 1)}
+
+	socketPath := filepath.Join(bundlePath, s.SocketName)
+	conn, err := listen(socketPath)
+	if err != nil {
+		log.Error("listen-failed", err)
+		return fmt.Errorf("start check: listen on notify socket: %s", err)
+	}
+	defer os.Remove(socketPath)
+	defer conn.Close()
 
-		if string(buff) == s.Expect {
-			close(detected)
+	go ready.watchSocket(conn)
+
+	stderr := &tailBuffer{limit: maxStderr}
+	process, err := start(&expectWriter{expect: []byte(s.Expect), ready: ready}, stderr)
+	if err != nil {
+		return err
+	}
+
+	exited := make(chan error, 1)
+	go func() {
+		status, err := process.Wait()
+		if err == nil {
+			err = fmt.Errorf("exit status %d", status)
 		}
+
+		exited <- err
 	}()
 
 	select {
-	case <-detected:
+	case via := <-ready.ch:
+		log.Info("ready", lager.Data{"via": via})
 		return nil
+	case err := <-exited:
+		return fmt.Errorf("container init exited before becoming ready: %s: %s", err, stderr)
 	case <-time.After(s.Timeout):
+		if stderr.Len() > 0 {
+			return fmt.Errorf("timed out waiting for container to start: %s", stderr)
+		}
+
 		return errors.New("timed out waiting for container to start")
 	}
 }
+
+func listen(socketPath string) (*net.UnixConn, error) {
+	// a stale socket from a previous attempt would make bind fail
+	if err := os.Remove(socketPath); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socketPath, Net: "unixgram"})
+	if err != nil {
+		return nil, err
+	}
+
+	// root in an unprivileged container is not root on the host
+	if err := os.Chmod(socketPath, 0777); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+type readiness struct {
+	once sync.Once
+	ch   chan string
+}
+
+func (r *readiness) signal(via string) {
+	r.once.Do(func() {
+		r.ch <- via
+	})
+}
+
+func (r *readiness) watchSocket(conn *net.UnixConn) {
+	buf := make([]byte, 4096)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return
+		}
+
+		for _, line := range bytes.Split(buf[:n], []byte("\n")) {
+			if string(line) == ReadyMessage {
+				r.signal("notify-socket")
+				return
+			}
+		}
+	}
+}
+
+// expectWriter signals readiness when the expected string is written,
+// regardless of how the writes are split up or what surrounds it
+type expectWriter struct {
+	expect []byte
+	ready  *readiness
+	window []byte
+	found  bool
+}
+
+func (w *expectWriter) Write(p []byte) (int, error) {
+	if len(w.expect) == 0 || w.found {
+		return len(p), nil
+	}
+
+	w.window = append(w.window, p...)
+	if bytes.Contains(w.window, w.expect) {
+		w.found = true
+		w.window = nil
+		w.ready.signal("stdout")
+		return len(p), nil
+	}
+
+	if keep := len(w.expect) - 1; len(w.window) > keep {
+		w.window = append([]byte{}, w.window[len(w.window)-keep:]...)
+	}
+
+	return len(p), nil
+}
+
+// tailBuffer keeps the last limit bytes written to it
+type tailBuffer struct {
+	limit int
+
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (t *tailBuffer) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.buf = append(t.buf, p...)
+	if len(t.buf) > t.limit {
+		t.buf = append([]byte{}, t.buf[len(t.buf)-t.limit:]...)
+	}
+
+	return len(p), nil
+}
+
+func (t *tailBuffer) Len() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return len(t.buf)
+}
+
+func (t *tailBuffer) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return string(bytes.TrimSpace(t.buf))
+}
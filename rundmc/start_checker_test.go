@@ -1,9 +1,15 @@
 package rundmc_test
 
 import (
+	"errors"
 	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
 	"time"
 
+	"github.com/cloudfoundry-incubator/garden"
 	"github.com/cloudfoundry-incubator/guardian/rundmc"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -14,37 +20,144 @@ import (
 var _ = Describe("StartChecker", func() {
 	var (
 		checker    *rundmc.StartChecker
-		pipeReader io.Reader
-		pipeWriter io.Writer
+		bundlePath string
 		logger     lager.Logger
+		exit       chan int
 	)
 
 	BeforeEach(func() {
 		logger = lagertest.NewTestLogger("test")
 		checker = &rundmc.StartChecker{
-			Expect: "potato", Timeout: 100 * time.Millisecond,
+			SocketName: "notify.sock", Expect: "potato", Timeout: 100 * time.Millisecond,
 		}
 
-		pipeReader, pipeWriter = io.Pipe()
+		var err error
+		bundlePath, err = ioutil.TempDir("", "start-checker")
+		Expect(err).NotTo(HaveOccurred())
+
+		exit = make(chan int, 1)
 	})
 
-	Context("when the expected string is output before the timeout", func() {
+	AfterEach(func() {
+		Expect(os.RemoveAll(bundlePath)).To(Succeed())
+	})
+
+	process := func() garden.Process {
+		return &waitingProcess{wait: func() (int, error) {
+			return <-exit, nil
+		}}
+	}
+
+	notify := func(message string) {
+		conn, err := net.Dial("unixgram", filepath.Join(bundlePath, "notify.sock"))
+		Expect(err).NotTo(HaveOccurred())
+		defer conn.Close()
+
+		_, err = conn.Write([]byte(message))
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	Context("when init sends the ready message to the notify socket", func() {
 		It("returns nil", func() {
-			go pipeWriter.Write([]byte("potato"))
-			Expect(checker.Check(logger, pipeReader)).To(Succeed())
+			Expect(checker.Check(logger, bundlePath, func(stdout, stderr io.Writer) (garden.Process, error) {
+				notify(rundmc.ReadyMessage)
+				return process(), nil
+			})).To(Succeed())
+		})
+
+		It("removes the socket afterwards", func() {
+			Expect(checker.Check(logger, bundlePath, func(stdout, stderr io.Writer) (garden.Process, error) {
+				notify(rundmc.ReadyMessage)
+				return process(), nil
+			})).To(Succeed())
+
+			Expect(filepath.Join(bundlePath, "notify.sock")).NotTo(BeAnExistingFile())
+		})
+	})
+
+	Context("when init sends some other message to the notify socket", func() {
+		It("returns an error", func() {
+			Expect(checker.Check(logger, bundlePath, func(stdout, stderr io.Writer) (garden.Process, error) {
+				notify("STATUS=starting")
+				return process(), nil
+			})).To(MatchError("timed out waiting for container to start"))
+		})
+	})
+
+	Context("when the expected string is output before the timeout", func() {
+		It("returns nil, even if it is surrounded by other output and split across writes", func() {
+			Expect(checker.Check(logger, bundlePath, func(stdout, stderr io.Writer) (garden.Process, error) {
+				stdout.Write([]byte("jam po"))
+				stdout.Write([]byte("tato jam"))
+				return process(), nil
+			})).To(Succeed())
+		})
+
+		Context("and compatibility mode is disabled", func() {
+			It("returns an error", func() {
+				checker.Expect = ""
+				Expect(checker.Check(logger, bundlePath, func(stdout, stderr io.Writer) (garden.Process, error) {
+					stdout.Write([]byte("potato"))
+					return process(), nil
+				})).NotTo(Succeed())
+			})
 		})
 	})
 
 	Context("when an unexpected string is output before the timeout", func() {
 		It("returns an error", func() {
-			go pipeWriter.Write([]byte("jamjamjamjam"))
-			Expect(checker.Check(logger, pipeReader)).NotTo(Succeed())
+			Expect(checker.Check(logger, bundlePath, func(stdout, stderr io.Writer) (garden.Process, error) {
+				stdout.Write([]byte("jamjamjamjam"))
+				return process(), nil
+			})).NotTo(Succeed())
 		})
 	})
 
 	Context("when no output is produced before the timeout", func() {
-		It("returns an error", func() {
-			Expect(checker.Check(logger, pipeReader)).NotTo(Succeed())
+		It("returns an error including anything init wrote to stderr", func() {
+			Expect(checker.Check(logger, bundlePath, func(stdout, stderr io.Writer) (garden.Process, error) {
+				stderr.Write([]byte("still thinking\n"))
+				return process(), nil
+			})).To(MatchError("timed out waiting for container to start: still thinking"))
+		})
+	})
+
+	Context("when init exits before becoming ready", func() {
+		It("returns an error with the exit status and stderr", func() {
+			Expect(checker.Check(logger, bundlePath, func(stdout, stderr io.Writer) (garden.Process, error) {
+				stderr.Write([]byte("no such file or directory\n"))
+				exit <- 127
+				return process(), nil
+			})).To(MatchError("container init exited before becoming ready: exit status 127: no such file or directory"))
+		})
+	})
+
+	Context("when init fails to start", func() {
+		It("returns the error", func() {
+			Expect(checker.Check(logger, bundlePath, func(stdout, stderr io.Writer) (garden.Process, error) {
+				return nil, errors.New("banana")
+			})).To(MatchError("banana"))
+		})
+	})
+
+	Context("when the notify socket cannot be created", func() {
+		It("returns an error without starting init", func() {
+			started := false
+			Expect(checker.Check(logger, filepath.Join(bundlePath, "does-not-exist"), func(stdout, stderr io.Writer) (garden.Process, error) {
+				started = true
+				return process(), nil
+			})).To(MatchError(ContainSubstring("listen on notify socket")))
+
+			Expect(started).To(BeFalse())
 		})
 	})
 })
+
+type waitingProcess struct {
+	garden.Process
+	wait func() (int, error)
+}
+
+func (p *waitingProcess) Wait() (int, error) {
+	return p.wait()
+}
@@ -4,9 +4,11 @@ import (
 	"bufio"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"os/exec"
 	"path"
+	"strings"
 
 	"github.com/cloudfoundry-incubator/guardian/logging"
 	"github.com/cloudfoundry/gunk/command_runner"
@@ -17,10 +19,15 @@ type Starter struct {
 	*CgroupStarter
 }
 
-func NewStarter(logger lager.Logger, procCgroupReader io.ReadCloser, cgroupMountpoint string, runner command_runner.CommandRunner) *Starter {
+// UnifiedControllers are the controllers which are enabled for containers
+// when the host uses the cgroup v2 unified hierarchy
+var UnifiedControllers = []string{"cpu", "cpuset", "io", "memory", "pids"}
+
+func NewStarter(logger lager.Logger, procCgroupReader io.ReadCloser, cgroupMountpoint, unifiedRoot string, runner command_runner.CommandRunner) *Starter {
 	return &Starter{
 		&CgroupStarter{
 			CgroupPath:    cgroupMountpoint,
+			UnifiedRoot:   unifiedRoot,
 			ProcCgroups:   procCgroupReader,
 			CommandRunner: runner,
 			Logger:        logger,
@@ -29,7 +36,14 @@ func NewStarter(logger lager.Logger, procCgroupReader io.ReadCloser, cgroupMount
 }
 
 type CgroupStarter struct {
-	CgroupPath    string
+	CgroupPath string
+
+	// UnifiedRoot is where the host mounts the cgroup v2 hierarchy, if it
+	// has one (usually /sys/fs/cgroup). When it is a cgroup2 mount the host's
+	// hierarchy is used as is, rather than mounting each v1 controller under
+	// CgroupPath.
+	UnifiedRoot string
+
 	CommandRunner command_runner.CommandRunner
 
 	ProcCgroups io.ReadCloser
@@ -37,9 +51,73 @@ type CgroupStarter struct {
 }
 
 func (s *CgroupStarter) Start() error {
+	if s.IsUnified() {
+		defer s.ProcCgroups.Close()
+		return enableUnifiedControllers(s.Logger, s.UnifiedRoot)
+	}
+
 	return s.mountCgroupsIfNeeded(s.Logger)
 }
 
+// IsUnified returns true if the host uses the cgroup v2 unified hierarchy
+func (s *CgroupStarter) IsUnified() bool {
+	return isUnified(s.UnifiedRoot)
+}
+
+func isUnified(unifiedRoot string) bool {
+	if unifiedRoot == "" {
+		return false
+	}
+
+	// only the root of a cgroup2 mount has this file
+	_, err := os.Stat(path.Join(unifiedRoot, "cgroup.controllers"))
+	return err == nil
+}
+
+// enableUnifiedControllers delegates the controllers containers need to the
+// children of a cgroup, e.g. the root cgroup, which is where runc creates them
+func enableUnifiedControllers(log lager.Logger, cgroup string) error {
+	log = log.Session("setup-unified-cgroup", lager.Data{
+		"path": cgroup,
+	})
+
+	log.Info("started")
+	defer log.Info("finished")
+
+	contents, err := ioutil.ReadFile(path.Join(cgroup, "cgroup.controllers"))
+	if err != nil {
+		log.Error("read-controllers-failed", err)
+		return err
+	}
+
+	available := make(map[string]bool)
+	for _, controller := range strings.Fields(string(contents)) {
+		available[controller] = true
+	}
+
+	var enable []string
+	for _, controller := range UnifiedControllers {
+		if !available[controller] {
+			log.Info("controller-unavailable", lager.Data{"controller": controller})
+			continue
+		}
+
+		enable = append(enable, "+"+controller)
+	}
+
+	if len(enable) == 0 {
+		return nil
+	}
+
+	subtreeControl := path.Join(cgroup, "cgroup.subtree_control")
+	if err := ioutil.WriteFile(subtreeControl, []byte(strings.Join(enable, " ")), 0644); err != nil {
+		log.Error("enable-controllers-failed", err, lager.Data{"controllers": enable})
+		return fmt.Errorf("enabling cgroup controllers %v: %s", enable, err)
+	}
+
+	return nil
+}
+
 func (s *CgroupStarter) mountCgroupsIfNeeded(log lager.Logger) error {
 	defer s.ProcCgroups.Close()
 	if err := os.MkdirAll(s.CgroupPath, 0755); err != nil {
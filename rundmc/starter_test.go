@@ -124,6 +124,83 @@ memory lala la`))
 		starter.Start()
 		Expect(procCgroups.closed).To(BeTrue())
 	})
+
+	Context("when the unified root is not a cgroup2 mount", func() {
+		BeforeEach(func() {
+			starter.UnifiedRoot = path.Join(tmpDir, "sys", "fs", "cgroup")
+			Expect(os.MkdirAll(starter.UnifiedRoot, 0755)).To(Succeed())
+		})
+
+		It("is not unified", func() {
+			Expect(starter.IsUnified()).To(BeFalse())
+		})
+
+		It("mounts the v1 hierarchies", func() {
+			starter.Start()
+			Expect(path.Join(tmpDir, "cgroup")).To(BeADirectory())
+		})
+	})
+
+	Context("when the host uses the unified hierarchy", func() {
+		var unifiedRoot string
+
+		BeforeEach(func() {
+			unifiedRoot = path.Join(tmpDir, "sys", "fs", "cgroup")
+			Expect(os.MkdirAll(unifiedRoot, 0755)).To(Succeed())
+			Expect(ioutil.WriteFile(path.Join(unifiedRoot, "cgroup.controllers"), []byte("cpuset cpu io memory hugetlb pids rdma\n"), 0644)).To(Succeed())
+			Expect(ioutil.WriteFile(path.Join(unifiedRoot, "cgroup.subtree_control"), []byte{}, 0644)).To(Succeed())
+
+			starter.UnifiedRoot = unifiedRoot
+
+			procCgroups.Write([]byte(
+				`header header header
+---- ---- ----
+devices 0 1 1
+memory 0 1 1`))
+		})
+
+		It("is unified", func() {
+			Expect(starter.IsUnified()).To(BeTrue())
+		})
+
+		It("enables the controllers containers need in the root cgroup", func() {
+			Expect(starter.Start()).To(Succeed())
+			Expect(ioutil.ReadFile(path.Join(unifiedRoot, "cgroup.subtree_control"))).To(Equal([]byte("+cpu +cpuset +io +memory +pids")))
+		})
+
+		It("does not mount any v1 hierarchies", func() {
+			Expect(starter.Start()).To(Succeed())
+			Expect(runner.ExecutedCommands()).To(BeEmpty())
+			Expect(path.Join(tmpDir, "cgroup")).NotTo(BeADirectory())
+		})
+
+		It("closes the procCgroups reader", func() {
+			Expect(starter.Start()).To(Succeed())
+			Expect(procCgroups.closed).To(BeTrue())
+		})
+
+		Context("when some controllers are not available", func() {
+			BeforeEach(func() {
+				Expect(ioutil.WriteFile(path.Join(unifiedRoot, "cgroup.controllers"), []byte("memory pids\n"), 0644)).To(Succeed())
+			})
+
+			It("enables only those which are", func() {
+				Expect(starter.Start()).To(Succeed())
+				Expect(ioutil.ReadFile(path.Join(unifiedRoot, "cgroup.subtree_control"))).To(Equal([]byte("+memory +pids")))
+			})
+		})
+
+		Context("when the controllers cannot be enabled", func() {
+			BeforeEach(func() {
+				Expect(os.Remove(path.Join(unifiedRoot, "cgroup.subtree_control"))).To(Succeed())
+				Expect(os.Mkdir(path.Join(unifiedRoot, "cgroup.subtree_control"), 0755)).To(Succeed())
+			})
+
+			It("returns an error", func() {
+				Expect(starter.Start()).To(MatchError(ContainSubstring("enabling cgroup controllers")))
+			})
+		})
+	})
 })
 
 type FakeReadCloser struct {
@@ -2,14 +2,23 @@ package rundmc
 
 import (
 	"encoding/json"
+	"io/ioutil"
 	"os"
 	"path"
+	"strings"
 
 	"github.com/pivotal-golang/lager"
 )
 
 type State struct {
 	Pid int `json:"init_process_pid"`
+
+	// CgroupPaths are the container's cgroups by controller, or under "" on
+	// the cgroup v2 unified hierarchy
+	CgroupPaths map[string]string `json:"cgroup_paths"`
+
+	// Paused is true when the container's processes are frozen
+	Paused bool `json:"-"`
 }
 
 type StateChecker struct {
@@ -22,9 +31,26 @@ func (s StateChecker) State(log lager.Logger, id string) (State, error) {
 		return State{}, err
 	}
 
+	state.Paused = frozen(state.CgroupPaths)
 	return state, nil
 }
 
+// frozen reads the state of the container's freezer; a container whose
+// freezer can't be read is treated as running
+func frozen(cgroupPaths map[string]string) bool {
+	if freezer, ok := cgroupPaths["freezer"]; ok {
+		contents, err := ioutil.ReadFile(path.Join(freezer, "freezer.state"))
+		return err == nil && strings.TrimSpace(string(contents)) == "FROZEN"
+	}
+
+	if unified, ok := cgroupPaths[""]; ok {
+		contents, err := ioutil.ReadFile(path.Join(unified, "cgroup.freeze"))
+		return err == nil && strings.TrimSpace(string(contents)) == "1"
+	}
+
+	return false
+}
+
 func readFromStateFile(log lager.Logger, path string) (State, error) {
 	log = log.Session("read-state-file", lager.Data{"path": path})
 	log.Info("start")
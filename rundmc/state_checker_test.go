@@ -46,6 +46,55 @@ var _ = Describe("StateChecker", func() {
 			Expect(state.Pid).To(Equal(42))
 		})
 
+		Describe("the paused state", func() {
+			var cgroup string
+
+			BeforeEach(func() {
+				cgroup = path.Join(tmp, "cgroup")
+				Expect(os.MkdirAll(cgroup, 0700)).To(Succeed())
+				Expect(os.MkdirAll(path.Join(tmp, "some-id"), 0700)).To(Succeed())
+			})
+
+			writeState := func(controller string) {
+				Expect(ioutil.WriteFile(path.Join(tmp, "some-id", "state.json"), []byte(`{"init_process_pid":42,"cgroup_paths":{"`+controller+`":"`+cgroup+`"}}`), 0700)).To(Succeed())
+			}
+
+			It("is paused when the v1 freezer is frozen", func() {
+				writeState("freezer")
+				Expect(ioutil.WriteFile(path.Join(cgroup, "freezer.state"), []byte("FROZEN\n"), 0700)).To(Succeed())
+
+				state, err := checker.State(logger, "some-id")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(state.Paused).To(BeTrue())
+			})
+
+			It("is not paused when the v1 freezer is thawed", func() {
+				writeState("freezer")
+				Expect(ioutil.WriteFile(path.Join(cgroup, "freezer.state"), []byte("THAWED\n"), 0700)).To(Succeed())
+
+				state, err := checker.State(logger, "some-id")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(state.Paused).To(BeFalse())
+			})
+
+			It("is paused when the unified cgroup is frozen", func() {
+				writeState("")
+				Expect(ioutil.WriteFile(path.Join(cgroup, "cgroup.freeze"), []byte("1\n"), 0700)).To(Succeed())
+
+				state, err := checker.State(logger, "some-id")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(state.Paused).To(BeTrue())
+			})
+
+			It("is not paused when the freezer can't be read", func() {
+				writeState("freezer")
+
+				state, err := checker.State(logger, "some-id")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(state.Paused).To(BeFalse())
+			})
+		})
+
 		Context("when the state file does not contain valid JSON", func() {
 			It("should return an error", func() {
 				Expect(os.MkdirAll(path.Join(tmp, "some-id"), 0700)).To(Succeed())
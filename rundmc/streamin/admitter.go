@@ -0,0 +1,318 @@
+// Package streamin inspects tar streams before they are extracted in to a
+// container, so that bad or oversized archives are rejected on the server
+// rather than filling the host's disk.
+package streamin
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/cloudfoundry-incubator/goci"
+	"github.com/cloudfoundry-incubator/guardian/rundmc/nstar"
+	"github.com/pivotal-golang/lager"
+)
+
+//go:generate counterfeiter . BundleLoader
+type BundleLoader interface {
+	Load(path string) (*goci.Bndl, error)
+}
+
+// Limit is a budget for streamed in data. Zero values are unlimited.
+type Limit struct {
+	Bytes   uint64
+	Entries uint64
+}
+
+type Counts struct {
+	Bytes   uint64 `json:"bytes"`
+	Entries uint64 `json:"entries"`
+}
+
+// UsageFile is kept in each container's bundle directory, so that its
+// per-container usage survives guardian restarting
+const UsageFile = "stream-in-usage.json"
+
+// RejectedError is returned when an archive is refused by the Admitter
+type RejectedError struct {
+	Entry  string
+	Reason string
+}
+
+func (e RejectedError) Error() string {
+	return fmt.Sprintf("archive rejected: %s: %s", e.Entry, e.Reason)
+}
+
+// Admitter filters stream-in archives. Each entry is checked before it is
+// passed on, so nothing from a rejected entry reaches the container.
+// Per-container usage is saved in the container's bundle directory after
+// each successful request, and is only dropped from memory by Forget.
+type Admitter struct {
+	BundleLoader BundleLoader
+
+	PerRequest   Limit
+	PerContainer Limit
+
+	mu    sync.Mutex
+	usage map[string]Counts
+}
+
+func New(loader BundleLoader, perRequest, perContainer Limit) *Admitter {
+	return &Admitter{
+		BundleLoader: loader,
+		PerRequest:   perRequest,
+		PerContainer: perContainer,
+		usage:        make(map[string]Counts),
+	}
+}
+
+// Admit returns a stream which yields the admitted (uncompressed) archive.
+// The returned function must be called with the result of extracting the
+// stream once it has been consumed. It returns a RejectedError if the archive
+// was refused, or any other error from filtering it. What the request used is
+// only kept if the archive was admitted and extracted.
+func (a *Admitter) Admit(log lager.Logger, handle, bundlePath string, tarStream io.Reader) (io.Reader, func(extractErr error) error) {
+	log = log.Session("admit", lager.Data{"handle": handle})
+
+	privileged, err := a.privileged(bundlePath)
+	if err != nil {
+		// fall back to the stricter, unprivileged, rules
+		log.Error("load-bundle-failed", err)
+	}
+
+	pr, pw := io.Pipe()
+	result := make(chan admission, 1)
+
+	go func() {
+		counts, err := a.filter(handle, bundlePath, privileged, tarStream, pw)
+		pw.CloseWithError(err)
+
+		data := lager.Data{"bytes": counts.Bytes, "entries": counts.Entries, "privileged": privileged}
+		if err != nil && err != io.ErrClosedPipe {
+			log.Error("rejected", err, data)
+		} else {
+			log.Info("admitted", data)
+		}
+
+		result <- admission{counts: counts, err: err}
+	}()
+
+	return pr, func(extractErr error) error {
+		// unblock the filter if the consumer gave up early
+		pr.Close()
+
+		admitted := <-result
+
+		// the filter sees a closed pipe when the extraction finished while it
+		// was still flushing, so what it admitted was extracted
+		if admitted.err == io.ErrClosedPipe {
+			admitted.err = nil
+		}
+
+		if admitted.err != nil || extractErr != nil {
+			a.release(handle, admitted.counts)
+		} else if err := a.save(handle, bundlePath); err != nil {
+			log.Error("save-usage-failed", err)
+		}
+
+		return admitted.err
+	}
+}
+
+type admission struct {
+	counts Counts
+	err    error
+}
+
+// Forget discards the per-container usage for the handle
+func (a *Admitter) Forget(handle string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	delete(a.usage, handle)
+}
+
+func (a *Admitter) privileged(bundlePath string) (bool, error) {
+	bndl, err := a.BundleLoader.Load(bundlePath)
+	if err != nil {
+		return false, err
+	}
+
+	return len(bndl.Spec.Linux.UIDMappings) == 0, nil
+}
+
+// filter copies the admitted entries from r to w, returning the counts it
+// reserved against the container's budget
+func (a *Admitter) filter(handle, bundlePath string, privileged bool, r io.Reader, w io.Writer) (Counts, error) {
+	var counts Counts
+
+	stream, err := nstar.Decompress(r)
+	if err != nil {
+		return counts, err
+	}
+
+	tr := tar.NewReader(stream)
+	tw := tar.NewWriter(w)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return counts, tw.Close()
+		}
+
+		if err != nil {
+			return counts, err
+		}
+
+		if err := check(hdr, privileged); err != nil {
+			return counts, err
+		}
+
+		size := uint64(0)
+		if hdr.Typeflag == tar.TypeReg || hdr.Typeflag == tar.TypeRegA {
+			size = uint64(hdr.Size)
+		}
+
+		if err := a.reserve(handle, bundlePath, counts, hdr.Name, size); err != nil {
+			return counts, err
+		}
+
+		counts.Entries++
+		counts.Bytes += size
+
+		if err := tw.WriteHeader(hdr); err != nil {
+			return counts, err
+		}
+
+		if _, err := io.Copy(tw, tr); err != nil {
+			return counts, err
+		}
+	}
+}
+
+// reserve accounts for an entry against both budgets, failing without
+// reserving anything if either would be exceeded.
+func (a *Admitter) reserve(handle, bundlePath string, request Counts, entry string, size uint64) error {
+	if exceeds(a.PerRequest, request, size) {
+		return RejectedError{Entry: entry, Reason: "exceeds the per-request stream-in limit"}
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	usage, ok := a.usage[handle]
+	if !ok {
+		var err error
+		if usage, err = loadUsage(bundlePath); err != nil {
+			return err
+		}
+	}
+
+	if exceeds(a.PerContainer, usage, size) {
+		a.usage[handle] = usage
+		return RejectedError{Entry: entry, Reason: "exceeds the per-container stream-in limit"}
+	}
+
+	a.usage[handle] = Counts{Bytes: usage.Bytes + size, Entries: usage.Entries + 1}
+	return nil
+}
+
+// release gives back what a failed request reserved
+func (a *Admitter) release(handle string, reserved Counts) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	usage, ok := a.usage[handle]
+	if !ok {
+		return
+	}
+
+	a.usage[handle] = Counts{Bytes: usage.Bytes - reserved.Bytes, Entries: usage.Entries - reserved.Entries}
+}
+
+// save writes the container's usage to its bundle directory, replacing the
+// previous file in one step so that it is never left half written
+func (a *Admitter) save(handle, bundlePath string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	usage, ok := a.usage[handle]
+	if !ok {
+		return nil
+	}
+
+	data, err := json.Marshal(usage)
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(bundlePath, UsageFile)
+	if err := ioutil.WriteFile(path+".tmp", data, 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(path+".tmp", path)
+}
+
+func loadUsage(bundlePath string) (Counts, error) {
+	var usage Counts
+
+	data, err := ioutil.ReadFile(filepath.Join(bundlePath, UsageFile))
+	if os.IsNotExist(err) {
+		return usage, nil
+	}
+
+	if err != nil {
+		return usage, fmt.Errorf("load stream-in usage: %s", err)
+	}
+
+	if err := json.Unmarshal(data, &usage); err != nil {
+		return usage, fmt.Errorf("load stream-in usage: %s", err)
+	}
+
+	return usage, nil
+}
+
+func exceeds(limit Limit, counts Counts, size uint64) bool {
+	if limit.Entries > 0 && counts.Entries+1 > limit.Entries {
+		return true
+	}
+
+	return limit.Bytes > 0 && counts.Bytes+size > limit.Bytes
+}
+
+func check(hdr *tar.Header, privileged bool) error {
+	if err := checkPath(hdr.Name); err != nil {
+		return err
+	}
+
+	switch hdr.Typeflag {
+	case tar.TypeLink:
+		return checkPath(hdr.Linkname)
+	case tar.TypeChar, tar.TypeBlock, tar.TypeFifo:
+		if !privileged {
+			return RejectedError{Entry: hdr.Name, Reason: "special files are only permitted in privileged containers"}
+		}
+	}
+
+	return nil
+}
+
+func checkPath(name string) error {
+	if filepath.IsAbs(name) {
+		return RejectedError{Entry: name, Reason: "absolute paths are not permitted"}
+	}
+
+	for _, component := range strings.Split(filepath.ToSlash(name), "/") {
+		if component == ".." {
+			return RejectedError{Entry: name, Reason: "paths containing '..' are not permitted"}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,300 @@
+package streamin_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry-incubator/goci"
+	"github.com/cloudfoundry-incubator/guardian/rundmc/streamin"
+	"github.com/cloudfoundry-incubator/guardian/rundmc/streamin/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/opencontainers/specs"
+	"github.com/pivotal-golang/lager/lagertest"
+)
+
+var _ = Describe("Admitter", func() {
+	var (
+		bundleLoader *fakes.FakeBundleLoader
+		perRequest   streamin.Limit
+		perContainer streamin.Limit
+		logger       *lagertest.TestLogger
+		bundlePath   string
+	)
+
+	BeforeEach(func() {
+		var err error
+		bundlePath, err = ioutil.TempDir("", "bundle")
+		Expect(err).NotTo(HaveOccurred())
+
+		bundleLoader = new(fakes.FakeBundleLoader)
+		bundleLoader.LoadStub = func(path string) (*goci.Bndl, error) {
+			bndl := &goci.Bndl{}
+			bndl.Spec.Linux.UIDMappings = []specs.IDMapping{{HostID: 1000, ContainerID: 0, Size: 1}}
+			return bndl, nil
+		}
+
+		perRequest = streamin.Limit{}
+		perContainer = streamin.Limit{}
+		logger = lagertest.NewTestLogger("test")
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(bundlePath)).To(Succeed())
+	})
+
+	admit := func(handle string, stream io.Reader) ([]byte, error) {
+		admitter := streamin.New(bundleLoader, perRequest, perContainer)
+		admitted, done := admitter.Admit(logger, handle, bundlePath, stream)
+		contents, _ := ioutil.ReadAll(admitted)
+		return contents, done(nil)
+	}
+
+	It("loads the container's bundle", func() {
+		_, err := admit("some-handle", archive(entry{name: "some-file", contents: "hello"}))
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(bundleLoader.LoadCallCount()).To(Equal(1))
+		Expect(bundleLoader.LoadArgsForCall(0)).To(Equal(bundlePath))
+	})
+
+	It("passes on the archive's entries", func() {
+		contents, err := admit("some-handle", archive(
+			entry{name: "some-dir/", dir: true},
+			entry{name: "some-dir/some-file", contents: "hello"},
+		))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries(contents)).To(Equal(map[string]string{
+			"some-dir/":          "",
+			"some-dir/some-file": "hello",
+		}))
+	})
+
+	It("decompresses gzipped archives", func() {
+		compressed := new(bytes.Buffer)
+		gz := gzip.NewWriter(compressed)
+		io.Copy(gz, archive(entry{name: "some-file", contents: "hello"}))
+		Expect(gz.Close()).To(Succeed())
+
+		contents, err := admit("some-handle", compressed)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries(contents)).To(Equal(map[string]string{"some-file": "hello"}))
+	})
+
+	It("logs the number of bytes and entries admitted", func() {
+		_, err := admit("some-handle", archive(
+			entry{name: "a", contents: "hello"},
+			entry{name: "b", contents: "world!"},
+		))
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(logger).Should(gbytes.Say(`"bytes":11,"entries":2`))
+	})
+
+	Context("with a per-request limit", func() {
+		It("rejects archives with too many bytes", func() {
+			perRequest = streamin.Limit{Bytes: 10}
+
+			contents, err := admit("some-handle", archive(
+				entry{name: "a", contents: "hello"},
+				entry{name: "b", contents: "world!"},
+			))
+			Expect(err).To(MatchError(streamin.RejectedError{Entry: "b", Reason: "exceeds the per-request stream-in limit"}))
+			Expect(entries(contents)).NotTo(HaveKey("b"))
+		})
+
+		It("rejects archives with too many entries", func() {
+			perRequest = streamin.Limit{Entries: 1}
+
+			_, err := admit("some-handle", archive(
+				entry{name: "a", contents: "hello"},
+				entry{name: "b", contents: "world"},
+			))
+			Expect(err).To(BeAssignableToTypeOf(streamin.RejectedError{}))
+		})
+
+		It("admits archives within the limit", func() {
+			perRequest = streamin.Limit{Bytes: 10, Entries: 2}
+
+			_, err := admit("some-handle", archive(
+				entry{name: "a", contents: "hello"},
+				entry{name: "b", contents: "world"},
+			))
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("with a per-container limit", func() {
+		var admitter *streamin.Admitter
+
+		BeforeEach(func() {
+			admitter = streamin.New(bundleLoader, streamin.Limit{}, streamin.Limit{Bytes: 8})
+		})
+
+		admitTo := func(handle string, stream io.Reader) error {
+			admitted, done := admitter.Admit(logger, handle, bundlePath, stream)
+			ioutil.ReadAll(admitted)
+			return done(nil)
+		}
+
+		It("accumulates usage across requests", func() {
+			Expect(admitTo("some-handle", archive(entry{name: "a", contents: "hello"}))).To(Succeed())
+			Expect(admitTo("some-handle", archive(entry{name: "b", contents: "world"}))).To(MatchError(streamin.RejectedError{
+				Entry:  "b",
+				Reason: "exceeds the per-container stream-in limit",
+			}))
+		})
+
+		It("tracks each container separately", func() {
+			Expect(admitTo("some-handle", archive(entry{name: "a", contents: "hello"}))).To(Succeed())
+
+			anotherBundle, err := ioutil.TempDir("", "bundle")
+			Expect(err).NotTo(HaveOccurred())
+			defer os.RemoveAll(anotherBundle)
+
+			admitted, done := admitter.Admit(logger, "another-handle", anotherBundle, archive(entry{name: "b", contents: "world"}))
+			ioutil.ReadAll(admitted)
+			Expect(done(nil)).To(Succeed())
+		})
+
+		It("does not count a request whose extraction failed", func() {
+			admitted, done := admitter.Admit(logger, "some-handle", bundlePath, archive(entry{name: "a", contents: "hello"}))
+			ioutil.ReadAll(admitted)
+			Expect(done(errors.New("disk full"))).To(Succeed())
+
+			Expect(admitTo("some-handle", archive(entry{name: "b", contents: "world"}))).To(Succeed())
+		})
+
+		It("counts a request whose extraction finished before the archive was flushed", func() {
+			_, done := admitter.Admit(logger, "some-handle", bundlePath, archive(entry{name: "a", contents: "hello"}))
+			Expect(done(nil)).To(Succeed())
+
+			Expect(admitTo("some-handle", archive(entry{name: "b", contents: "world"}))).To(MatchError(streamin.RejectedError{
+				Entry:  "b",
+				Reason: "exceeds the per-container stream-in limit",
+			}))
+		})
+
+		It("does not count the entries admitted before an archive was rejected", func() {
+			Expect(admitTo("some-handle", archive(
+				entry{name: "a", contents: "hello"},
+				entry{name: "b", contents: "world"},
+			))).To(BeAssignableToTypeOf(streamin.RejectedError{}))
+
+			Expect(admitTo("some-handle", archive(entry{name: "c", contents: "hello"}))).To(Succeed())
+		})
+
+		It("keeps the usage in the bundle directory, so that it survives a restart", func() {
+			Expect(admitTo("some-handle", archive(entry{name: "a", contents: "hello"}))).To(Succeed())
+			Expect(ioutil.ReadFile(filepath.Join(bundlePath, streamin.UsageFile))).To(MatchJSON(`{"bytes": 5, "entries": 1}`))
+
+			admitter = streamin.New(bundleLoader, streamin.Limit{}, streamin.Limit{Bytes: 8})
+			Expect(admitTo("some-handle", archive(entry{name: "b", contents: "world"}))).To(MatchError(streamin.RejectedError{
+				Entry:  "b",
+				Reason: "exceeds the per-container stream-in limit",
+			}))
+		})
+
+		It("refuses the stream when the saved usage can't be read", func() {
+			Expect(ioutil.WriteFile(filepath.Join(bundlePath, streamin.UsageFile), []byte("{"), 0600)).To(Succeed())
+			Expect(admitTo("some-handle", archive(entry{name: "a", contents: "hello"}))).To(MatchError(ContainSubstring("load stream-in usage")))
+		})
+
+		It("resets the usage when the container is forgotten and its bundle is destroyed", func() {
+			Expect(admitTo("some-handle", archive(entry{name: "a", contents: "hello"}))).To(Succeed())
+			admitter.Forget("some-handle")
+			Expect(os.Remove(filepath.Join(bundlePath, streamin.UsageFile))).To(Succeed())
+			Expect(admitTo("some-handle", archive(entry{name: "b", contents: "world"}))).To(Succeed())
+		})
+	})
+
+	DescribeTable("rejecting unsafe entries",
+		func(e entry) {
+			_, err := admit("some-handle", archive(e))
+			Expect(err).To(BeAssignableToTypeOf(streamin.RejectedError{}))
+		},
+		Entry("absolute paths", entry{name: "/etc/passwd", contents: "root"}),
+		Entry("parent directory references", entry{name: "a/../../etc/passwd", contents: "root"}),
+		Entry("hardlinks outside the destination", entry{name: "a", hardlink: "../../etc/passwd"}),
+		Entry("absolute hardlinks", entry{name: "a", hardlink: "/etc/passwd"}),
+	)
+
+	Describe("special files", func() {
+		It("rejects them in unprivileged containers", func() {
+			_, err := admit("some-handle", archive(entry{name: "null", typeflag: tar.TypeChar}))
+			Expect(err).To(MatchError(streamin.RejectedError{
+				Entry:  "null",
+				Reason: "special files are only permitted in privileged containers",
+			}))
+		})
+
+		It("admits them in privileged containers", func() {
+			bundleLoader.LoadReturns(&goci.Bndl{}, nil)
+
+			_, err := admit("some-handle", archive(entry{name: "null", typeflag: tar.TypeChar}))
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+})
+
+type entry struct {
+	name     string
+	contents string
+	dir      bool
+	hardlink string
+	typeflag byte
+}
+
+func archive(entries ...entry) io.Reader {
+	buf := new(bytes.Buffer)
+	tw := tar.NewWriter(buf)
+
+	for _, e := range entries {
+		hdr := &tar.Header{Name: e.name, Mode: 0644, Typeflag: tar.TypeReg, Size: int64(len(e.contents))}
+		switch {
+		case e.dir:
+			hdr.Typeflag = tar.TypeDir
+			hdr.Mode = 0755
+		case e.hardlink != "":
+			hdr.Typeflag = tar.TypeLink
+			hdr.Linkname = e.hardlink
+		case e.typeflag != 0:
+			hdr.Typeflag = e.typeflag
+		}
+
+		if hdr.Typeflag != tar.TypeReg {
+			hdr.Size = 0
+		}
+
+		Expect(tw.WriteHeader(hdr)).To(Succeed())
+		_, err := tw.Write([]byte(e.contents))
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	Expect(tw.Close()).To(Succeed())
+	return buf
+}
+
+func entries(contents []byte) map[string]string {
+	result := make(map[string]string)
+
+	tr := tar.NewReader(bytes.NewReader(contents))
+	for {
+		hdr, err := tr.Next()
+		if err != nil {
+			return result
+		}
+
+		data, err := ioutil.ReadAll(tr)
+		Expect(err).NotTo(HaveOccurred())
+		result[hdr.Name] = string(data)
+	}
+}
@@ -0,0 +1,13 @@
+package streamin_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestStreamin(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Streamin Suite")
+}
@@ -0,0 +1,114 @@
+package sysinfo
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	DefaultOnlineCPUs        = "/sys/devices/system/cpu/online"
+	DefaultOnlineMemoryNodes = "/sys/devices/system/node/online"
+)
+
+// ParseCPUList parses a list in the kernel's cpuset format (e.g. "0-3,8"),
+// returning the sorted, distinct numbers in it
+func ParseCPUList(list string) ([]int, error) {
+	return ParseCPUListWithin(list, math.MaxInt32)
+}
+
+// ParseCPUListWithin is ParseCPUList for untrusted lists: it rejects numbers
+// above max before expanding any ranges, so that a range like
+// "0-2000000000" can't exhaust memory
+func ParseCPUListWithin(list string, max int) ([]int, error) {
+	seen := make(map[int]bool)
+	for _, item := range strings.Split(strings.TrimSpace(list), ",") {
+		if item == "" {
+			continue
+		}
+
+		first, last := item, item
+		if i := strings.Index(item, "-"); i >= 0 {
+			first, last = item[:i], item[i+1:]
+		}
+
+		start, err := strconv.Atoi(first)
+		if err != nil || start < 0 {
+			return nil, fmt.Errorf("invalid cpu list %q", list)
+		}
+
+		end, err := strconv.Atoi(last)
+		if err != nil || end < start {
+			return nil, fmt.Errorf("invalid cpu list %q", list)
+		}
+
+		if end > max {
+			return nil, fmt.Errorf("invalid cpu list %q: %d is above the highest allowed, %d", list, end, max)
+		}
+
+		for n := start; n <= end; n++ {
+			seen[n] = true
+		}
+	}
+
+	var numbers []int
+	for n := range seen {
+		numbers = append(numbers, n)
+	}
+	sort.Ints(numbers)
+
+	return numbers, nil
+}
+
+// FormatCPUList formats numbers in the kernel's cpuset format, collapsing
+// runs in to ranges
+func FormatCPUList(numbers []int) string {
+	sorted := append([]int{}, numbers...)
+	sort.Ints(sorted)
+
+	var items []string
+	for i := 0; i < len(sorted); {
+		j := i
+		for j+1 < len(sorted) && sorted[j+1] <= sorted[j]+1 {
+			j++
+		}
+
+		if sorted[i] == sorted[j] {
+			items = append(items, strconv.Itoa(sorted[i]))
+		} else {
+			items = append(items, fmt.Sprintf("%d-%d", sorted[i], sorted[j]))
+		}
+
+		i = j + 1
+	}
+
+	return strings.Join(items, ",")
+}
+
+func (provider Provider) CPUs() ([]int, error) {
+	return readCPUList(provider.onlineCPUsPath)
+}
+
+// MemoryNodes returns the online NUMA memory nodes, or just node 0 on
+// hosts without NUMA support
+func (provider Provider) MemoryNodes() ([]int, error) {
+	nodes, err := readCPUList(provider.onlineMemoryNodesPath)
+	if os.IsNotExist(err) {
+		return []int{0}, nil
+	}
+
+	return nodes, err
+}
+
+func readCPUList(path string) ([]int, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseCPUList(string(contents))
+}
@@ -0,0 +1,57 @@
+package sysinfo_test
+
+import (
+	"github.com/cloudfoundry-incubator/guardian/sysinfo"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CPU lists", func() {
+	DescribeTable("ParseCPUList",
+		func(list string, numbers []int) {
+			Expect(sysinfo.ParseCPUList(list)).To(Equal(numbers))
+		},
+		Entry("a single cpu", "3", []int{3}),
+		Entry("a range", "0-3", []int{0, 1, 2, 3}),
+		Entry("ranges and cpus", "0-1,4,6-7\n", []int{0, 1, 4, 6, 7}),
+		Entry("overlapping and unsorted items", "4,0-2,1", []int{0, 1, 2, 4}),
+	)
+
+	DescribeTable("rejecting invalid lists",
+		func(list string) {
+			_, err := sysinfo.ParseCPUList(list)
+			Expect(err).To(MatchError(ContainSubstring("invalid cpu list")))
+		},
+		Entry("a word", "banana"),
+		Entry("a backwards range", "3-1"),
+		Entry("an open range", "1-"),
+		Entry("a negative cpu", "-1"),
+	)
+
+	Describe("ParseCPUListWithin", func() {
+		It("parses lists within the maximum", func() {
+			Expect(sysinfo.ParseCPUListWithin("0-3,7", 7)).To(Equal([]int{0, 1, 2, 3, 7}))
+		})
+
+		It("rejects ranges beyond the maximum without expanding them", func() {
+			_, err := sysinfo.ParseCPUListWithin("0-2000000000", 7)
+			Expect(err).To(MatchError(ContainSubstring("2000000000 is above the highest allowed, 7")))
+		})
+
+		It("rejects single numbers beyond the maximum", func() {
+			_, err := sysinfo.ParseCPUListWithin("1,8", 7)
+			Expect(err).To(MatchError(ContainSubstring("8 is above the highest allowed, 7")))
+		})
+	})
+
+	DescribeTable("FormatCPUList",
+		func(numbers []int, list string) {
+			Expect(sysinfo.FormatCPUList(numbers)).To(Equal(list))
+		},
+		Entry("nothing", []int{}, ""),
+		Entry("a single cpu", []int{3}, "3"),
+		Entry("runs and gaps", []int{7, 0, 1, 2, 4, 6}, "0-2,4,6-7"),
+	)
+})
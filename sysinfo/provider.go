@@ -3,12 +3,16 @@ package sysinfo
 import "github.com/cloudfoundry/gosigar"
 
 type Provider struct {
-	depotPath string
+	depotPath             string
+	onlineCPUsPath        string
+	onlineMemoryNodesPath string
 }
 
 func NewProvider(depotPath string) Provider {
 	return Provider{
-		depotPath: depotPath,
+		depotPath:             depotPath,
+		onlineCPUsPath:        DefaultOnlineCPUs,
+		onlineMemoryNodesPath: DefaultOnlineMemoryNodes,
 	}
 }
 
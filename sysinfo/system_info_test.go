@@ -35,4 +35,30 @@ var _ = Describe("SystemInfo", func() {
 			Expect(totalDisk).To(BeNumerically(">", 0))
 		})
 	})
+
+	Describe("CPUs", func() {
+		BeforeEach(func() {
+			provider = sysinfo.NewProvider("/")
+		})
+
+		It("provides the online CPUs", func() {
+			cpus, err := provider.CPUs()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(cpus).To(ContainElement(0))
+		})
+	})
+
+	Describe("MemoryNodes", func() {
+		BeforeEach(func() {
+			provider = sysinfo.NewProvider("/")
+		})
+
+		It("provides the online memory nodes", func() {
+			nodes, err := provider.MemoryNodes()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(nodes).To(ContainElement(0))
+		})
+	})
 })